@@ -1,19 +1,23 @@
 package main
 
 import (
-	"encoding/json"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"strconv"
-	"strings"
+	"time"
+	_ "time/tzdata" // 内嵌时区数据，保证精简镜像中也能解析用户时区
 
+	"github.com/joy_project/todo-list-backend/internal/api"
 	"github.com/joy_project/todo-list-backend/internal/auth"
+	"github.com/joy_project/todo-list-backend/internal/blob"
+	"github.com/joy_project/todo-list-backend/internal/config"
 	"github.com/joy_project/todo-list-backend/internal/database"
-	"github.com/joy_project/todo-list-backend/internal/middleware"
-	"github.com/joy_project/todo-list-backend/internal/models"
-	"github.com/joy_project/todo-list-backend/internal/validator"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/joy_project/todo-list-backend/internal/mail"
+	"github.com/joy_project/todo-list-backend/internal/memstore"
+	"github.com/joy_project/todo-list-backend/internal/migrate"
+	"github.com/joy_project/todo-list-backend/internal/store"
 )
 
 var logger *log.Logger
@@ -23,340 +27,153 @@ func init() {
 }
 
 func main() {
-	database.InitDB()
-
-	// 公共路由
-	http.HandleFunc("/register", middleware.CORS(logRequest(handleRegister)))
-	http.HandleFunc("/login", middleware.CORS(logRequest(handleLogin)))
-
-	// 需要认证的路由
-	http.HandleFunc("/todos", middleware.CORS(logRequest(authHandler(handleTodos))))
-	http.HandleFunc("/todos/", middleware.CORS(logRequest(authHandler(handleTodo))))
-
-	logger.Println("Server starting on port 8081...")
-	log.Fatal(http.ListenAndServe(":8081", nil))
-}
-
-func logRequest(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		logger.Printf("%s %s", r.Method, r.URL.Path)
-		next.ServeHTTP(w, r)
-	}
-}
-
-// 认证处理器包装器
-func authHandler(next http.HandlerFunc) http.HandlerFunc {
-	return middleware.Auth(next)
-}
-
-// 用户注册处理
-func handleRegister(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	var req models.RegisterRequest
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		logger.Printf("Error decoding register request: %v", err)
-		http.Error(w, "Bad request", http.StatusBadRequest)
-		return
-	}
-
-	errors := validator.ValidateRegister(req)
-	if len(errors) > 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(errors)
-		return
-	}
-
-	userID, err := database.CreateUser(req)
-	if err != nil {
-		logger.Printf("Error creating user: %v", err)
-		if strings.Contains(err.Error(), "already exists") {
-			http.Error(w, err.Error(), http.StatusConflict)
-		} else {
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+	configPath := flag.String("config", "", "path to a YAML config file (defaults to $CONFIG_FILE)")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if cfg.Auth.Algorithm == config.AlgHS256 && cfg.Auth.JWTSecret == config.PlaceholderJWTSecret {
+		logger.Println("WARNING: using the placeholder JWT secret, set JWT_SECRET before deploying")
+	}
+
+	var st store.Store
+	var blobs blob.Store
+	if cfg.Database.Driver == "memory" {
+		// 演示模式：数据只保存在进程内，重启后丢失
+		st = memstore.New()
+		blobs = blob.NewMemory()
+		logger.Println("Using in-memory store, data will be lost on exit")
+	} else {
+		db, err := database.Open(database.Options{
+			Driver:          cfg.Database.Driver,
+			DSN:             cfg.Database.DSN,
+			MaxOpenConns:    cfg.Database.MaxOpenConns,
+			MaxIdleConns:    cfg.Database.MaxIdleConns,
+			ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		})
+		if err != nil {
+			log.Fatal(err)
 		}
-		return
-	}
+		defer db.Close()
+		logger.Printf("Connected to the %s database successfully", cfg.Database.Driver)
 
-	user, err := database.GetUserByID(int(userID))
-	if err != nil {
-		logger.Printf("Error getting user: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	token, err := auth.GenerateToken(user)
-	if err != nil {
-		logger.Printf("Error generating token: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	response := models.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		Token:     token,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
-}
-
-// 用户登录处理
-func handleLogin(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	var req models.LoginRequest
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		logger.Printf("Error decoding login request: %v", err)
-		http.Error(w, "Bad request", http.StatusBadRequest)
-		return
-	}
+		if err := checkMigrations(db, cfg.Database); err != nil {
+			log.Fatal(err)
+		}
+		st = db
 
-	errors := validator.ValidateLogin(req)
-	if len(errors) > 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(errors)
-		return
+		disk, err := blob.NewDisk(cfg.Attachments.Dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		blobs = disk
+		logger.Printf("Storing attachments in %s", cfg.Attachments.Dir)
 	}
 
-	user, err := database.GetUserByEmail(req.Email)
+	tokens, err := newTokenManager(cfg.Auth)
 	if err != nil {
-		logger.Printf("Error getting user: %v", err)
-		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
-		return
+		log.Fatal(err)
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+	mailer, err := newMailer(cfg.Mail)
 	if err != nil {
-		logger.Printf("Invalid password: %v", err)
-		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
-		return
+		log.Fatal(err)
 	}
 
-	token, err := auth.GenerateToken(user)
-	if err != nil {
-		logger.Printf("Error generating token: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+	server := api.NewServer(st, blobs, tokens, mailer, cfg, logger)
+	if cfg.Todos.RebalanceInterval > 0 {
+		go rebalancePositions(st, cfg.Todos.RebalanceInterval)
 	}
 
-	response := models.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		Token:     token,
+	httpServer := &http.Server{
+		Addr:         cfg.Server.Addr,
+		Handler:      server.Handler(),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	logger.Printf("Server starting on %s (%s mode)...", cfg.Server.Addr, cfg.Environment)
+	log.Fatal(httpServer.ListenAndServe())
 }
 
-func handleTodos(w http.ResponseWriter, r *http.Request) {
-	userID, ok := middleware.GetUserID(r)
-	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	switch r.Method {
-	case http.MethodGet:
-		// 检查是否请求分页
-		if r.URL.Query().Get("page") != "" {
-			getTodosWithPagination(w, r, userID)
-		} else {
-			getTodos(w, r, userID)
+// rebalancePositions 每隔 interval 把间隔过小的手动排序重新编号
+func rebalancePositions(st store.Store, interval time.Duration) {
+	for range time.Tick(interval) {
+		n, err := st.RebalancePositions()
+		if err != nil {
+			logger.Printf("Error rebalancing todo positions: %v", err)
+			continue
+		}
+		if n > 0 {
+			logger.Printf("Rebalanced the positions of %d sibling group(s)", n)
 		}
-	case http.MethodPost:
-		createTodo(w, r, userID)
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
-func handleTodo(w http.ResponseWriter, r *http.Request) {
-	userID, ok := middleware.GetUserID(r)
-	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	switch r.Method {
-	case http.MethodPut:
-		updateTodo(w, r, userID)
-	case http.MethodDelete:
-		deleteTodo(w, r, userID)
+// newMailer 按配置的驱动创建邮件发送器
+func newMailer(mailCfg config.MailConfig) (mail.Mailer, error) {
+	switch mailCfg.Driver {
+	case config.MailSMTP:
+		logger.Printf("Sending mail via SMTP server %s:%d", mailCfg.SMTPHost, mailCfg.SMTPPort)
+		return mail.NewSMTP(mailCfg.SMTPHost, mailCfg.SMTPPort, mailCfg.SMTPUsername, mailCfg.SMTPPassword, mailCfg.From)
+	case config.MailFile:
+		logger.Printf("Writing mail to %s", mailCfg.Dir)
+		return mail.NewFile(mailCfg.Dir, mailCfg.From)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	}
-}
-
-func getTodos(w http.ResponseWriter, r *http.Request, userID int) {
-	todos, err := database.GetAllTodos(userID)
-	if err != nil {
-		logger.Printf("Error getting todos: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		logger.Printf("Writing mail to the log, configure mail.driver to send real mail")
+		return mail.NewLog(logger), nil
 	}
-
-	logger.Printf("Retrieved %d todos for user %d", len(todos), userID)
-	for _, todo := range todos {
-		logger.Printf("Todo: ID=%d, Title=%s, Completed=%v, Priority=%s", todo.ID, todo.Title, todo.Completed, todo.Priority)
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(todos)
 }
 
-func getTodosWithPagination(w http.ResponseWriter, r *http.Request, userID int) {
-	// 解析分页参数
-	pageStr := r.URL.Query().Get("page")
-	pageSizeStr := r.URL.Query().Get("pageSize")
-
-	page := 1
-	pageSize := 10
-
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
-	}
-
-	if pageSizeStr != "" {
-		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
-			pageSize = ps
-		}
+// newTokenManager 按配置的算法创建令牌管理器，RS256 和 EdDSA 从 keys_dir 加载密钥库
+func newTokenManager(authCfg config.AuthConfig) (*auth.Manager, error) {
+	if authCfg.Algorithm == config.AlgHS256 {
+		return auth.NewManager(authCfg.JWTSecret, authCfg.TokenTTL), nil
 	}
 
-	// 获取分页数据
-	todos, total, err := database.GetTodosWithPagination(userID, page, pageSize)
+	keys, err := auth.LoadKeyStore(authCfg.KeysDir)
 	if err != nil {
-		logger.Printf("Error getting todos with pagination: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		return nil, err
 	}
-
-	logger.Printf("Retrieved %d todos (page %d of %d) for user %d", len(todos), page, (total+pageSize-1)/pageSize, userID)
-
-	// 构建响应
-	response := map[string]interface{}{
-		"todos": todos,
-		"pagination": map[string]int{
-			"page":       page,
-			"pageSize":   pageSize,
-			"total":      total,
-			"totalPages": (total + pageSize - 1) / pageSize,
-		},
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-func createTodo(w http.ResponseWriter, r *http.Request, userID int) {
-	var todo models.Todo
-	err := json.NewDecoder(r.Body).Decode(&todo)
+	tokens, err := auth.NewKeyManager(keys, authCfg.SigningKeyID, authCfg.TokenTTL)
 	if err != nil {
-		logger.Printf("Error decoding todo: %v", err)
-		http.Error(w, "Bad request", http.StatusBadRequest)
-		return
+		return nil, err
 	}
-
-	errors := validator.ValidateTodo(todo)
-	if len(errors) > 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(errors)
-		return
-	}
-
-	todo.UserID = userID
-	id, err := database.CreateTodo(todo)
-	if err != nil {
-		logger.Printf("Error creating todo: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+	if tokens.Algorithm() != authCfg.Algorithm {
+		return nil, fmt.Errorf("signing key %q is a %s key, but auth.algorithm is %s", authCfg.SigningKeyID, tokens.Algorithm(), authCfg.Algorithm)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]int64{"id": id})
+	logger.Printf("Signing tokens with %s key %q, %d key(s) published in JWKS", tokens.Algorithm(), authCfg.SigningKeyID, len(tokens.JWKS().Keys))
+	return tokens, nil
 }
 
-func updateTodo(w http.ResponseWriter, r *http.Request, userID int) {
-	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/todos/"))
-	if err != nil {
-		logger.Printf("Invalid todo ID: %v", err)
-		http.Error(w, "Invalid todo ID", http.StatusBadRequest)
-		return
-	}
-
-	var todo models.Todo
-	err = json.NewDecoder(r.Body).Decode(&todo)
+// checkMigrations 在存在未应用的迁移时拒绝启动，开启 database.auto_migrate 时改为自动应用
+func checkMigrations(db *database.Store, dbCfg config.DatabaseConfig) error {
+	m, err := migrate.New(db.DB(), dbCfg.Driver, logger)
 	if err != nil {
-		logger.Printf("Error decoding todo: %v", err)
-		http.Error(w, "Bad request", http.StatusBadRequest)
-		return
+		return err
 	}
 
-	errors := validator.ValidateTodo(todo)
-	if len(errors) > 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(errors)
-		return
-	}
-
-	todo.ID = id
-	todo.UserID = userID
-	err = database.UpdateTodo(todo)
-	if err != nil {
-		logger.Printf("Error updating todo: %v", err)
-		if strings.Contains(err.Error(), "not found or not owned") {
-			http.Error(w, err.Error(), http.StatusForbidden)
-		} else {
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+	if dbCfg.AutoMigrate {
+		n, err := m.Up()
+		if err != nil {
+			return err
 		}
-		return
+		logger.Printf("Applied %d migration(s)", n)
+		return nil
 	}
 
-	w.WriteHeader(http.StatusOK)
-}
-
-func deleteTodo(w http.ResponseWriter, r *http.Request, userID int) {
-	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/todos/"))
+	pending, err := m.Pending()
 	if err != nil {
-		logger.Printf("Invalid todo ID: %v", err)
-		http.Error(w, "Invalid todo ID", http.StatusBadRequest)
-		return
+		return err
 	}
-
-	err = database.DeleteTodo(id, userID)
-	if err != nil {
-		logger.Printf("Error deleting todo: %v", err)
-		if strings.Contains(err.Error(), "not found or not owned") {
-			http.Error(w, err.Error(), http.StatusForbidden)
-		} else {
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
-		return
+	if len(pending) > 0 {
+		return fmt.Errorf("database has %d pending migration(s), run `go run ./cmd/migrate up` first", len(pending))
 	}
-
-	w.WriteHeader(http.StatusOK)
+	return nil
 }
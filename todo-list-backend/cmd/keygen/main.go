@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/auth"
+)
+
+// keygen 生成 RS256 或 EdDSA 签名密钥，写入密钥目录后即可通过 JWT_SIGNING_KEY_ID 启用
+func main() {
+	algorithm := flag.String("alg", auth.AlgEdDSA, "key algorithm: RS256 or EdDSA")
+	dir := flag.String("dir", "keys", "key store directory")
+	id := flag.String("kid", time.Now().UTC().Format("20060102-150405"), "key id, used as the file name")
+	flag.Parse()
+
+	key, err := auth.GenerateKey(*algorithm)
+	if err != nil {
+		log.Fatal(err)
+	}
+	path, err := auth.WriteKey(*dir, *id, key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Wrote %s key %q to %s", *algorithm, *id, path)
+}
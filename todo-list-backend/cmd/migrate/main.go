@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/joy_project/todo-list-backend/internal/config"
+	"github.com/joy_project/todo-list-backend/internal/database"
+	"github.com/joy_project/todo-list-backend/internal/migrate"
+)
+
+const usage = `usage: migrate <command>
+
+commands:
+  status    列出全部迁移及其应用状态
+  up        应用全部待执行的迁移
+  down      回滚最近应用的一个迁移
+  to N      迁移到版本 N（N 为 0 时回滚全部）
+
+数据库通过 CONFIG_FILE 指定的配置文件或 DB_DRIVER / DB_DSN 环境变量指定，默认使用本地 MySQL`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.Database.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if cfg.Database.Driver == "memory" {
+		log.Fatal("The in-memory store has no schema to migrate")
+	}
+
+	db, err := database.Open(database.Options{
+		Driver:          cfg.Database.Driver,
+		DSN:             cfg.Database.DSN,
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	m, err := migrate.New(db.DB(), cfg.Database.Driver, log.Default())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch cmd := os.Args[1]; cmd {
+	case "status":
+		err = printStatus(m)
+	case "up":
+		var n int
+		n, err = m.Up()
+		log.Printf("Applied %d migration(s)", n)
+	case "down":
+		err = m.Down()
+	case "to":
+		if len(os.Args) != 3 {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		var version int
+		version, err = strconv.Atoi(os.Args[2])
+		if err != nil {
+			log.Fatalf("Invalid version %q", os.Args[2])
+		}
+		err = m.To(version)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", cmd, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if os.Args[1] != "status" {
+		current, err := m.Current()
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Database is at version %d (latest %d)", current, m.Latest())
+	}
+}
+
+func printStatus(m *migrate.Migrator) error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	for _, st := range statuses {
+		state := "pending"
+		switch {
+		case st.Applied:
+			state = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+		case st.Failed:
+			state = "failed " + st.FailedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%04d  %-40s %s\n", st.Version, st.Name, state)
+	}
+	return nil
+}
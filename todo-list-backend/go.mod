@@ -7,7 +7,9 @@ toolchain go1.23.6
 require (
 	github.com/go-sql-driver/mysql v1.8.1
 	github.com/golang-jwt/jwt/v5 v5.2.1
+	github.com/mattn/go-sqlite3 v1.14.22
 	golang.org/x/crypto v0.35.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require filippo.io/edwards25519 v1.1.0 // indirect
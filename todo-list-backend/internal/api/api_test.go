@@ -0,0 +1,212 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/joy_project/todo-list-backend/internal/api"
+	"github.com/joy_project/todo-list-backend/internal/auth"
+	"github.com/joy_project/todo-list-backend/internal/blob"
+	"github.com/joy_project/todo-list-backend/internal/config"
+	"github.com/joy_project/todo-list-backend/internal/mail"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/storetest"
+)
+
+// newServer 在 st 上启动完整的 HTTP API，邮件和日志都被丢弃
+func newServer(t *testing.T, st store.Store) *httptest.Server {
+	t.Helper()
+	return newServerWithLog(t, st, io.Discard)
+}
+
+// newServerWithLog 与 newServer 相同，但服务日志和 log 驱动发出的邮件都写入 out
+func newServerWithLog(t *testing.T, st store.Store, out io.Writer) *httptest.Server {
+	t.Helper()
+	return startServer(t, st, blob.NewMemory(), testConfig(t), out)
+}
+
+// testConfig 返回测试环境的默认配置
+func testConfig(t *testing.T) *config.Config {
+	cfg := config.Default()
+	cfg.Environment = config.EnvTest
+	cfg.Attachments.Dir = t.TempDir()
+	return cfg
+}
+
+// startServer 用给定的附件内容存储和配置启动完整的 HTTP API，服务日志和 log 驱动发出的邮件都写入 out
+func startServer(t *testing.T, st store.Store, blobs blob.Store, cfg *config.Config, out io.Writer) *httptest.Server {
+	t.Helper()
+
+	logger := log.New(out, "", 0)
+	tokens := auth.NewManager("test-secret", cfg.Auth.TokenTTL)
+
+	srv := httptest.NewServer(api.NewServer(st, blobs, tokens, mail.NewLog(logger), cfg, logger).Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// client 以某个用户的身份调用 API
+type client struct {
+	t           *testing.T
+	srv         *httptest.Server
+	token       string
+	userID      int
+	workspaceID int
+}
+
+// do 发送 JSON 请求，返回状态码和响应体
+func (c *client) do(method, path string, body interface{}) (int, []byte) {
+	c.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatalf("marshal request: %v", err)
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.srv.URL+path, reader)
+	if err != nil {
+		c.t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.srv.Client().Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("read response: %v", err)
+	}
+	return resp.StatusCode, data
+}
+
+// decode 发送请求并要求返回 want 状态码，把响应体解析到 out
+func (c *client) decode(method, path string, body interface{}, want int, out interface{}) {
+	c.t.Helper()
+
+	status, data := c.do(method, path, body)
+	if status != want {
+		c.t.Fatalf("%s %s: status %d, want %d: %s", method, path, status, want, data)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			c.t.Fatalf("%s %s: decode %s: %v", method, path, data, err)
+		}
+	}
+}
+
+// register 注册用户并返回以其身份登录、进入个人工作区的客户端
+func register(t *testing.T, srv *httptest.Server, username string) *client {
+	t.Helper()
+
+	var resp struct {
+		ID          int    `json:"id"`
+		Token       string `json:"token"`
+		WorkspaceID int    `json:"workspace_id"`
+	}
+	anon := &client{t: t, srv: srv}
+	anon.decode(http.MethodPost, "/register", map[string]string{
+		"username": username,
+		"email":    username + "@example.com",
+		"password": "password123",
+	}, http.StatusCreated, &resp)
+	return &client{t: t, srv: srv, token: resp.Token, userID: resp.ID, workspaceID: resp.WorkspaceID}
+}
+
+// createTodo 创建待办事项并返回其ID
+func (c *client) createTodo(body map[string]interface{}) int {
+	c.t.Helper()
+
+	if body["priority"] == nil {
+		body["priority"] = "medium"
+	}
+	var resp struct {
+		ID int `json:"id"`
+	}
+	c.decode(http.MethodPost, "/todos", body, http.StatusCreated, &resp)
+	return resp.ID
+}
+
+func TestRegisterConflicts(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		srv := newServer(t, st)
+		register(t, srv, "alice")
+
+		anon := &client{t: t, srv: srv}
+		tests := []struct {
+			name     string
+			username string
+			email    string
+			want     string
+		}{
+			{"duplicate email", "alice2", "alice@example.com", store.ErrEmailExists.Error()},
+			{"duplicate username", "alice", "other@example.com", store.ErrUsernameExists.Error()},
+		}
+		for _, tt := range tests {
+			status, body := anon.do(http.MethodPost, "/register", map[string]string{
+				"username": tt.username,
+				"email":    tt.email,
+				"password": "password123",
+			})
+			if status != http.StatusConflict || string(bytes.TrimSpace(body)) != tt.want {
+				t.Errorf("%s: got %d %q, want %d %q", tt.name, status, body, http.StatusConflict, tt.want)
+			}
+		}
+	})
+}
+
+func TestTodoUpdateDeleteNotOwned(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		srv := newServer(t, st)
+		alice := register(t, srv, "alice")
+		bob := register(t, srv, "bob")
+
+		id := alice.createTodo(map[string]interface{}{"title": "alice's"})
+		update := map[string]interface{}{"title": "hijacked", "priority": "high"}
+
+		tests := []struct {
+			name   string
+			c      *client
+			method string
+			path   string
+			body   interface{}
+		}{
+			{"update missing todo", alice, http.MethodPut, "/todos/9999", update},
+			{"delete missing todo", alice, http.MethodDelete, "/todos/9999", nil},
+			{"read another user's todo", bob, http.MethodGet, fmt.Sprintf("/todos/%d", id), nil},
+			{"update another user's todo", bob, http.MethodPut, fmt.Sprintf("/todos/%d", id), update},
+			{"delete another user's todo", bob, http.MethodDelete, fmt.Sprintf("/todos/%d", id), nil},
+		}
+		for _, tt := range tests {
+			status, body := tt.c.do(tt.method, tt.path, tt.body)
+			if status != http.StatusForbidden && status != http.StatusNotFound {
+				t.Errorf("%s: status %d, want 403 or 404: %s", tt.name, status, body)
+			}
+		}
+
+		var todo struct {
+			Title    string `json:"title"`
+			Priority string `json:"priority"`
+		}
+		alice.decode(http.MethodGet, fmt.Sprintf("/todos/%d", id), nil, http.StatusOK, &todo)
+		if todo.Title != "alice's" || todo.Priority != "medium" {
+			t.Errorf("todo changed by rejected requests: %+v", todo)
+		}
+
+		alice.decode(http.MethodPut, fmt.Sprintf("/todos/%d", id), update, http.StatusOK, nil)
+		alice.decode(http.MethodDelete, fmt.Sprintf("/todos/%d", id), nil, http.StatusOK, nil)
+	})
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// errFileTooLarge 上传的文件超过 attachments.max_size
+var errFileTooLarge = errors.New("file too large")
+
+// sniffLen http.DetectContentType 最多检查的字节数
+const sniffLen = 512
+
+// handleAttachments 处理 /todos/{id}/attachments（GET 列表，POST 上传）和 /todos/{id}/attachments/{attachmentID}（GET 下载，DELETE 删除）
+func (s *Server) handleAttachments(w http.ResponseWriter, r *http.Request, userID int, todoID int, rest string) {
+	if rest == "" {
+		switch r.Method {
+		case http.MethodGet:
+			attachments, err := s.attachments.GetAttachments(todoID, userID)
+			if err != nil {
+				s.attachmentError(w, "getting attachments", err)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(attachments)
+		case http.MethodPost:
+			s.uploadAttachment(w, r, userID, todoID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(rest)
+	if err != nil {
+		http.Error(w, "Invalid attachment ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.downloadAttachment(w, r, userID, todoID, id)
+	case http.MethodDelete:
+		// 上传者可以删除自己的附件，对待办事项有 editor 角色的用户可以删除任何附件
+		hash, err := s.attachments.DeleteAttachment(id, todoID, userID)
+		if err != nil {
+			s.attachmentError(w, "deleting attachment", err)
+			return
+		}
+		s.removeOrphanedBlobs([]string{hash})
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// uploadAttachment 处理 multipart/form-data 上传，file 字段中的文件以流的方式写入内容存储
+func (s *Server) uploadAttachment(w http.ResponseWriter, r *http.Request, userID int, todoID int) {
+	// 先确认待办事项可以访问，避免为无权访问的请求写入内容
+	if _, err := s.todos.GetTodo(todoID, userID); err != nil {
+		s.attachmentError(w, "uploading attachment", err)
+		return
+	}
+
+	// 为 multipart 的边界和其他字段留出余量
+	r.Body = http.MaxBytesReader(w, r.Body, s.attachCfg.MaxSize+1<<20)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		s.logger.Printf("Error reading multipart request: %v", err)
+		http.Error(w, "Expected a multipart/form-data request", http.StatusBadRequest)
+		return
+	}
+
+	var part io.Reader
+	var filename string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.uploadError(w, err)
+			return
+		}
+		if p.FormName() == "file" {
+			part, filename = p, cleanFilename(p.FileName())
+			break
+		}
+	}
+	if part == nil {
+		http.Error(w, "Missing file field", http.StatusBadRequest)
+		return
+	}
+
+	// 根据内容检测类型，不信任客户端声明的 Content-Type
+	br := bufio.NewReaderSize(part, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		s.uploadError(w, err)
+		return
+	}
+	if len(head) == 0 {
+		http.Error(w, "File is empty", http.StatusBadRequest)
+		return
+	}
+	contentType := http.DetectContentType(head)
+	if !s.allowedType(contentType) {
+		http.Error(w, "File type "+contentType+" is not allowed", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// 写入内容和登记附件期间持有读锁，防止刚写入的内容在登记前被当作无引用内容清理掉
+	s.blobMu.RLock()
+	body := &sizeLimitReader{r: br, n: s.attachCfg.MaxSize}
+	hash, size, err := s.blobs.Put(body)
+	if err != nil {
+		s.blobMu.RUnlock()
+		if body.err != nil {
+			s.uploadError(w, body.err)
+		} else {
+			s.logger.Printf("Error storing attachment content: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	attachment := models.Attachment{
+		TodoID:      todoID,
+		UserID:      userID,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        size,
+		Hash:        hash,
+	}
+	id, err := s.attachments.CreateAttachment(attachment)
+	s.blobMu.RUnlock()
+	if err != nil {
+		s.removeOrphanedBlobs([]string{hash})
+		s.attachmentError(w, "creating attachment", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]int64{"id": id})
+}
+
+// downloadAttachment 以附件形式返回文件内容，支持 Range 和基于内容摘要的条件请求
+func (s *Server) downloadAttachment(w http.ResponseWriter, r *http.Request, userID int, todoID int, id int) {
+	attachment, err := s.attachments.GetAttachment(id, todoID, userID)
+	if err != nil {
+		s.attachmentError(w, "getting attachment", err)
+		return
+	}
+
+	content, err := s.blobs.Open(attachment.Hash)
+	if err != nil {
+		s.logger.Printf("Error opening attachment %d content %s: %v", attachment.ID, attachment.Hash, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", `"`+attachment.Hash+`"`)
+
+	if rs, ok := content.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", attachment.CreatedAt, rs)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	if r.Method != http.MethodHead {
+		io.Copy(w, content)
+	}
+}
+
+// removeOrphanedBlobs 在附件删除提交后同步删除 hashes 中已没有引用的内容，持有 blobMu 的写锁等待进行中的上传完成登记，避免误删刚写入的相同内容
+func (s *Server) removeOrphanedBlobs(hashes []string) {
+	if len(hashes) == 0 {
+		return
+	}
+
+	s.blobMu.Lock()
+	defer s.blobMu.Unlock()
+
+	orphans, err := s.attachments.UnreferencedHashes(hashes)
+	if err != nil {
+		s.logger.Printf("Error finding orphaned attachment content: %v", err)
+		return
+	}
+	for _, hash := range orphans {
+		if err := s.blobs.Delete(hash); err != nil {
+			s.logger.Printf("Error deleting attachment content %s: %v", hash, err)
+		}
+	}
+}
+
+// allowedType 判断检测到的类型是否在 attachments.allowed_types 中，忽略 charset 等参数
+func (s *Server) allowedType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range s.attachCfg.AllowedTypes {
+		if strings.EqualFold(t, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadError 将读取上传内容时的错误映射为 HTTP 状态码
+func (s *Server) uploadError(w http.ResponseWriter, err error) {
+	s.logger.Printf("Error uploading attachment: %v", err)
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, errFileTooLarge), errors.As(err, &maxBytesErr):
+		http.Error(w, "File must be at most "+strconv.FormatInt(s.attachCfg.MaxSize, 10)+" bytes", http.StatusRequestEntityTooLarge)
+	default:
+		http.Error(w, "Bad request", http.StatusBadRequest)
+	}
+}
+
+// attachmentError 将附件相关的存储错误映射为 HTTP 状态码，无法访问待办事项时与 handleTodo 一致返回 403
+func (s *Server) attachmentError(w http.ResponseWriter, action string, err error) {
+	s.logger.Printf("Error %s: %v", action, err)
+	switch {
+	case errors.Is(err, store.ErrTodoNotFound), errors.Is(err, store.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, store.ErrAttachmentNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// sizeLimitReader 读取超过 n 字节时返回 errFileTooLarge，err 记录读取请求体时的错误，用于区分客户端的问题和存储的故障
+type sizeLimitReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		err = errFileTooLarge
+	}
+	if err != nil && err != io.EOF {
+		l.err = err
+	}
+	return n, err
+}
+
+// cleanFilename 只保留客户端文件名的最后一段，去掉控制字符并限制长度
+func cleanFilename(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	for utf8.RuneCountInString(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == ".." {
+		return "attachment"
+	}
+	return name
+}
@@ -0,0 +1,173 @@
+package api_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/joy_project/todo-list-backend/internal/blob"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/storetest"
+)
+
+// newAttachmentServer 启动附件上限为 maxSize 字节的服务，返回其附件内容存储以便检查清理结果
+func newAttachmentServer(t *testing.T, st store.Store, maxSize int64) (*client, *blob.Memory) {
+	t.Helper()
+
+	blobs := blob.NewMemory()
+	cfg := testConfig(t)
+	cfg.Attachments.MaxSize = maxSize
+	srv := startServer(t, st, blobs, cfg, io.Discard)
+	return register(t, srv, "alice"), blobs
+}
+
+// hashOf 返回内容的 SHA-256 摘要
+func hashOf(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// stored 判断内容是否仍保存在内容存储中
+func stored(t *testing.T, blobs blob.Store, content []byte) bool {
+	t.Helper()
+
+	r, err := blobs.Open(hashOf(content))
+	if err == blob.ErrNotFound {
+		return false
+	}
+	if err != nil {
+		t.Fatalf("open blob: %v", err)
+	}
+	r.Close()
+	return true
+}
+
+// uploadID 上传附件并要求返回 201，返回附件ID
+func (c *client) uploadID(todoID int, filename string, content []byte) int {
+	c.t.Helper()
+
+	status, body := c.upload(fmt.Sprintf("/todos/%d/attachments", todoID), filename, content)
+	if status != http.StatusCreated {
+		c.t.Fatalf("upload %s: status %d: %s", filename, status, body)
+	}
+	var resp struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		c.t.Fatalf("decode %s: %v", body, err)
+	}
+	return resp.ID
+}
+
+func TestUploadRejected(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		alice, blobs := newAttachmentServer(t, st, 1024)
+		todo := alice.createTodo(map[string]interface{}{"title": "with files"})
+		path := fmt.Sprintf("/todos/%d/attachments", todo)
+
+		tooLarge := bytes.Repeat([]byte("a"), 1025)
+		executable := append([]byte("\x7fELF\x02\x01\x01"), make([]byte, 100)...)
+		tests := []struct {
+			name     string
+			filename string
+			content  []byte
+			want     int
+		}{
+			{"one byte over the limit", "big.txt", tooLarge, http.StatusRequestEntityTooLarge},
+			{"far over the limit", "huge.txt", bytes.Repeat([]byte("a"), 64<<10), http.StatusRequestEntityTooLarge},
+			{"binary disguised as text", "notes.txt", executable, http.StatusUnsupportedMediaType},
+			{"HTML", "page.png", []byte("<!DOCTYPE html><html><script>alert(1)</script></html>"), http.StatusUnsupportedMediaType},
+			{"empty file", "empty.txt", nil, http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			if status, body := alice.upload(path, tt.filename, tt.content); status != tt.want {
+				t.Errorf("%s: status %d, want %d: %s", tt.name, status, tt.want, body)
+			}
+			if len(tt.content) > 0 && stored(t, blobs, tt.content) {
+				t.Errorf("%s: rejected content was stored", tt.name)
+			}
+		}
+
+		var attachments []models.Attachment
+		alice.decode(http.MethodGet, path, nil, http.StatusOK, &attachments)
+		if len(attachments) != 0 {
+			t.Errorf("attachments = %+v, want none", attachments)
+		}
+
+		// 恰好等于上限的文件可以上传，类型根据内容检测
+		exact := bytes.Repeat([]byte("a"), 1024)
+		alice.uploadID(todo, "exact.bin", exact)
+		alice.decode(http.MethodGet, path, nil, http.StatusOK, &attachments)
+		if len(attachments) != 1 || !strings.HasPrefix(attachments[0].ContentType, "text/plain") || attachments[0].Size != 1024 || attachments[0].Hash != hashOf(exact) {
+			t.Errorf("attachments = %+v", attachments)
+		}
+	})
+}
+
+func TestAttachmentDedupAndCleanup(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		alice, blobs := newAttachmentServer(t, st, 1024)
+		first := alice.createTodo(map[string]interface{}{"title": "first"})
+		second := alice.createTodo(map[string]interface{}{"title": "second"})
+		shared, own := []byte("shared content"), []byte("only on the second todo")
+
+		a := alice.uploadID(first, "a.txt", shared)
+		b := alice.uploadID(second, "b.txt", shared)
+		alice.uploadID(second, "c.txt", own)
+
+		// 相同内容只保存一份，两个附件引用同一摘要
+		var attachments []models.Attachment
+		alice.decode(http.MethodGet, fmt.Sprintf("/todos/%d/attachments", second), nil, http.StatusOK, &attachments)
+		if len(attachments) != 2 || attachments[0].ID != b || attachments[0].Hash != hashOf(shared) {
+			t.Fatalf("attachments = %+v", attachments)
+		}
+
+		// 仍被引用的内容不删除；清理在响应返回前完成
+		alice.decode(http.MethodDelete, fmt.Sprintf("/todos/%d/attachments/%d", first, a), nil, http.StatusOK, nil)
+		if !stored(t, blobs, shared) {
+			t.Fatal("content deleted while another attachment still references it")
+		}
+		if status, body := alice.do(http.MethodGet, fmt.Sprintf("/todos/%d/attachments/%d", second, b), nil); status != http.StatusOK || !bytes.Equal(body, shared) {
+			t.Errorf("download shared content: %d %q", status, body)
+		}
+
+		// 删除待办事项后清理它的附件不再引用的内容
+		alice.decode(http.MethodDelete, fmt.Sprintf("/todos/%d", second), nil, http.StatusOK, nil)
+		if stored(t, blobs, shared) || stored(t, blobs, own) {
+			t.Error("content of the deleted todo's attachments was not removed")
+		}
+	})
+}
+
+func TestCascadeDeleteCleansUpAttachments(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		alice, blobs := newAttachmentServer(t, st, 1024)
+		parent := alice.createTodo(map[string]interface{}{"title": "parent"})
+		child := alice.createTodo(map[string]interface{}{"title": "child", "parent_id": parent})
+		other := alice.createTodo(map[string]interface{}{"title": "other"})
+		kept, removed := []byte("also on another todo"), []byte("only in the subtree")
+
+		alice.uploadID(child, "kept.txt", kept)
+		alice.uploadID(child, "removed.txt", removed)
+		alice.uploadID(other, "kept.txt", kept)
+
+		alice.decode(http.MethodDelete, fmt.Sprintf("/todos/%d", parent), nil, http.StatusConflict, nil)
+		if !stored(t, blobs, removed) {
+			t.Fatal("content removed although the delete was rejected")
+		}
+		alice.decode(http.MethodDelete, fmt.Sprintf("/todos/%d?cascade=true", parent), nil, http.StatusOK, nil)
+		if stored(t, blobs, removed) {
+			t.Error("content only referenced by the subtree was not removed")
+		}
+		if !stored(t, blobs, kept) {
+			t.Error("content still referenced by another todo was removed")
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/validator"
+)
+
+// handleComments 处理 /todos/{id}/comments（GET 列表，POST 发表）和 /todos/{id}/comments/{commentID}（PUT 修改，DELETE 删除）
+func (s *Server) handleComments(w http.ResponseWriter, r *http.Request, userID int, todoID int, rest string) {
+	if rest == "" {
+		switch r.Method {
+		case http.MethodGet:
+			comments, err := s.comments.GetComments(todoID, userID)
+			if err != nil {
+				s.commentError(w, "getting comments", err)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(comments)
+		case http.MethodPost:
+			s.createComment(w, r, userID, todoID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(rest)
+	if err != nil {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		s.updateComment(w, r, userID, todoID, id)
+	case http.MethodDelete:
+		// 作者可以删除自己的评论，对待办事项有 editor 角色的用户可以删除任何评论
+		if err := s.comments.DeleteComment(id, todoID, userID); err != nil {
+			s.commentError(w, "deleting comment", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) createComment(w http.ResponseWriter, r *http.Request, userID int, todoID int) {
+	var comment models.Comment
+	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+		s.logger.Printf("Error decoding comment: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	validationErrors := validator.ValidateComment(comment)
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+
+	comment.TodoID = todoID
+	comment.UserID = userID
+	id, err := s.comments.CreateComment(comment)
+	if err != nil {
+		s.commentError(w, "creating comment", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]int64{"id": id})
+}
+
+func (s *Server) updateComment(w http.ResponseWriter, r *http.Request, userID int, todoID int, id int) {
+	var comment models.Comment
+	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+		s.logger.Printf("Error decoding comment: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	validationErrors := validator.ValidateComment(comment)
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+
+	comment.ID = id
+	comment.TodoID = todoID
+	comment.UserID = userID
+	if err := s.comments.UpdateComment(comment); err != nil {
+		s.commentError(w, "updating comment", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// commentError 将评论相关的存储错误映射为 HTTP 状态码，无法访问待办事项时与 handleTodo 一致返回 403
+func (s *Server) commentError(w http.ResponseWriter, action string, err error) {
+	s.logger.Printf("Error %s: %v", action, err)
+	switch {
+	case errors.Is(err, store.ErrTodoNotFound), errors.Is(err, store.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, store.ErrCommentNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+const (
+	// defaultCursorLimit 游标分页默认每页条数
+	defaultCursorLimit = 20
+	// maxCursorLimit 游标分页每页条数上限
+	maxCursorLimit = 100
+)
+
+var errCursorSort = errors.New("cursor does not match the requested sort")
+
+// cursor 游标的内容：排序方式、该排序用到的字段值和 ID，编码为对客户端不透明的 base64url JSON
+type cursor struct {
+	Sort      string     `json:"s"`
+	ID        int        `json:"id"`
+	CreatedAt *time.Time `json:"c,omitempty"`
+	UpdatedAt *time.Time `json:"u,omitempty"`
+	DueAt     *time.Time `json:"d,omitempty"`
+	Priority  string     `json:"p,omitempty"`
+	Title     *string    `json:"t,omitempty"`
+	Completed *bool      `json:"x,omitempty"`
+	Position  *float64   `json:"o,omitempty"`
+}
+
+// formatSort 把排序键格式化为 ParseSort 接受的文本，空排序格式化为 DefaultSort
+func formatSort(keys []store.SortKey) string {
+	if len(keys) == 0 {
+		keys = store.DefaultSort
+	}
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = string(k.Field)
+		if k.Desc {
+			parts[i] = "-" + parts[i]
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// encodeCursor 为待办事项生成游标，只记录排序用到的字段
+func encodeCursor(todo models.Todo, keys []store.SortKey) string {
+	c := cursor{Sort: formatSort(keys), ID: todo.ID}
+	if len(keys) == 0 {
+		keys = store.DefaultSort
+	}
+	for _, k := range keys {
+		switch k.Field {
+		case store.SortCreatedAt:
+			c.CreatedAt = &todo.CreatedAt
+		case store.SortUpdatedAt:
+			c.UpdatedAt = &todo.UpdatedAt
+		case store.SortDueAt:
+			c.DueAt = todo.DueAt
+		case store.SortPriority:
+			c.Priority = todo.Priority
+		case store.SortTitle:
+			c.Title = &todo.Title
+		case store.SortCompleted:
+			c.Completed = &todo.Completed
+		case store.SortPosition:
+			c.Position = &todo.Position
+		}
+	}
+
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor 解析游标，返回只包含排序字段和 ID 的待办事项；游标必须由相同的排序生成
+func decodeCursor(s string, keys []store.SortKey) (*models.Todo, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	if c.Sort != formatSort(keys) {
+		return nil, errCursorSort
+	}
+
+	todo := &models.Todo{ID: c.ID, DueAt: c.DueAt, Priority: c.Priority}
+	if c.CreatedAt != nil {
+		todo.CreatedAt = *c.CreatedAt
+	}
+	if c.UpdatedAt != nil {
+		todo.UpdatedAt = *c.UpdatedAt
+	}
+	if c.Title != nil {
+		todo.Title = *c.Title
+	}
+	if c.Completed != nil {
+		todo.Completed = *c.Completed
+	}
+	if c.Position != nil {
+		todo.Position = *c.Position
+	}
+	return todo, nil
+}
+
+// isCursorRequest 判断列表请求是否使用游标分页：带有 after、before 或 limit 且没有 page
+func isCursorRequest(query url.Values) bool {
+	if query.Get("page") != "" {
+		return false
+	}
+	return query.Get("after") != "" || query.Get("before") != "" || query.Get("limit") != ""
+}
+
+// parsePage 解析游标分页参数
+func parsePage(query url.Values, keys []store.SortKey) (store.Page, map[string]string) {
+	errors := make(map[string]string)
+	page := store.Page{Limit: defaultCursorLimit}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			errors["limit"] = "limit must be a positive integer"
+		} else {
+			page.Limit = min(n, maxCursorLimit)
+		}
+	}
+
+	after, before := query.Get("after"), query.Get("before")
+	if after != "" && before != "" {
+		errors["before"] = "after and before cannot be used together"
+		return page, errors
+	}
+	for _, p := range []struct {
+		name   string
+		value  string
+		target **models.Todo
+	}{
+		{"after", after, &page.After},
+		{"before", before, &page.Before},
+	} {
+		if p.value == "" {
+			continue
+		}
+		todo, err := decodeCursor(p.value, keys)
+		if err == errCursorSort {
+			errors[p.name] = "Cursor was created with a different sort"
+		} else if err != nil {
+			errors[p.name] = "Invalid cursor"
+		}
+		*p.target = todo
+	}
+
+	return page, errors
+}
+
+// getTodosWithCursor 按 after、before、limit 参数进行游标分页，返回 next、prev 游标和 Link 头
+func (s *Server) getTodosWithCursor(w http.ResponseWriter, r *http.Request, userID int, filter store.TodoFilter) {
+	query := r.URL.Query()
+	page, validationErrors := parsePage(query, filter.Sort)
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+
+	todos, more, err := s.todos.GetTodosPage(userID, filter, page)
+	if err != nil {
+		s.logger.Printf("Error getting todos with cursor: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Printf("Retrieved %d todos (cursor page) for user %d", len(todos), userID)
+
+	// 本页为空时沿用请求中的游标，越过末尾后仍然可以往回翻
+	var next, prev string
+	if len(todos) > 0 {
+		first, last := encodeCursor(todos[0], filter.Sort), encodeCursor(todos[len(todos)-1], filter.Sort)
+		if page.Before != nil {
+			next = last
+			if more {
+				prev = first
+			}
+		} else {
+			if more {
+				next = last
+			}
+			if page.After != nil {
+				prev = first
+			}
+		}
+	} else if page.Before != nil {
+		next = query.Get("before")
+	} else if page.After != nil {
+		prev = query.Get("after")
+	}
+
+	var links []string
+	if next != "" {
+		links = append(links, pageLink(r.URL, "after", next, "next"))
+	}
+	if prev != "" {
+		links = append(links, pageLink(r.URL, "before", prev, "prev"))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	pagination := map[string]interface{}{"limit": page.Limit, "next": nil, "prev": nil}
+	if next != "" {
+		pagination["next"] = next
+	}
+	if prev != "" {
+		pagination["prev"] = prev
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"todos":      todos,
+		"pagination": pagination,
+	})
+}
+
+// pageLink 基于当前请求地址生成 Link 头的一项，保留过滤和排序参数，只替换游标
+func pageLink(u *url.URL, param, value, rel string) string {
+	query := u.Query()
+	query.Del("after")
+	query.Del("before")
+	query.Set(param, value)
+	link := url.URL{Path: u.Path, RawQuery: query.Encode()}
+	return "<" + link.String() + `>; rel="` + rel + `"`
+}
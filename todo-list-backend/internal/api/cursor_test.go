@@ -0,0 +1,245 @@
+package api_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/storetest"
+)
+
+// cursorPage 游标分页的响应
+type cursorPage struct {
+	Todos      []models.Todo `json:"todos"`
+	Pagination struct {
+		Limit int     `json:"limit"`
+		Next  *string `json:"next"`
+		Prev  *string `json:"prev"`
+	} `json:"pagination"`
+}
+
+// page 请求一页待办事项，返回响应和 Link 头
+func (c *client) page(path string) (cursorPage, string) {
+	c.t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, c.srv.URL+path, nil)
+	if err != nil {
+		c.t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.srv.Client().Do(req)
+	if err != nil {
+		c.t.Fatalf("GET %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		c.t.Fatalf("GET %s: status %d: %s", path, resp.StatusCode, data)
+	}
+
+	var p cursorPage
+	if err := json.Unmarshal(data, &p); err != nil {
+		c.t.Fatalf("GET %s: decode %s: %v", path, data, err)
+	}
+	return p, resp.Header.Get("Link")
+}
+
+// titles 返回待办事项的标题
+func titles(todos []models.Todo) []string {
+	var out []string
+	for _, td := range todos {
+		out = append(out, td.Title)
+	}
+	return out
+}
+
+func TestCursorTraversal(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		srv := newServer(t, st)
+		alice := register(t, srv, "alice")
+		for i := 1; i < 12; i += 2 {
+			alice.createTodo(map[string]interface{}{"title": fmt.Sprintf("t%02d", i)})
+		}
+
+		first, _ := alice.page("/todos?sort=title&limit=3")
+		if got := titles(first.Todos); !slices.Equal(got, []string{"t01", "t03", "t05"}) {
+			t.Fatalf("first page = %v", got)
+		}
+		if first.Pagination.Limit != 3 || first.Pagination.Next == nil || first.Pagination.Prev != nil {
+			t.Fatalf("first page pagination = %+v", first.Pagination)
+		}
+
+		// 翻页之间插入的数据：已翻过的位置不会重复出现，尚未翻到的位置会出现
+		alice.createTodo(map[string]interface{}{"title": "t04"})
+		alice.createTodo(map[string]interface{}{"title": "t06"})
+
+		forward := titles(first.Todos)
+		var last cursorPage
+		for next := first.Pagination.Next; next != nil; next = last.Pagination.Next {
+			last, _ = alice.page("/todos?sort=title&limit=3&after=" + url.QueryEscape(*next))
+			forward = append(forward, titles(last.Todos)...)
+		}
+		if want := []string{"t01", "t03", "t05", "t06", "t07", "t09", "t11"}; !slices.Equal(forward, want) {
+			t.Errorf("forward traversal = %v, want %v", forward, want)
+		}
+		if last.Pagination.Prev == nil {
+			t.Fatal("last page has no prev cursor")
+		}
+
+		// 从最后一页往回翻，得到最后一页之前的全部数据，包括新插入的
+		var backward []string
+		cur := last
+		for prev := last.Pagination.Prev; prev != nil; prev = cur.Pagination.Prev {
+			cur, _ = alice.page("/todos?sort=title&limit=3&before=" + url.QueryEscape(*prev))
+			if cur.Pagination.Next == nil {
+				t.Errorf("page before %v has no next cursor", titles(cur.Todos))
+			}
+			backward = append(titles(cur.Todos), backward...)
+		}
+		want := []string{"t01", "t03", "t04", "t05", "t06", "t07", "t09", "t11"}
+		if got := append(backward, titles(last.Todos)...); !slices.Equal(got, want) {
+			t.Errorf("backward traversal = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestCursorDefaultSort(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		srv := newServer(t, st)
+		alice := register(t, srv, "alice")
+		var ids []int
+		for i := 0; i < 7; i++ {
+			ids = append(ids, alice.createTodo(map[string]interface{}{"title": fmt.Sprintf("t%d", i)}))
+		}
+
+		// 默认按创建时间倒序，创建时间相同的按 ID 倒序，同一秒内创建的数据也不会重复或遗漏
+		var got []int
+		p, _ := alice.page("/todos?limit=3")
+		for {
+			for _, td := range p.Todos {
+				got = append(got, td.ID)
+			}
+			if p.Pagination.Next == nil {
+				break
+			}
+			p, _ = alice.page("/todos?limit=3&after=" + url.QueryEscape(*p.Pagination.Next))
+		}
+		slices.Reverse(ids)
+		if !slices.Equal(got, ids) {
+			t.Errorf("traversal = %v, want %v", got, ids)
+		}
+	})
+}
+
+var linkPattern = regexp.MustCompile(`<([^>]*)>; rel="(\w+)"`)
+
+func TestCursorLinkHeader(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		srv := newServer(t, st)
+		alice := register(t, srv, "alice")
+		for i := 0; i < 7; i++ {
+			alice.createTodo(map[string]interface{}{"title": fmt.Sprintf("t%d", i)})
+		}
+
+		// links 解析 Link 头，检查每项保留了过滤、排序和每页条数，返回 rel 到游标参数的映射
+		links := func(header string) map[string]url.Values {
+			t.Helper()
+			out := make(map[string]url.Values)
+			for _, m := range linkPattern.FindAllStringSubmatch(header, -1) {
+				u, err := url.Parse(m[1])
+				if err != nil {
+					t.Fatalf("link %q: %v", m[1], err)
+				}
+				q := u.Query()
+				if u.Path != "/todos" || q.Get("sort") != "title" || q.Get("limit") != "3" || q.Get("completed") != "false" {
+					t.Errorf("link %q does not keep the request's parameters", m[1])
+				}
+				out[m[2]] = q
+			}
+			return out
+		}
+
+		first, header := alice.page("/todos?completed=false&sort=title&limit=3")
+		got := links(header)
+		if len(got) != 1 || got["next"].Get("after") != *first.Pagination.Next {
+			t.Fatalf("first page Link = %q, want only next after=%s", header, *first.Pagination.Next)
+		}
+
+		second, header := alice.page("/todos?completed=false&sort=title&limit=3&after=" + url.QueryEscape(*first.Pagination.Next))
+		got = links(header)
+		if got["next"].Get("after") != *second.Pagination.Next || got["prev"].Get("before") != *second.Pagination.Prev {
+			t.Errorf("second page Link = %q, want next and prev matching %+v", header, second.Pagination)
+		}
+		if got["next"].Has("before") || got["prev"].Has("after") {
+			t.Errorf("second page Link = %q carries both cursors", header)
+		}
+
+		// 最后一页没有 next；其中的数据删除后越过末尾的空页仍给出往回翻的链接
+		after := url.QueryEscape(*second.Pagination.Next)
+		third, header := alice.page("/todos?completed=false&sort=title&limit=3&after=" + after)
+		got = links(header)
+		if third.Pagination.Next != nil || len(got) != 1 || got["prev"].Get("before") != *third.Pagination.Prev {
+			t.Errorf("last page %v: Link = %q, pagination %+v", titles(third.Todos), header, third.Pagination)
+		}
+		alice.decode(http.MethodDelete, fmt.Sprintf("/todos/%d", third.Todos[0].ID), nil, http.StatusOK, nil)
+		empty, header := alice.page("/todos?completed=false&sort=title&limit=3&after=" + after)
+		got = links(header)
+		if len(empty.Todos) != 0 || len(got) != 1 || got["prev"].Get("before") != *second.Pagination.Next {
+			t.Errorf("empty page: Link = %q, pagination %+v", header, empty.Pagination)
+		}
+	})
+}
+
+func TestCursorRejected(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		srv := newServer(t, st)
+		alice := register(t, srv, "alice")
+		for i := 0; i < 4; i++ {
+			alice.createTodo(map[string]interface{}{"title": fmt.Sprintf("t%d", i)})
+		}
+		first, _ := alice.page("/todos?sort=title&limit=2")
+		cursor := *first.Pagination.Next
+
+		raw, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			t.Fatalf("cursor is not base64url: %v", err)
+		}
+		// 改写游标中记录的排序，伪装成另一种排序生成的游标
+		resorted := base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(raw), `"s":"title"`, `"s":"-title"`, 1)))
+
+		tests := []struct {
+			name  string
+			query string
+			field string
+			want  string
+		}{
+			{"truncated cursor", "sort=title&after=" + cursor[:len(cursor)-3], "after", "Invalid cursor"},
+			{"not base64", "sort=title&after=%21%21%21", "after", "Invalid cursor"},
+			{"not JSON", "sort=title&before=" + base64.RawURLEncoding.EncodeToString([]byte("not json")), "before", "Invalid cursor"},
+			{"different sort", "sort=-title&after=" + cursor, "after", "Cursor was created with a different sort"},
+			{"default sort", "limit=2&after=" + cursor, "after", "Cursor was created with a different sort"},
+			{"sort rewritten in cursor", "sort=title&after=" + resorted, "after", "Cursor was created with a different sort"},
+			{"after and before", "sort=title&after=" + cursor + "&before=" + cursor, "before", "after and before cannot be used together"},
+			{"non-positive limit", "sort=title&limit=0", "limit", "limit must be a positive integer"},
+		}
+		for _, tt := range tests {
+			var errs map[string]string
+			alice.decode(http.MethodGet, "/todos?"+tt.query, nil, http.StatusBadRequest, &errs)
+			if errs[tt.field] != tt.want {
+				t.Errorf("%s: errors = %v, want %s: %q", tt.name, errs, tt.field, tt.want)
+			}
+		}
+	})
+}
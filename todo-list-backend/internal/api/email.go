@@ -0,0 +1,277 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/auth"
+	"github.com/joy_project/todo-list-backend/internal/mail"
+	"github.com/joy_project/todo-list-backend/internal/middleware"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/validator"
+)
+
+// 邮件中一次性令牌的有效期和发送频率限制
+const (
+	verifyEmailTTL     = 48 * time.Hour
+	resetPasswordTTL   = time.Hour
+	mailsPerAccount    = 3  // 每个账户每小时最多收到的同类邮件数
+	emailRequestsPerIP = 20 // 每个 IP 每 15 分钟最多请求邮件或提交令牌的次数
+)
+
+// handleRequestVerification 处理 POST /verify-email/request，向当前用户重新发送验证邮件
+func (s *Server) handleRequestVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		s.logger.Printf("Error getting user: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		http.Error(w, "Email is already verified", http.StatusConflict)
+		return
+	}
+
+	if ok, retry := s.accountLimit.Allow("verify:" + strconv.Itoa(user.ID)); !ok {
+		tooManyRequests(w, retry)
+		return
+	}
+
+	if err := s.sendEmailToken(user, models.PurposeVerifyEmail); err != nil {
+		s.logger.Printf("Error creating verification token: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleVerifyEmail 处理 POST /verify-email，使用验证邮件中的令牌确认邮箱
+func (s *Server) handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Printf("Error decoding verify email request: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if req.Token == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"token": "Token is required"})
+		return
+	}
+
+	if ok, retry := s.ipLimit.Allow(clientIP(r)); !ok {
+		tooManyRequests(w, retry)
+		return
+	}
+
+	token, err := s.emailTokens.ConsumeEmailToken(auth.HashToken(req.Token), models.PurposeVerifyEmail)
+	if err != nil {
+		s.emailTokenError(w, "verifying email", err)
+		return
+	}
+	if err := s.users.MarkEmailVerified(token.UserID); err != nil {
+		s.logger.Printf("Error marking email verified: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Printf("User %d verified their email", token.UserID)
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleForgotPassword 处理 POST /password/forgot，无论邮箱是否已注册都返回 200，避免探测账户是否存在
+func (s *Server) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Printf("Error decoding forgot password request: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	validationErrors := validator.ValidateForgotPassword(req)
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+
+	if ok, retry := s.ipLimit.Allow(clientIP(r)); !ok {
+		tooManyRequests(w, retry)
+		return
+	}
+	// 按邮箱而不是用户计数，未注册的邮箱也受同样的限制，响应不会因账户是否存在而不同
+	if ok, retry := s.accountLimit.Allow("reset:" + strings.ToLower(strings.TrimSpace(req.Email))); !ok {
+		tooManyRequests(w, retry)
+		return
+	}
+
+	user, err := s.users.GetUserByEmail(strings.TrimSpace(req.Email))
+	if err != nil {
+		s.logger.Printf("Password reset requested for unknown email: %v", err)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err := s.sendEmailToken(user, models.PurposeResetPassword); err != nil {
+		s.logger.Printf("Error creating password reset token: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleResetPassword 处理 POST /password/reset，使用重置邮件中的令牌设置新密码并撤销该用户的全部会话
+func (s *Server) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Printf("Error decoding reset password request: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	// 先校验新密码，密码不合格时令牌不会被消耗
+	validationErrors := validator.ValidateResetPassword(req)
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+
+	if ok, retry := s.ipLimit.Allow(clientIP(r)); !ok {
+		tooManyRequests(w, retry)
+		return
+	}
+
+	token, err := s.emailTokens.ConsumeEmailToken(auth.HashToken(req.Token), models.PurposeResetPassword)
+	if err != nil {
+		s.emailTokenError(w, "resetting password", err)
+		return
+	}
+	if err := s.users.UpdateUserPassword(token.UserID, req.Password); err != nil {
+		s.logger.Printf("Error updating password: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	// 能收到重置邮件说明用户拥有该邮箱
+	if err := s.users.MarkEmailVerified(token.UserID); err != nil {
+		s.logger.Printf("Error marking email verified: %v", err)
+	}
+	if err := s.sessions.RevokeUserSessions(token.UserID); err != nil {
+		s.logger.Printf("Error revoking sessions: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Printf("User %d reset their password", token.UserID)
+	w.WriteHeader(http.StatusOK)
+}
+
+// sendEmailToken 为用户签发 purpose 用途的一次性令牌并在后台发送邮件，发送失败只记录日志
+func (s *Server) sendEmailToken(user models.User, purpose models.TokenPurpose) error {
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		return err
+	}
+
+	var ttl time.Duration
+	var msg mail.Message
+	switch purpose {
+	case models.PurposeVerifyEmail:
+		ttl = verifyEmailTTL
+		msg = mail.Message{
+			To:      user.Email,
+			Subject: "Verify your email address",
+			Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s/verify-email?token=%s\n\nThe link expires in %d hours. If you did not create an account, you can ignore this email.\n",
+				user.Username, s.linkBaseURL, token, int(ttl.Hours())),
+		}
+	case models.PurposeResetPassword:
+		ttl = resetPasswordTTL
+		msg = mail.Message{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf("Hi %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s/reset-password?token=%s\n\nThe link expires in %d minutes and can only be used once. If you did not request a reset, you can ignore this email.\n",
+				user.Username, s.linkBaseURL, token, int(ttl.Minutes())),
+		}
+	default:
+		return fmt.Errorf("unknown token purpose %q", purpose)
+	}
+
+	err = s.emailTokens.CreateEmailToken(models.EmailToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.mailer.Send(msg); err != nil {
+			s.logger.Printf("Error sending %s mail to user %d: %v", purpose, user.ID, err)
+		}
+	}()
+	return nil
+}
+
+// emailTokenError 把一次性令牌相关的存储错误映射为 HTTP 状态码
+func (s *Server) emailTokenError(w http.ResponseWriter, action string, err error) {
+	s.logger.Printf("Error %s: %v", action, err)
+	if errors.Is(err, store.ErrInvalidEmailToken) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// tooManyRequests 返回 429，Retry-After 为距离限流窗口结束的秒数
+func tooManyRequests(w http.ResponseWriter, retry time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+	http.Error(w, "Too many requests, please try again later", http.StatusTooManyRequests)
+}
+
+// clientIP 返回请求的客户端 IP，不信任 X-Forwarded-For，避免客户端伪造来源绕过限流
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,177 @@
+package api_test
+
+import (
+	"bytes"
+	"net/http"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/auth"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/storetest"
+)
+
+const (
+	verifySubject = "Verify your email address"
+	resetSubject  = "Reset your password"
+)
+
+// mailbox 收集服务日志，log 驱动把邮件连同其中的链接写入日志
+type mailbox struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (m *mailbox) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.buf.Write(p)
+}
+
+var mailPattern = regexp.MustCompile(`Mail to (\S+): ([^\n]*)\n(?s:.*?)\?token=([A-Za-z0-9_-]+)`)
+
+// tokens 返回已发给 to、主题为 subject 的邮件中的令牌，按发送顺序
+func (m *mailbox) tokens(to, subject string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var tokens []string
+	for _, match := range mailPattern.FindAllStringSubmatch(m.buf.String(), -1) {
+		if match[1] == to && match[2] == subject {
+			tokens = append(tokens, match[3])
+		}
+	}
+	return tokens
+}
+
+// waitToken 等待第 n 封（从 1 开始）发给 to 的 subject 邮件并返回其中的令牌，邮件在后台发送
+func (m *mailbox) waitToken(t *testing.T, to, subject string, n int) string {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if tokens := m.tokens(to, subject); len(tokens) >= n {
+			return tokens[n-1]
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("mail %d %q to %s was not sent", n, subject, to)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		mail := &mailbox{}
+		srv := newServerWithLog(t, st, mail)
+		alice := register(t, srv, "alice")
+		anon := &client{t: t, srv: srv}
+
+		var me models.UserResponse
+		alice.decode(http.MethodGet, "/me", nil, http.StatusOK, &me)
+		if me.EmailVerified {
+			t.Fatal("new account is already verified")
+		}
+
+		// 注册时发出的验证邮件中的令牌只能使用一次
+		token := mail.waitToken(t, "alice@example.com", verifySubject, 1)
+		anon.decode(http.MethodPost, "/verify-email", models.VerifyEmailRequest{Token: token}, http.StatusOK, nil)
+		alice.decode(http.MethodGet, "/me", nil, http.StatusOK, &me)
+		if !me.EmailVerified {
+			t.Error("email not verified after submitting the token")
+		}
+		anon.decode(http.MethodPost, "/verify-email", models.VerifyEmailRequest{Token: token}, http.StatusBadRequest, nil)
+
+		alice.decode(http.MethodPost, "/verify-email/request", nil, http.StatusConflict, nil)
+	})
+}
+
+func TestEmailTokenExpiry(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		srv := newServer(t, st)
+		alice := register(t, srv, "alice")
+		anon := &client{t: t, srv: srv}
+
+		for _, purpose := range []models.TokenPurpose{models.PurposeVerifyEmail, models.PurposeResetPassword} {
+			token, hash, err := auth.NewToken()
+			if err != nil {
+				t.Fatalf("NewToken: %v", err)
+			}
+			err = st.CreateEmailToken(models.EmailToken{
+				UserID:    alice.userID,
+				Purpose:   purpose,
+				TokenHash: hash,
+				ExpiresAt: time.Now().UTC().Add(-time.Minute),
+			})
+			if err != nil {
+				t.Fatalf("CreateEmailToken: %v", err)
+			}
+
+			if purpose == models.PurposeVerifyEmail {
+				anon.decode(http.MethodPost, "/verify-email", models.VerifyEmailRequest{Token: token}, http.StatusBadRequest, nil)
+			} else {
+				anon.decode(http.MethodPost, "/password/reset", models.ResetPasswordRequest{Token: token, Password: "newpassword123"}, http.StatusBadRequest, nil)
+			}
+		}
+	})
+}
+
+func TestPasswordReset(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		mail := &mailbox{}
+		srv := newServerWithLog(t, st, mail)
+		alice := register(t, srv, "alice")
+		anon := &client{t: t, srv: srv}
+
+		var login struct {
+			Token        string `json:"token"`
+			RefreshToken string `json:"refresh_token"`
+		}
+		anon.decode(http.MethodPost, "/login", models.LoginRequest{Email: "alice@example.com", Password: "password123"}, http.StatusOK, &login)
+		other := &client{t: t, srv: srv, token: login.Token}
+
+		// 未注册的邮箱同样返回 200，但不会发出邮件
+		anon.decode(http.MethodPost, "/password/forgot", models.ForgotPasswordRequest{Email: "nobody@example.com"}, http.StatusOK, nil)
+		anon.decode(http.MethodPost, "/password/forgot", models.ForgotPasswordRequest{Email: "alice@example.com"}, http.StatusOK, nil)
+		token := mail.waitToken(t, "alice@example.com", resetSubject, 1)
+		if tokens := mail.tokens("nobody@example.com", resetSubject); len(tokens) != 0 {
+			t.Errorf("reset mail sent to an unknown address")
+		}
+
+		// 不合格的新密码不消耗令牌
+		anon.decode(http.MethodPost, "/password/reset", models.ResetPasswordRequest{Token: token, Password: "short"}, http.StatusBadRequest, nil)
+		anon.decode(http.MethodPost, "/password/reset", models.ResetPasswordRequest{Token: token, Password: "newpassword123"}, http.StatusOK, nil)
+		anon.decode(http.MethodPost, "/password/reset", models.ResetPasswordRequest{Token: token, Password: "otherpassword123"}, http.StatusBadRequest, nil)
+
+		// 重置后全部会话失效，只能用新密码登录
+		alice.decode(http.MethodGet, "/todos", nil, http.StatusUnauthorized, nil)
+		other.decode(http.MethodGet, "/todos", nil, http.StatusUnauthorized, nil)
+		anon.decode(http.MethodPost, "/token/refresh", models.RefreshRequest{RefreshToken: login.RefreshToken}, http.StatusUnauthorized, nil)
+		anon.decode(http.MethodPost, "/login", models.LoginRequest{Email: "alice@example.com", Password: "password123"}, http.StatusUnauthorized, nil)
+		anon.decode(http.MethodPost, "/login", models.LoginRequest{Email: "alice@example.com", Password: "newpassword123"}, http.StatusOK, nil)
+	})
+}
+
+func TestEmailRateLimits(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		srv := newServer(t, st)
+		register(t, srv, "alice")
+		anon := &client{t: t, srv: srv}
+
+		// 每个账户每小时最多 3 封重置邮件，超出后返回 429
+		for i := 0; i < 3; i++ {
+			anon.decode(http.MethodPost, "/password/forgot", models.ForgotPasswordRequest{Email: "alice@example.com"}, http.StatusOK, nil)
+		}
+		anon.decode(http.MethodPost, "/password/forgot", models.ForgotPasswordRequest{Email: "Alice@Example.com"}, http.StatusTooManyRequests, nil)
+
+		// 同一 IP 每 15 分钟最多 20 次请求邮件或提交令牌，包括上面的 4 次
+		for i := 0; i < 16; i++ {
+			anon.decode(http.MethodPost, "/verify-email", models.VerifyEmailRequest{Token: "invalid"}, http.StatusBadRequest, nil)
+		}
+		anon.decode(http.MethodPost, "/verify-email", models.VerifyEmailRequest{Token: "invalid"}, http.StatusTooManyRequests, nil)
+		anon.decode(http.MethodPost, "/password/forgot", models.ForgotPasswordRequest{Email: "bob@example.com"}, http.StatusTooManyRequests, nil)
+	})
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// filterParams GET /todos 中表示过滤和排序的参数，智能列表只能保存这些参数
+var filterParams = map[string]bool{
+	"completed": true, "priority": true, "contains": true, "sort": true,
+	"tag": true, "tag_mode": true, "project_id": true, "assignee": true,
+	"due": true, "overdue": true,
+	"due_after": true, "due_before": true,
+	"created_after": true, "created_before": true,
+	"updated_after": true, "updated_before": true,
+}
+
+// todoFilter 从查询参数解析列表过滤条件，参数无效时写入 400 响应并返回 false
+func (s *Server) todoFilter(w http.ResponseWriter, query url.Values, userID int) (store.TodoFilter, bool) {
+	filter, validationErrors, err := s.parseTodoFilter(query, userID)
+	if err != nil {
+		s.logger.Printf("Error getting user timezone: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return filter, false
+	}
+
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return filter, false
+	}
+
+	return filter, true
+}
+
+// parseTodoFilter 解析列表过滤条件，返回参数错误；只有查询用户时区失败时返回 error
+func (s *Server) parseTodoFilter(query url.Values, userID int) (store.TodoFilter, map[string]string, error) {
+	var filter store.TodoFilter
+
+	validationErrors := parseTagFilter(query, &filter)
+	for field, msg := range parseFieldFilter(query, &filter) {
+		validationErrors[field] = msg
+	}
+
+	if projectID := query.Get("project_id"); projectID != "" {
+		id, err := strconv.Atoi(projectID)
+		if err != nil {
+			validationErrors["project_id"] = "project_id must be an integer"
+		}
+		filter.ProjectID = &id
+	}
+
+	// assignee=me 返回指派给当前用户的待办事项，也可以指定其他成员的用户ID
+	if assignee := query.Get("assignee"); assignee == "me" {
+		filter.AssigneeID = &userID
+	} else if assignee != "" {
+		id, err := strconv.Atoi(assignee)
+		if err != nil {
+			validationErrors["assignee"] = "assignee must be me or a user ID"
+		}
+		filter.AssigneeID = &id
+	}
+
+	// 没有日期相关参数时无需查询用户时区
+	if hasDateFilter(query) {
+		loc, err := s.userLocation(userID)
+		if err != nil {
+			return filter, nil, err
+		}
+
+		for field, msg := range parseDateFilter(query, loc, time.Now(), &filter) {
+			validationErrors[field] = msg
+		}
+	}
+
+	return filter, validationErrors, nil
+}
+
+// parseFieldFilter 解析 completed、priority（可逗号分隔多个）、contains 和 sort 参数
+func parseFieldFilter(query url.Values, filter *store.TodoFilter) map[string]string {
+	errors := make(map[string]string)
+
+	switch query.Get("completed") {
+	case "":
+	case "true", "false":
+		completed := query.Get("completed") == "true"
+		filter.Completed = &completed
+	default:
+		errors["completed"] = "completed must be true or false"
+	}
+
+	if v := query.Get("priority"); v != "" {
+		for _, p := range strings.Split(v, ",") {
+			if store.PriorityRank(p) == 0 {
+				errors["priority"] = "priority must be a comma-separated list of low, medium and high"
+				break
+			}
+			filter.Priorities = append(filter.Priorities, p)
+		}
+	}
+
+	if v := query.Get("contains"); utf8.RuneCountInString(v) > 255 {
+		errors["contains"] = "contains must be at most 255 characters"
+	} else {
+		filter.TitleContains = v
+	}
+
+	sort, err := store.ParseSort(query.Get("sort"))
+	if err != nil {
+		errors["sort"] = err.Error() + "; allowed fields are created_at, updated_at, due_at, priority, title, completed and position"
+	}
+	filter.Sort = sort
+
+	return errors
+}
+
+// parseTagFilter 解析可重复的 tag 参数和 tag_mode（all 为 AND，any 为 OR）
+func parseTagFilter(query url.Values, filter *store.TodoFilter) map[string]string {
+	errors := make(map[string]string)
+
+	for _, name := range query["tag"] {
+		if name != "" {
+			filter.Tags = append(filter.Tags, name)
+		}
+	}
+
+	switch mode := store.TagMode(query.Get("tag_mode")); mode {
+	case "", store.TagModeAll:
+		filter.TagMode = store.TagModeAll
+	case store.TagModeAny:
+		filter.TagMode = store.TagModeAny
+	default:
+		errors["tag_mode"] = "tag_mode must be all or any"
+	}
+
+	return errors
+}
+
+// dateParams 日期范围参数及其对应的过滤字段，after 包含边界，before 不包含
+var dateParams = []struct {
+	name  string
+	field func(*store.TodoFilter) **time.Time
+}{
+	{"due_after", func(f *store.TodoFilter) **time.Time { return &f.DueAfter }},
+	{"due_before", func(f *store.TodoFilter) **time.Time { return &f.DueBefore }},
+	{"created_after", func(f *store.TodoFilter) **time.Time { return &f.CreatedAfter }},
+	{"created_before", func(f *store.TodoFilter) **time.Time { return &f.CreatedBefore }},
+	{"updated_after", func(f *store.TodoFilter) **time.Time { return &f.UpdatedAfter }},
+	{"updated_before", func(f *store.TodoFilter) **time.Time { return &f.UpdatedBefore }},
+}
+
+// hasDateFilter 判断是否有需要按用户时区解析的参数
+func hasDateFilter(query url.Values) bool {
+	for _, p := range dateParams {
+		if query.Get(p.name) != "" {
+			return true
+		}
+	}
+	return query.Get("due") != "" || query.Get("overdue") != ""
+}
+
+// parseDateFilter 解析 dateParams 中的日期范围以及 due 和 overdue 参数，日期和相对日期按用户所在时区计算
+func parseDateFilter(query url.Values, loc *time.Location, now time.Time, filter *store.TodoFilter) map[string]string {
+	errors := make(map[string]string)
+
+	for _, p := range dateParams {
+		v := query.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := parseTimeParam(v, loc)
+		if err != nil {
+			errors[p.name] = p.name + " must be RFC 3339 or YYYY-MM-DD"
+		} else {
+			*p.field(filter) = &t
+		}
+	}
+
+	if v := query.Get("due"); v != "" {
+		today := startOfDay(now, loc)
+		var start time.Time
+		switch v {
+		case "today":
+			start = today
+		case "tomorrow":
+			start = today.AddDate(0, 0, 1)
+		default:
+			errors["due"] = "due must be today or tomorrow"
+		}
+		if !start.IsZero() {
+			end := start.AddDate(0, 0, 1)
+			filter.DueAfter = &start
+			filter.DueBefore = &end
+		}
+	}
+
+	switch query.Get("overdue") {
+	case "", "false":
+	case "true":
+		filter.Overdue = true
+	default:
+		errors["overdue"] = "overdue must be true or false"
+	}
+
+	return errors
+}
+
+// parseTimeParam 解析 RFC 3339 时间或按 loc 解释的日期
+func parseTimeParam(v string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", v, loc)
+}
+
+// startOfDay 返回 t 在 loc 时区当天的零点
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// userLocation 返回用户设置的时区，未设置或无法识别时使用 UTC
+func (s *Server) userLocation(userID int) (*time.Location, error) {
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		s.logger.Printf("Unknown timezone %q for user %d, falling back to UTC", user.Timezone, userID)
+		return time.UTC, nil
+	}
+	return loc, nil
+}
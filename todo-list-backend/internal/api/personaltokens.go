@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/joy_project/todo-list-backend/internal/auth"
+	"github.com/joy_project/todo-list-backend/internal/middleware"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/validator"
+)
+
+// handlePersonalTokens 处理 /tokens：GET 列出自己的个人访问令牌，POST 创建限定在当前工作区的个人访问令牌
+func (s *Server) handlePersonalTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		tokens, err := s.personalTokens.GetPersonalTokens(userID)
+		if err != nil {
+			s.logger.Printf("Error getting personal access tokens: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tokens)
+	case http.MethodPost:
+		workspaceID, ok := middleware.GetWorkspaceID(r)
+		if !ok {
+			http.Error(w, "Token has no workspace, please log in again", http.StatusUnauthorized)
+			return
+		}
+
+		var req models.CreatePersonalTokenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Printf("Error decoding personal access token request: %v", err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		validationErrors := validator.ValidatePersonalToken(req)
+		if len(validationErrors) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(validationErrors)
+			return
+		}
+
+		token, prefix, hash, err := auth.NewPersonalToken()
+		if err != nil {
+			s.logger.Printf("Error generating personal access token: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		// 权限范围去重并排序，保存和返回的形式与请求中的顺序无关
+		scopes := slices.Clone(req.Scopes)
+		slices.Sort(scopes)
+		pat := models.PersonalAccessToken{
+			UserID:      userID,
+			WorkspaceID: workspaceID,
+			Name:        strings.TrimSpace(req.Name),
+			Prefix:      prefix,
+			TokenHash:   hash,
+			Scopes:      slices.Compact(scopes),
+			ExpiresAt:   req.ExpiresAt,
+		}
+		id, err := s.personalTokens.CreatePersonalToken(pat)
+		if err != nil {
+			s.logger.Printf("Error creating personal access token: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		pat.ID = int(id)
+
+		s.logger.Printf("User %d created personal access token %d", userID, id)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(models.CreatePersonalTokenResponse{PersonalAccessToken: pat, Token: token})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePersonalToken 处理 DELETE /tokens/{id}，撤销个人访问令牌后立即失效
+func (s *Server) handlePersonalToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/tokens/"))
+	if err != nil {
+		s.logger.Printf("Invalid personal access token ID: %v", err)
+		http.Error(w, "Invalid personal access token ID", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := s.personalTokens.DeletePersonalToken(id, userID); err != nil {
+		s.logger.Printf("Error deleting personal access token: %v", err)
+		if errors.Is(err, store.ErrPersonalTokenNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,76 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/storetest"
+)
+
+// personalToken 在 c 当前的工作区中创建拥有 scopes 的个人访问令牌，返回以该令牌调用 API 的客户端
+func (c *client) personalToken(scopes ...models.Scope) *client {
+	c.t.Helper()
+
+	var resp models.CreatePersonalTokenResponse
+	c.decode(http.MethodPost, "/tokens", models.CreatePersonalTokenRequest{Name: "script", Scopes: scopes}, http.StatusCreated, &resp)
+	return &client{t: c.t, srv: c.srv, token: resp.Token, userID: c.userID, workspaceID: c.workspaceID}
+}
+
+func TestPersonalTokenScopes(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		srv := newServer(t, st)
+		alice := register(t, srv, "alice")
+		todo := alice.createTodo(map[string]interface{}{"title": "alice's"})
+		other := alice.create("/workspaces", map[string]string{"name": "Other"})
+
+		reader := alice.personalToken(models.ScopeTodosRead)
+		writer := alice.personalToken(models.ScopeTodosRead, models.ScopeTodosWrite, models.ScopeWorkspacesRead, models.ScopeWorkspacesWrite)
+		own := fmt.Sprintf("/workspaces/%d", alice.workspaceID)
+
+		tests := []struct {
+			name   string
+			c      *client
+			method string
+			path   string
+			body   interface{}
+			want   int
+		}{
+			{"read scope lists todos", reader, http.MethodGet, "/todos", nil, http.StatusOK},
+			{"read scope gets a todo", reader, http.MethodGet, fmt.Sprintf("/todos/%d", todo), nil, http.StatusOK},
+			{"read scope searches", reader, http.MethodGet, "/todos/search?q=alice", nil, http.StatusOK},
+			{"write needs write scope", reader, http.MethodPost, "/todos", map[string]string{"title": "x", "priority": "low"}, http.StatusForbidden},
+			{"delete needs write scope", reader, http.MethodDelete, fmt.Sprintf("/todos/%d", todo), nil, http.StatusForbidden},
+			{"tags outside scopes", reader, http.MethodGet, "/tags", nil, http.StatusForbidden},
+			{"projects outside scopes", reader, http.MethodGet, "/projects", nil, http.StatusForbidden},
+			{"profile outside scopes", reader, http.MethodGet, "/me", nil, http.StatusForbidden},
+			{"workspaces outside scopes", reader, http.MethodGet, own, nil, http.StatusForbidden},
+			{"session-only token list", writer, http.MethodGet, "/tokens", nil, http.StatusForbidden},
+			{"session-only token creation", writer, http.MethodPost, "/tokens", models.CreatePersonalTokenRequest{Name: "x", Scopes: []models.Scope{models.ScopeTodosRead}}, http.StatusForbidden},
+			{"session-only two-factor", writer, http.MethodGet, "/2fa", nil, http.StatusForbidden},
+			{"write scope creates", writer, http.MethodPost, "/todos", map[string]string{"title": "x", "priority": "low"}, http.StatusCreated},
+			{"own workspace", writer, http.MethodGet, own, nil, http.StatusOK},
+			{"other workspace", writer, http.MethodGet, fmt.Sprintf("/workspaces/%d", other), nil, http.StatusForbidden},
+			{"other workspace members", writer, http.MethodGet, fmt.Sprintf("/workspaces/%d/members", other), nil, http.StatusForbidden},
+			{"switch to own workspace", writer, http.MethodPost, own + "/switch", nil, http.StatusForbidden},
+			{"switch to other workspace", writer, http.MethodPost, fmt.Sprintf("/workspaces/%d/switch", other), nil, http.StatusForbidden},
+		}
+		for _, tt := range tests {
+			if status, body := tt.c.do(tt.method, tt.path, tt.body); status != tt.want {
+				t.Errorf("%s: %s %s = %d, want %d: %s", tt.name, tt.method, tt.path, status, tt.want, body)
+			}
+		}
+
+		// 在其他工作区创建的待办事项对该工作区的令牌不可见
+		alice.switchTo(other).createTodo(map[string]interface{}{"title": "elsewhere"})
+		var todos []models.Todo
+		reader.decode(http.MethodGet, "/todos", nil, http.StatusOK, &todos)
+		for _, td := range todos {
+			if td.Title == "elsewhere" {
+				t.Errorf("token from workspace %d sees todo %d from workspace %d", alice.workspaceID, td.ID, other)
+			}
+		}
+	})
+}
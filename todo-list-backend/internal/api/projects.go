@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/joy_project/todo-list-backend/internal/middleware"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/validator"
+)
+
+func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		// 默认不返回已归档的项目，?archived=true 时一并返回
+		projects, err := s.projects.GetProjects(userID, r.URL.Query().Get("archived") == "true")
+		if err != nil {
+			s.logger.Printf("Error getting projects: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(projects)
+	case http.MethodPost:
+		s.createProject(w, r, userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleProject(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// 路径格式为 /projects/{id} 或 /projects/{id}/{子资源}
+	idStr, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/projects/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		s.logger.Printf("Invalid project ID: %v", err)
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case sub == "members" || strings.HasPrefix(sub, "members/"):
+		s.handleMembers(w, r, userID, id, strings.TrimPrefix(strings.TrimPrefix(sub, "members"), "/"))
+		return
+	case sub == "invitations":
+		s.handleProjectInvitations(w, r, userID, id)
+		return
+	case sub != "":
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		project, err := s.projects.GetProject(id, userID)
+		if err != nil {
+			s.projectError(w, "getting project", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(project)
+	case http.MethodPut:
+		s.updateProject(w, r, userID, id)
+	case http.MethodDelete:
+		// 项目中的待办事项会被移到收件箱
+		if err := s.projects.DeleteProject(id, userID); err != nil {
+			s.projectError(w, "deleting project", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) createProject(w http.ResponseWriter, r *http.Request, userID int) {
+	var project models.Project
+	if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
+		s.logger.Printf("Error decoding project: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if project.Color == "" {
+		project.Color = defaultColor
+	}
+
+	validationErrors := validator.ValidateProject(project)
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+
+	project.UserID = userID
+	id, err := s.projects.CreateProject(project)
+	if err != nil {
+		s.projectError(w, "creating project", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]int64{"id": id})
+}
+
+func (s *Server) updateProject(w http.ResponseWriter, r *http.Request, userID int, id int) {
+	var project models.Project
+	if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
+		s.logger.Printf("Error decoding project: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if project.Color == "" {
+		project.Color = defaultColor
+	}
+
+	validationErrors := validator.ValidateProject(project)
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+
+	project.ID = id
+	project.UserID = userID
+	if err := s.projects.UpdateProject(project); err != nil {
+		s.projectError(w, "updating project", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// projectError 将项目相关的存储错误映射为 HTTP 状态码
+func (s *Server) projectError(w http.ResponseWriter, action string, err error) {
+	s.logger.Printf("Error %s: %v", action, err)
+	switch {
+	case errors.Is(err, store.ErrProjectNotFound), errors.Is(err, store.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, store.ErrInboxProject), errors.Is(err, store.ErrAlreadyMember), errors.Is(err, store.ErrInvitationExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, store.ErrUserNotFound), errors.Is(err, store.ErrMemberNotFound), errors.Is(err, store.ErrInvitationNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/recurrence"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// normalizeRecurrence 将已通过校验的规则转换为规范形式，便于存储和比较
+func normalizeRecurrence(rule string) string {
+	if rule == "" {
+		return ""
+	}
+	parsed, err := recurrence.Parse(rule)
+	if err != nil {
+		return rule
+	}
+	return parsed.String()
+}
+
+// spawnNextOccurrence 在重复待办被完成后以原创建者的身份创建下一次实例，规则已结束时返回 0
+func (s *Server) spawnNextOccurrence(todo models.Todo) (int64, error) {
+	rule, err := recurrence.Parse(todo.Recurrence)
+	if err != nil || todo.DueAt == nil {
+		return 0, err
+	}
+
+	loc, err := s.userLocation(todo.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	nextDue, ok := rule.Next(*todo.DueAt, todo.Occurrence, loc)
+	if !ok {
+		return 0, nil
+	}
+
+	next := models.Todo{
+		Title:      todo.Title,
+		Priority:   todo.Priority,
+		UserID:     todo.UserID,
+		AssigneeID: todo.AssigneeID,
+		ProjectID:  todo.ProjectID,
+		DueAt:      &nextDue,
+		StartAt:    shiftRelative(todo.StartAt, *todo.DueAt, nextDue),
+		RemindAt:   shiftRelative(todo.RemindAt, *todo.DueAt, nextDue),
+		Recurrence: todo.Recurrence,
+		Occurrence: todo.Occurrence + 1,
+	}
+
+	id, err := s.todos.CreateTodo(next)
+	if errors.Is(err, store.ErrInvalidAssignee) {
+		// 负责人已离开工作区时不再指派
+		next.AssigneeID = nil
+		id, err = s.todos.CreateTodo(next)
+	}
+	if errors.Is(err, store.ErrInvalidProject) || errors.Is(err, store.ErrForbidden) {
+		// 原项目已归档、删除或创建者已失去编辑权限时放入收件箱
+		inbox, err := s.projects.EnsureInbox(todo.UserID)
+		if err != nil {
+			return 0, err
+		}
+		next.ProjectID = &inbox.ID
+		return s.todos.CreateTodo(next)
+	}
+	return id, err
+}
+
+// shiftRelative 保持 t 与旧截止时间的间隔，计算相对新截止时间的对应时刻
+func shiftRelative(t *time.Time, oldDue, newDue time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	shifted := newDue.Add(t.Sub(oldDue))
+	return &shifted
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/joy_project/todo-list-backend/internal/middleware"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/search"
+)
+
+// searchBatch 每批从存储层取出的候选数，全部候选分批参与排序，内存中只保留当前批次和得分最高的结果
+const searchBatch = 500
+
+// handleSearch 处理 GET /todos/search?q=&limit=，在标题和评论中搜索并按相关度排序
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query().Get("q")
+	terms := search.ParseQuery(q)
+	validationErrors := make(map[string]string)
+	if utf8.RuneCountInString(q) > 200 {
+		validationErrors["q"] = "Query must be at most 200 characters"
+	} else if len(terms) == 0 {
+		validationErrors["q"] = "Query must contain at least one letter, digit or CJK character"
+	}
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+
+	limit := 20
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	results := []models.SearchResult{}
+	beforeID := 0
+	for {
+		todos, comments, more, err := s.todos.SearchTodos(userID, search.Texts(terms), beforeID, searchBatch)
+		if err != nil {
+			s.logger.Printf("Error searching todos: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		for _, todo := range todos {
+			fields := []search.Field{{Name: "title", Text: todo.Title, Weight: search.TitleWeight}}
+			for _, c := range comments[todo.ID] {
+				fields = append(fields, search.Field{Name: "comment", ID: c.ID, Text: c.Body, Weight: search.CommentWeight, Snippet: true})
+			}
+
+			score, highlights, ok := search.Rank(terms, fields)
+			if !ok {
+				continue
+			}
+			result := models.SearchResult{Todo: todo, Score: score, Highlights: make([]models.SearchHighlight, len(highlights))}
+			for i, h := range highlights {
+				result.Highlights[i] = models.SearchHighlight{Field: h.Field, CommentID: h.ID, Snippet: h.Snippet}
+			}
+			results = append(results, result)
+		}
+
+		// 候选按ID倒序分批返回，得分相同时较新创建的排在前面
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Score > results[j].Score
+		})
+		if len(results) > limit {
+			results = results[:limit]
+		}
+
+		if !more {
+			break
+		}
+		beforeID = todos[len(todos)-1].ID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,40 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/storetest"
+)
+
+func TestSearchRanksAllCandidates(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		srv := newServer(t, st)
+		alice := register(t, srv, "alice")
+
+		// 最早创建的精确匹配之后有超过一批的较新的前缀匹配
+		exact := alice.createTodo(map[string]interface{}{"title": "milk"})
+		ws := st.Workspace(alice.workspaceID)
+		for i := 0; i < 600; i++ {
+			if _, err := ws.CreateTodo(models.Todo{Title: fmt.Sprintf("milkshake %d", i), Priority: "low", UserID: alice.userID}); err != nil {
+				t.Fatalf("CreateTodo: %v", err)
+			}
+		}
+
+		var results []models.SearchResult
+		alice.decode(http.MethodGet, "/todos/search?q=milk&limit=3", nil, http.StatusOK, &results)
+		if len(results) != 3 || results[0].Todo.ID != exact {
+			t.Fatalf("results = %+v, want the exact match %d first", results, exact)
+		}
+		if results[0].Highlights[0].Snippet != "<mark>milk</mark>" || results[1].Score >= results[0].Score {
+			t.Errorf("results = %+v", results)
+		}
+		// 得分相同的前缀匹配中最新创建的在前
+		if results[1].Todo.Title != "milkshake 599" || results[2].Todo.Title != "milkshake 598" {
+			t.Errorf("ties = %q, %q; want the newest first", results[1].Todo.Title, results[2].Todo.Title)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/auth"
+	"github.com/joy_project/todo-list-backend/internal/blob"
+	"github.com/joy_project/todo-list-backend/internal/config"
+	"github.com/joy_project/todo-list-backend/internal/mail"
+	"github.com/joy_project/todo-list-backend/internal/middleware"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/ratelimit"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// Server 持有处理器依赖的存储、令牌管理器和日志，数据存储只在 tenant 包装的处理器中可用
+type Server struct {
+	users          store.UserStore
+	workspaces     store.WorkspaceStore
+	sessions       store.TokenStore
+	personalTokens store.PersonalTokenStore
+	scoped         func(workspaceID int) store.TenantStore
+	todos          store.TodoStore
+	tags           store.TagStore
+	comments       store.CommentStore
+	attachments    store.AttachmentStore
+	projects       store.ProjectStore
+	shares         store.ShareStore
+	smartLists     store.SmartListStore
+	blobs          blob.Store
+	blobMu         *sync.RWMutex // 上传持有读锁，清理无引用的内容时持有写锁
+	attachCfg      config.AttachmentsConfig
+	tokens         *auth.Manager
+	refreshTTL     time.Duration
+	cors           func(http.HandlerFunc) http.HandlerFunc
+	logger         *log.Logger
+	// 邮箱验证和密码重置
+	emailTokens  store.EmailTokenStore
+	mailer       mail.Mailer
+	linkBaseURL  string
+	accountLimit *ratelimit.Limiter // 每个账户发送邮件的频率
+	ipLimit      *ratelimit.Limiter // 每个客户端 IP 请求邮件或提交令牌的频率
+	// 两步验证
+	twoFactor  store.TwoFactorStore
+	totpIssuer string
+	codeLimit  *ratelimit.Limiter // 每个用户提交验证码的频率
+}
+
+// NewServer 使用给定的存储实现、附件内容存储、令牌管理器、邮件发送器和配置创建 API 服务
+func NewServer(st store.Store, blobs blob.Store, tokens *auth.Manager, mailer mail.Mailer, cfg *config.Config, logger *log.Logger) *Server {
+	return &Server{
+		users:          st,
+		workspaces:     st,
+		sessions:       st,
+		personalTokens: st,
+		scoped:         st.Workspace,
+		blobs:          blobs,
+		blobMu:         &sync.RWMutex{},
+		attachCfg:      cfg.Attachments,
+		tokens:         tokens,
+		refreshTTL:     cfg.Auth.RefreshTTL,
+		cors:           middleware.CORS(cfg.CORS.AllowedOrigins),
+		logger:         logger,
+		emailTokens:    st,
+		mailer:         mailer,
+		linkBaseURL:    strings.TrimSuffix(cfg.Mail.LinkBaseURL, "/"),
+		accountLimit:   ratelimit.New(mailsPerAccount, time.Hour),
+		ipLimit:        ratelimit.New(emailRequestsPerIP, 15*time.Minute),
+		twoFactor:      st,
+		totpIssuer:     cfg.Auth.TOTPIssuer,
+		codeLimit:      ratelimit.New(codeAttemptsPerUser, 15*time.Minute),
+	}
+}
+
+// Handler 返回注册了全部路由的 http.Handler
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	// 公共路由
+	mux.HandleFunc("/register", s.cors(s.logRequest(s.handleRegister)))
+	mux.HandleFunc("/login", s.cors(s.logRequest(s.handleLogin)))
+	mux.HandleFunc("/login/2fa", s.cors(s.logRequest(s.handleLoginTwoFactor)))
+	mux.HandleFunc("/token/refresh", s.cors(s.logRequest(s.handleRefresh)))
+	mux.HandleFunc("/.well-known/jwks.json", s.cors(s.logRequest(s.handleJWKS)))
+	mux.HandleFunc("/verify-email", s.cors(s.logRequest(s.handleVerifyEmail)))
+	mux.HandleFunc("/password/forgot", s.cors(s.logRequest(s.handleForgotPassword)))
+	mux.HandleFunc("/password/reset", s.cors(s.logRequest(s.handleResetPassword)))
+
+	// 需要认证的路由
+	mux.HandleFunc("/me", s.cors(s.logRequest(s.auth(profileScopes, s.handleMe))))
+	mux.HandleFunc("/verify-email/request", s.cors(s.logRequest(s.auth(profileScopes, s.handleRequestVerification))))
+	mux.HandleFunc("/logout", s.cors(s.logRequest(s.auth(sessionOnly, s.handleLogout))))
+	mux.HandleFunc("/2fa", s.cors(s.logRequest(s.auth(sessionOnly, s.handleTwoFactor))))
+	mux.HandleFunc("/2fa/enroll", s.cors(s.logRequest(s.auth(sessionOnly, s.handleTwoFactorEnroll))))
+	mux.HandleFunc("/2fa/confirm", s.cors(s.logRequest(s.auth(sessionOnly, s.handleTwoFactorConfirm))))
+	mux.HandleFunc("/2fa/disable", s.cors(s.logRequest(s.auth(sessionOnly, s.handleTwoFactorDisable))))
+	mux.HandleFunc("/2fa/recovery-codes", s.cors(s.logRequest(s.auth(sessionOnly, s.handleRecoveryCodes))))
+	mux.HandleFunc("/tokens", s.cors(s.logRequest(s.auth(sessionOnly, s.handlePersonalTokens))))
+	mux.HandleFunc("/tokens/", s.cors(s.logRequest(s.auth(sessionOnly, s.handlePersonalToken))))
+	mux.HandleFunc("/workspaces", s.cors(s.logRequest(s.auth(workspacesScopes, s.handleWorkspaces))))
+	mux.HandleFunc("/workspaces/", s.cors(s.logRequest(s.auth(workspacesScopes, s.handleWorkspace))))
+
+	// 工作区内的路由
+	mux.HandleFunc("/todos", s.cors(s.logRequest(s.auth(todosScopes, s.tenant((*Server).handleTodos)))))
+	mux.HandleFunc("/todos/", s.cors(s.logRequest(s.auth(todosScopes, s.tenant((*Server).handleTodo)))))
+	mux.HandleFunc("/todos/search", s.cors(s.logRequest(s.auth(todosScopes, s.tenant((*Server).handleSearch)))))
+	mux.HandleFunc("/tags", s.cors(s.logRequest(s.auth(tagsScopes, s.tenant((*Server).handleTags)))))
+	mux.HandleFunc("/tags/", s.cors(s.logRequest(s.auth(tagsScopes, s.tenant((*Server).handleTag)))))
+	mux.HandleFunc("/projects", s.cors(s.logRequest(s.auth(projectsScopes, s.tenant((*Server).handleProjects)))))
+	mux.HandleFunc("/projects/", s.cors(s.logRequest(s.auth(projectsScopes, s.tenant((*Server).handleProject)))))
+	mux.HandleFunc("/invitations", s.cors(s.logRequest(s.auth(projectsScopes, s.tenant((*Server).handleInvitations)))))
+	mux.HandleFunc("/invitations/", s.cors(s.logRequest(s.auth(projectsScopes, s.tenant((*Server).handleInvitation)))))
+	mux.HandleFunc("/smart-lists", s.cors(s.logRequest(s.auth(todosScopes, s.tenant((*Server).handleSmartLists)))))
+	mux.HandleFunc("/smart-lists/", s.cors(s.logRequest(s.auth(todosScopes, s.tenant((*Server).handleSmartList)))))
+
+	return mux
+}
+
+// scopes 个人访问令牌调用某组路由需要的权限范围，读取和修改分别对应 GET 和其余方法
+type scopes struct {
+	read, write models.Scope
+}
+
+// 各组路由的权限范围，sessionOnly 的路由只接受登录得到的 JWT
+var (
+	profileScopes    = scopes{models.ScopeProfileRead, models.ScopeProfileWrite}
+	workspacesScopes = scopes{models.ScopeWorkspacesRead, models.ScopeWorkspacesWrite}
+	todosScopes      = scopes{models.ScopeTodosRead, models.ScopeTodosWrite}
+	tagsScopes       = scopes{models.ScopeTagsRead, models.ScopeTagsWrite}
+	projectsScopes   = scopes{models.ScopeProjectsRead, models.ScopeProjectsWrite}
+	sessionOnly      = scopes{}
+)
+
+// auth 要求请求携带有效且未被撤销的 JWT，或拥有 required 权限范围的个人访问令牌
+func (s *Server) auth(required scopes, next http.HandlerFunc) http.HandlerFunc {
+	return middleware.Auth(s.tokens, s.sessions, s.personalTokens, middleware.RequireScope(required.read, required.write, next))
+}
+
+// tenant 把处理器的数据访问限定在令牌对应的工作区内，处理器运行在持有该工作区视图的 Server 副本上
+func (s *Server) tenant(handler func(*Server, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		workspaceID, ok := middleware.GetWorkspaceID(r)
+		if !ok {
+			http.Error(w, "Token has no workspace, please log in again", http.StatusUnauthorized)
+			return
+		}
+
+		// 每次请求都检查成员资格，被移出工作区的用户即使令牌未过期也会立即失去访问权限
+		if _, err := s.workspaces.GetWorkspace(workspaceID, userID); err != nil {
+			s.logger.Printf("Error checking workspace membership: %v", err)
+			if errors.Is(err, store.ErrWorkspaceNotFound) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+			} else {
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		scoped := *s
+		tenant := s.scoped(workspaceID)
+		scoped.todos, scoped.tags, scoped.comments, scoped.attachments = tenant, tenant, tenant, tenant
+		scoped.projects, scoped.shares, scoped.smartLists = tenant, tenant, tenant
+		handler(&scoped, w, r)
+	}
+}
+
+func (s *Server) logRequest(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.logger.Printf("%s %s", r.Method, r.URL.Path)
+		next.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/joy_project/todo-list-backend/internal/middleware"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/validator"
+)
+
+// handleMembers 处理 /projects/{id}/members（GET 列表）和 /projects/{id}/members/{userID}（PUT 修改角色，DELETE 移除）
+func (s *Server) handleMembers(w http.ResponseWriter, r *http.Request, userID int, projectID int, rest string) {
+	if rest == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		members, err := s.shares.GetMembers(projectID, userID)
+		if err != nil {
+			s.projectError(w, "getting members", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(members)
+		return
+	}
+
+	memberID, err := strconv.Atoi(rest)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		var req models.UpdateMemberRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Printf("Error decoding member request: %v", err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		if msg := validator.ValidateRole(req.Role); msg != "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"role": msg})
+			return
+		}
+
+		if err := s.shares.UpdateMemberRole(projectID, memberID, req.Role, userID); err != nil {
+			s.projectError(w, "updating member", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		// owner 可以移除任何成员，成员也可以移除自己以退出项目
+		if err := s.shares.RemoveMember(projectID, memberID, userID); err != nil {
+			s.projectError(w, "removing member", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleProjectInvitations 处理 /projects/{id}/invitations：GET 查看待处理的邀请，POST 邀请用户
+func (s *Server) handleProjectInvitations(w http.ResponseWriter, r *http.Request, userID int, projectID int) {
+	switch r.Method {
+	case http.MethodGet:
+		invitations, err := s.shares.GetProjectInvitations(projectID, userID)
+		if err != nil {
+			s.projectError(w, "getting invitations", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(invitations)
+	case http.MethodPost:
+		var req models.InviteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Printf("Error decoding invite request: %v", err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		validationErrors := validator.ValidateInvite(req)
+		if len(validationErrors) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(validationErrors)
+			return
+		}
+
+		id, err := s.shares.CreateInvitation(projectID, req.Email, req.Role, userID)
+		if err != nil {
+			s.projectError(w, "creating invitation", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]int64{"id": id})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleInvitations 列出当前用户收到的待处理邀请
+func (s *Server) handleInvitations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	invitations, err := s.shares.GetInvitations(userID)
+	if err != nil {
+		s.logger.Printf("Error getting invitations: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invitations)
+}
+
+// handleInvitation 处理 POST /invitations/{id}/accept 和 POST /invitations/{id}/decline
+func (s *Server) handleInvitation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/invitations/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid invitation ID", http.StatusBadRequest)
+		return
+	}
+	if action != "accept" && action != "decline" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := s.shares.RespondInvitation(id, userID, action == "accept"); err != nil {
+		s.projectError(w, "responding to invitation", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/joy_project/todo-list-backend/internal/middleware"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/validator"
+)
+
+// handleSmartLists 处理 /smart-lists：GET 列出自己的智能列表，POST 创建智能列表
+func (s *Server) handleSmartLists(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		lists, err := s.smartLists.GetSmartLists(userID)
+		if err != nil {
+			s.logger.Printf("Error getting smart lists: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(lists)
+	case http.MethodPost:
+		list, ok := s.decodeSmartList(w, r, userID)
+		if !ok {
+			return
+		}
+
+		id, err := s.smartLists.CreateSmartList(list)
+		if err != nil {
+			s.smartListError(w, "creating smart list", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]int64{"id": id})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleSmartList 处理 /smart-lists/{id}（GET 查看，PUT 修改，DELETE 删除）和 /smart-lists/{id}/todos
+func (s *Server) handleSmartList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// 路径格式为 /smart-lists/{id} 或 /smart-lists/{id}/todos
+	idStr, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/smart-lists/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		s.logger.Printf("Invalid smart list ID: %v", err)
+		http.Error(w, "Invalid smart list ID", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case sub == "todos" && r.Method == http.MethodGet:
+		s.getSmartListTodos(w, r, userID, id)
+	case sub == "" && r.Method == http.MethodGet:
+		list, err := s.smartLists.GetSmartList(id, userID)
+		if err != nil {
+			s.smartListError(w, "getting smart list", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	case sub == "" && r.Method == http.MethodPut:
+		list, ok := s.decodeSmartList(w, r, userID)
+		if !ok {
+			return
+		}
+
+		list.ID = id
+		if err := s.smartLists.UpdateSmartList(list); err != nil {
+			s.smartListError(w, "updating smart list", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case sub == "" && r.Method == http.MethodDelete:
+		if err := s.smartLists.DeleteSmartList(id, userID); err != nil {
+			s.smartListError(w, "deleting smart list", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case sub == "" || sub == "todos":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// decodeSmartList 读取并校验请求中的智能列表，查询参数保存为规范化的形式；无效时写入 400 响应并返回 false
+func (s *Server) decodeSmartList(w http.ResponseWriter, r *http.Request, userID int) (models.SmartList, bool) {
+	var list models.SmartList
+	if err := json.NewDecoder(r.Body).Decode(&list); err != nil {
+		s.logger.Printf("Error decoding smart list: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return list, false
+	}
+	list.UserID = userID
+
+	validationErrors := validator.ValidateSmartList(list)
+	if len(validationErrors) == 0 {
+		query, _, filterErrors, err := s.smartListFilter(list.Query, userID)
+		if err != nil {
+			s.logger.Printf("Error getting user timezone: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return list, false
+		}
+		validationErrors = filterErrors
+		list.Query = query.Encode()
+	}
+
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return list, false
+	}
+	return list, true
+}
+
+// smartListFilter 按当前的列表过滤参数重新解析智能列表保存的查询，只允许 filterParams 中的参数
+func (s *Server) smartListFilter(raw string, userID int) (url.Values, store.TodoFilter, map[string]string, error) {
+	query, err := url.ParseQuery(strings.TrimPrefix(raw, "?"))
+	if err != nil {
+		return nil, store.TodoFilter{}, map[string]string{"query": "Query must be URL-encoded parameters like priority=high&completed=false"}, nil
+	}
+
+	var unknown []string
+	for name := range query {
+		if !filterParams[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, store.TodoFilter{}, map[string]string{"query": "Unsupported filter parameters: " + strings.Join(unknown, ", ")}, nil
+	}
+
+	filter, validationErrors, err := s.parseTodoFilter(query, userID)
+	return query, filter, validationErrors, err
+}
+
+// getSmartListTodos 处理 GET /smart-lists/{id}/todos，保存的条件在当前版本下已无效时返回 422
+func (s *Server) getSmartListTodos(w http.ResponseWriter, r *http.Request, userID int, id int) {
+	list, err := s.smartLists.GetSmartList(id, userID)
+	if err != nil {
+		s.smartListError(w, "getting smart list", err)
+		return
+	}
+
+	_, filter, validationErrors, err := s.smartListFilter(list.Query, userID)
+	if err != nil {
+		s.logger.Printf("Error getting user timezone: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if len(validationErrors) > 0 {
+		s.logger.Printf("Smart list %d has an invalid query %q: %v", id, list.Query, validationErrors)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+
+	s.listTodos(w, r, userID, filter)
+}
+
+// smartListError 把智能列表存储返回的错误映射为 HTTP 状态码
+func (s *Server) smartListError(w http.ResponseWriter, action string, err error) {
+	s.logger.Printf("Error %s: %v", action, err)
+	switch {
+	case errors.Is(err, store.ErrSmartListNotFound):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// getSubtree 以嵌套结构返回待办事项及其全部子项
+func (s *Server) getSubtree(w http.ResponseWriter, r *http.Request, userID int, id int) {
+	todos, err := s.todos.GetSubtree(id, userID)
+	if err != nil {
+		s.logger.Printf("Error getting subtree: %v", err)
+		if errors.Is(err, store.ErrTodoNotFound) || errors.Is(err, store.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildTree(todos))
+}
+
+// moveTodo 将待办事项连同子树移动到新的父项下、另一个项目中，或者锚点之前或之后
+func (s *Server) moveTodo(w http.ResponseWriter, r *http.Request, userID int, id int) {
+	var req models.MoveTodoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Printf("Error decoding move request: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	switch {
+	case req.ParentID != nil && req.ProjectID != nil:
+		// 子项总是跟随父项所在的项目，两者同时指定没有意义
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"project_id": "parent_id and project_id cannot both be set"})
+		return
+	case req.BeforeID != nil || req.AfterID != nil:
+		// 锚点决定了目标位置，不能再指定父项或项目
+		if msg := anchorError(req); msg != "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"before_id": msg})
+			return
+		}
+		err = s.moveNextTo(id, userID, req)
+	case req.ProjectID != nil:
+		err = s.todos.MoveTodoToProject(id, userID, *req.ProjectID)
+	default:
+		err = s.todos.MoveTodo(id, userID, req.ParentID)
+	}
+	if err != nil {
+		s.logger.Printf("Error moving todo: %v", err)
+		switch {
+		case errors.Is(err, store.ErrTodoNotFound), errors.Is(err, store.ErrForbidden):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, store.ErrInvalidParent), errors.Is(err, store.ErrInvalidProject), errors.Is(err, store.ErrInvalidAnchor):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// anchorError 检查移动请求中的锚点参数，返回空字符串表示有效
+func anchorError(req models.MoveTodoRequest) string {
+	switch {
+	case req.BeforeID != nil && req.AfterID != nil:
+		return "before_id and after_id cannot both be set"
+	case req.ParentID != nil || req.ProjectID != nil:
+		return "before_id and after_id cannot be combined with parent_id or project_id"
+	}
+	return ""
+}
+
+// moveNextTo 把待办事项移动到锚点之前或之后，锚点在其他父项或项目下时先把子树移动过去
+func (s *Server) moveNextTo(id int, userID int, req models.MoveTodoRequest) error {
+	before := req.BeforeID != nil
+	anchorID := req.AfterID
+	if before {
+		anchorID = req.BeforeID
+	}
+
+	anchor, err := s.todos.GetTodo(*anchorID, userID)
+	if errors.Is(err, store.ErrTodoNotFound) || errors.Is(err, store.ErrForbidden) {
+		return store.ErrInvalidAnchor
+	}
+	if err != nil {
+		return err
+	}
+	todo, err := s.todos.GetTodo(id, userID)
+	if err != nil {
+		return err
+	}
+
+	if !sameParent(todo, anchor) {
+		switch {
+		case anchor.ParentID == nil && anchor.ProjectID != nil:
+			err = s.todos.MoveTodoToProject(id, userID, *anchor.ProjectID)
+		default:
+			err = s.todos.MoveTodo(id, userID, anchor.ParentID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.todos.ReorderTodo(id, userID, *anchorID, before)
+}
+
+// sameParent 判断两个待办事项是否在同一项目的同一父项下
+func sameParent(a, b models.Todo) bool {
+	same := func(x, y *int) bool {
+		if x == nil || y == nil {
+			return x == nil && y == nil
+		}
+		return *x == *y
+	}
+	return same(a.ParentID, b.ParentID) && same(a.ProjectID, b.ProjectID)
+}
+
+// completeParents 当某个父项的子项全部完成时自动完成该父项，并继续向上检查
+func (s *Server) completeParents(parentID int, userID int) error {
+	for {
+		children, err := s.todos.GetChildren(parentID, userID)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			if !child.Completed {
+				return nil
+			}
+		}
+
+		parent, err := s.todos.GetTodo(parentID, userID)
+		if err != nil {
+			return err
+		}
+		if !parent.Completed {
+			// 以当前用户的身份修改，父项的创建者可能是其他协作者
+			parent.UserID = userID
+			parent.Completed = true
+			if err := s.todos.UpdateTodo(parent); err != nil {
+				return err
+			}
+		}
+
+		if parent.ParentID == nil {
+			return nil
+		}
+		parentID = *parent.ParentID
+	}
+}
+
+// buildTree 将根节点在前的扁平子树转换为嵌套结构
+func buildTree(todos []models.Todo) models.TodoNode {
+	byParent := make(map[int][]models.Todo)
+	for _, t := range todos[1:] {
+		if t.ParentID != nil {
+			byParent[*t.ParentID] = append(byParent[*t.ParentID], t)
+		}
+	}
+
+	var build func(t models.Todo) models.TodoNode
+	build = func(t models.Todo) models.TodoNode {
+		node := models.TodoNode{Todo: t, Children: []models.TodoNode{}}
+		for _, child := range byParent[t.ID] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+	return build(todos[0])
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/joy_project/todo-list-backend/internal/middleware"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/validator"
+)
+
+// defaultColor 标签和项目未指定颜色时使用的灰色
+const defaultColor = "#808080"
+
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		tags, err := s.tags.GetTags(userID)
+		if err != nil {
+			s.logger.Printf("Error getting tags: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tags)
+	case http.MethodPost:
+		s.createTag(w, r, userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleTag(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/tags/"))
+	if err != nil {
+		s.logger.Printf("Invalid tag ID: %v", err)
+		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		tag, err := s.tags.GetTag(id, userID)
+		if err != nil {
+			s.tagError(w, "getting tag", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tag)
+	case http.MethodPut:
+		s.updateTag(w, r, userID, id)
+	case http.MethodDelete:
+		if err := s.tags.DeleteTag(id, userID); err != nil {
+			s.tagError(w, "deleting tag", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) createTag(w http.ResponseWriter, r *http.Request, userID int) {
+	var tag models.Tag
+	if err := json.NewDecoder(r.Body).Decode(&tag); err != nil {
+		s.logger.Printf("Error decoding tag: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if tag.Color == "" {
+		tag.Color = defaultColor
+	}
+
+	validationErrors := validator.ValidateTag(tag)
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+
+	tag.UserID = userID
+	id, err := s.tags.CreateTag(tag)
+	if err != nil {
+		s.tagError(w, "creating tag", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]int64{"id": id})
+}
+
+func (s *Server) updateTag(w http.ResponseWriter, r *http.Request, userID int, id int) {
+	var tag models.Tag
+	if err := json.NewDecoder(r.Body).Decode(&tag); err != nil {
+		s.logger.Printf("Error decoding tag: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if tag.Color == "" {
+		tag.Color = defaultColor
+	}
+
+	validationErrors := validator.ValidateTag(tag)
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+
+	tag.ID = id
+	tag.UserID = userID
+	if err := s.tags.UpdateTag(tag); err != nil {
+		s.tagError(w, "updating tag", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleTodoTags 处理 /todos/{id}/tags（POST 添加）和 /todos/{id}/tags/{tagID}（DELETE 移除）
+func (s *Server) handleTodoTags(w http.ResponseWriter, r *http.Request, userID int, todoID int, rest string) {
+	switch {
+	case rest == "" && r.Method == http.MethodPost:
+		var req models.AttachTagRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Printf("Error decoding attach request: %v", err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if err := s.tags.AttachTag(todoID, req.TagID, userID); err != nil {
+			s.tagError(w, "attaching tag", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case rest != "" && r.Method == http.MethodDelete:
+		tagID, err := strconv.Atoi(rest)
+		if err != nil {
+			http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+			return
+		}
+		if err := s.tags.DetachTag(todoID, tagID, userID); err != nil {
+			s.tagError(w, "detaching tag", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// tagError 将标签相关的存储错误映射为 HTTP 状态码
+func (s *Server) tagError(w http.ResponseWriter, action string, err error) {
+	s.logger.Printf("Error %s: %v", action, err)
+	switch {
+	case errors.Is(err, store.ErrTagNotFound), errors.Is(err, store.ErrTodoNotFound), errors.Is(err, store.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, store.ErrTagExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
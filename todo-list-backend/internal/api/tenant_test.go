@@ -0,0 +1,288 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/storetest"
+)
+
+// secret 出现在工作区 B 的每一条数据中，任何以工作区 A 身份得到的响应都不能包含它
+const secret = "zebraconfidential"
+
+// switchTo 切换到 workspaceID，返回持有该工作区令牌的客户端
+func (c *client) switchTo(workspaceID int) *client {
+	c.t.Helper()
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	c.decode(http.MethodPost, fmt.Sprintf("/workspaces/%d/switch", workspaceID), nil, http.StatusOK, &resp)
+	return &client{t: c.t, srv: c.srv, token: resp.Token, userID: c.userID, workspaceID: workspaceID}
+}
+
+// create 发送创建请求并返回新资源的ID
+func (c *client) create(path string, body interface{}) int {
+	c.t.Helper()
+
+	var resp struct {
+		ID int `json:"id"`
+	}
+	c.decode(http.MethodPost, path, body, http.StatusCreated, &resp)
+	return resp.ID
+}
+
+// upload 以 multipart/form-data 上传附件，返回状态码和响应体
+func (c *client) upload(path, filename string, content []byte) (int, []byte) {
+	c.t.Helper()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		c.t.Fatalf("create form file: %v", err)
+	}
+	fw.Write(content)
+	mw.Close()
+
+	req, err := http.NewRequest(http.MethodPost, c.srv.URL+path, &buf)
+	if err != nil {
+		c.t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.srv.Client().Do(req)
+	if err != nil {
+		c.t.Fatalf("POST %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("read response: %v", err)
+	}
+	return resp.StatusCode, data
+}
+
+// workspaceB 工作区 B 中的全部数据
+type workspaceB struct {
+	id, todo, subtask, comment, attachment, tag, project, smartList, invitation int
+}
+
+// seedWorkspaceB 由 owner 创建工作区 B 并在其中创建每一种资源，返回 owner 进入 B 后的客户端
+func seedWorkspaceB(t *testing.T, owner *client) (*client, workspaceB) {
+	t.Helper()
+
+	var b workspaceB
+	b.id = owner.create("/workspaces", map[string]string{"name": "B " + secret})
+	owner.decode(http.MethodPost, fmt.Sprintf("/workspaces/%d/members", b.id),
+		map[string]string{"email": "bob@example.com", "role": "member"}, http.StatusCreated, nil)
+	inB := owner.switchTo(b.id)
+
+	b.project = inB.create("/projects", map[string]string{"name": "project " + secret})
+	b.todo = inB.createTodo(map[string]interface{}{"title": "todo " + secret, "project_id": b.project})
+	b.subtask = inB.createTodo(map[string]interface{}{"title": "subtask " + secret, "parent_id": b.todo})
+	b.comment = inB.create(fmt.Sprintf("/todos/%d/comments", b.todo), map[string]string{"body": "comment " + secret})
+	b.tag = inB.create("/tags", map[string]string{"name": "tag-" + secret})
+	inB.decode(http.MethodPost, fmt.Sprintf("/todos/%d/tags", b.todo), map[string]int{"tag_id": b.tag}, http.StatusOK, nil)
+	b.smartList = inB.create("/smart-lists", map[string]string{"name": "list " + secret, "query": "priority=medium"})
+	b.invitation = inB.create(fmt.Sprintf("/projects/%d/invitations", b.project),
+		map[string]string{"email": "bob@example.com", "role": "editor"})
+
+	status, body := inB.upload(fmt.Sprintf("/todos/%d/attachments", b.todo), secret+".txt", []byte("attachment "+secret))
+	if status != http.StatusCreated {
+		t.Fatalf("upload attachment: status %d: %s", status, body)
+	}
+	var att struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(body, &att); err != nil {
+		t.Fatalf("decode attachment %s: %v", body, err)
+	}
+	b.attachment = att.ID
+	return inB, b
+}
+
+// request 一个测试请求
+type request struct {
+	method, path string
+	body         interface{}
+}
+
+// crossTenantRequests 以其他工作区的身份访问 b 中资源的全部请求，资源ID都在路径中
+func crossTenantRequests(b workspaceB) []request {
+	todo := fmt.Sprintf("/todos/%d", b.todo)
+	update := map[string]interface{}{"title": "hijacked", "priority": "high"}
+	return []request{
+		{http.MethodGet, todo, nil},
+		{http.MethodPut, todo, update},
+		{http.MethodPut, fmt.Sprintf("/todos/%d", b.subtask), update},
+		{http.MethodDelete, fmt.Sprintf("/todos/%d", b.subtask), nil},
+		{http.MethodGet, todo + "/subtree", nil},
+		{http.MethodPost, todo + "/move", map[string]interface{}{"project_id": b.project}},
+		{http.MethodPost, todo + "/tags", map[string]int{"tag_id": b.tag}},
+		{http.MethodDelete, fmt.Sprintf("%s/tags/%d", todo, b.tag), nil},
+		{http.MethodGet, todo + "/comments", nil},
+		{http.MethodPost, todo + "/comments", map[string]string{"body": "hijacked"}},
+		{http.MethodPut, fmt.Sprintf("%s/comments/%d", todo, b.comment), map[string]string{"body": "hijacked"}},
+		{http.MethodDelete, fmt.Sprintf("%s/comments/%d", todo, b.comment), nil},
+		{http.MethodGet, todo + "/attachments", nil},
+		{http.MethodGet, fmt.Sprintf("%s/attachments/%d", todo, b.attachment), nil},
+		{http.MethodDelete, fmt.Sprintf("%s/attachments/%d", todo, b.attachment), nil},
+		{http.MethodGet, fmt.Sprintf("/tags/%d", b.tag), nil},
+		{http.MethodPut, fmt.Sprintf("/tags/%d", b.tag), map[string]string{"name": "hijacked"}},
+		{http.MethodDelete, fmt.Sprintf("/tags/%d", b.tag), nil},
+		{http.MethodGet, fmt.Sprintf("/projects/%d", b.project), nil},
+		{http.MethodPut, fmt.Sprintf("/projects/%d", b.project), map[string]string{"name": "hijacked"}},
+		{http.MethodDelete, fmt.Sprintf("/projects/%d", b.project), nil},
+		{http.MethodGet, fmt.Sprintf("/projects/%d/members", b.project), nil},
+		{http.MethodGet, fmt.Sprintf("/projects/%d/invitations", b.project), nil},
+		{http.MethodPost, fmt.Sprintf("/projects/%d/invitations", b.project), map[string]string{"email": "bob@example.com", "role": "viewer"}},
+		{http.MethodGet, fmt.Sprintf("/smart-lists/%d", b.smartList), nil},
+		{http.MethodGet, fmt.Sprintf("/smart-lists/%d/todos", b.smartList), nil},
+		{http.MethodPut, fmt.Sprintf("/smart-lists/%d", b.smartList), map[string]string{"name": "hijacked", "query": "priority=low"}},
+		{http.MethodDelete, fmt.Sprintf("/smart-lists/%d", b.smartList), nil},
+	}
+}
+
+// referenceRequests 在请求体中引用 b 中资源的请求，与引用不存在的资源一样返回 403 或 400
+func referenceRequests(b workspaceB, ownTodo int) []request {
+	return []request{
+		{http.MethodPost, fmt.Sprintf("/todos/%d/tags", ownTodo), map[string]int{"tag_id": b.tag}},
+		{http.MethodPost, fmt.Sprintf("/todos/%d/move", ownTodo), map[string]interface{}{"parent_id": b.todo}},
+		{http.MethodPost, fmt.Sprintf("/todos/%d/move", ownTodo), map[string]interface{}{"project_id": b.project}},
+		{http.MethodPost, "/todos", map[string]interface{}{"title": "child", "priority": "low", "parent_id": b.todo}},
+		{http.MethodPost, "/todos", map[string]interface{}{"title": "in project", "priority": "low", "project_id": b.project}},
+	}
+}
+
+// assertNoLeak 检查列表接口可以访问，但结果中没有工作区 B 的数据
+func assertNoLeak(t *testing.T, c *client, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		status, body := c.do(http.MethodGet, path, nil)
+		if status != http.StatusOK {
+			t.Errorf("GET %s: status %d: %s", path, status, body)
+		}
+		if strings.Contains(string(body), secret) {
+			t.Errorf("GET %s leaked workspace B data: %s", path, body)
+		}
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		srv := newServer(t, st)
+		alice := register(t, srv, "alice")
+		bob := register(t, srv, "bob")
+		aliceB, b := seedWorkspaceB(t, alice)
+
+		// alice 是工作区 B 的创建者，只有令牌限定在工作区 A，任何跨工作区访问都必须被拒绝
+		ownTodo := alice.createTodo(map[string]interface{}{"title": "in A"})
+		for _, tt := range crossTenantRequests(b) {
+			status, body := alice.do(tt.method, tt.path, tt.body)
+			if status != http.StatusForbidden && status != http.StatusNotFound {
+				t.Errorf("%s %s: status %d, want 403 or 404: %s", tt.method, tt.path, status, body)
+			}
+			if strings.Contains(string(body), secret) {
+				t.Errorf("%s %s leaked workspace B data: %s", tt.method, tt.path, body)
+			}
+		}
+		for _, tt := range referenceRequests(b, ownTodo) {
+			status, body := alice.do(tt.method, tt.path, tt.body)
+			if status != http.StatusBadRequest && status != http.StatusForbidden && status != http.StatusNotFound {
+				t.Errorf("%s %s %v: status %d, want 400, 403 or 404: %s", tt.method, tt.path, tt.body, status, body)
+			}
+		}
+		assertNoLeak(t, alice, "/todos", "/todos?page=1", fmt.Sprintf("/todos?project_id=%d", b.project),
+			"/todos/search?q="+secret, "/tags", "/projects", "/invitations", "/smart-lists")
+
+		// 引用失败的请求没有在任何工作区中创建或移动待办事项
+		var subtree struct {
+			Children []struct {
+				ID int `json:"id"`
+			} `json:"children"`
+		}
+		aliceB.decode(http.MethodGet, fmt.Sprintf("/todos/%d/subtree", b.todo), nil, http.StatusOK, &subtree)
+		if len(subtree.Children) != 1 || subtree.Children[0].ID != b.subtask {
+			t.Errorf("workspace B subtree children = %+v after rejected requests, want only %d", subtree.Children, b.subtask)
+		}
+		var own []struct {
+			ID int `json:"id"`
+		}
+		alice.decode(http.MethodGet, "/todos", nil, http.StatusOK, &own)
+		if len(own) != 1 || own[0].ID != ownTodo {
+			t.Errorf("workspace A todos = %+v after rejected requests, want only %d", own, ownTodo)
+		}
+
+		// bob 只在个人工作区中，看不到也不能响应工作区 B 的邀请
+		invitation := fmt.Sprintf("/invitations/%d/", b.invitation)
+		for _, action := range []string{"accept", "decline"} {
+			if status, body := bob.do(http.MethodPost, invitation+action, nil); status != http.StatusForbidden && status != http.StatusNotFound {
+				t.Errorf("%s invitation from another workspace: status %d, want 403 or 404: %s", action, status, body)
+			}
+		}
+		assertNoLeak(t, bob, "/invitations", "/projects")
+
+		// 被拒绝的请求没有改动工作区 B 的任何数据
+		var todo struct {
+			Title string `json:"title"`
+		}
+		aliceB.decode(http.MethodGet, fmt.Sprintf("/todos/%d", b.todo), nil, http.StatusOK, &todo)
+		if todo.Title != "todo "+secret {
+			t.Errorf("todo title = %q after cross-workspace requests", todo.Title)
+		}
+		for _, path := range []string{
+			fmt.Sprintf("/todos/%d", b.subtask),
+			fmt.Sprintf("/todos/%d/comments", b.todo),
+			fmt.Sprintf("/todos/%d/attachments/%d", b.todo, b.attachment),
+			fmt.Sprintf("/tags/%d", b.tag),
+			fmt.Sprintf("/projects/%d", b.project),
+			fmt.Sprintf("/smart-lists/%d", b.smartList),
+		} {
+			if status, body := aliceB.do(http.MethodGet, path, nil); status != http.StatusOK || !strings.Contains(string(body), secret) {
+				t.Errorf("GET %s in workspace B: status %d: %s", path, status, body)
+			}
+		}
+
+		// 进入工作区 B 后 bob 可以正常响应邀请，说明上面的拒绝来自工作区隔离
+		bob.switchTo(b.id).decode(http.MethodPost, invitation+"accept", nil, http.StatusOK, nil)
+	})
+}
+
+func TestRemovedMemberLosesAccess(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		srv := newServer(t, st)
+		alice := register(t, srv, "alice")
+		bob := register(t, srv, "bob")
+		_, b := seedWorkspaceB(t, alice)
+
+		bobB := bob.switchTo(b.id)
+		paths := []string{"/todos", "/todos/search?q=" + secret, "/tags", "/projects", "/invitations", "/smart-lists"}
+		for _, path := range paths {
+			bobB.decode(http.MethodGet, path, nil, http.StatusOK, nil)
+		}
+
+		alice.decode(http.MethodDelete, fmt.Sprintf("/workspaces/%d/members/%d", b.id, bob.userID), nil, http.StatusOK, nil)
+
+		// 令牌仍在有效期内，但每次请求都会重新检查成员资格
+		for _, path := range append(paths, fmt.Sprintf("/projects/%d", b.project), fmt.Sprintf("/todos/%d", b.todo)) {
+			status, body := bobB.do(http.MethodGet, path, nil)
+			if status != http.StatusForbidden {
+				t.Errorf("GET %s after removal: status %d, want 403: %s", path, status, body)
+			}
+			if strings.Contains(string(body), secret) {
+				t.Errorf("GET %s after removal leaked workspace B data: %s", path, body)
+			}
+		}
+		if status, _ := bobB.do(http.MethodPost, fmt.Sprintf("/workspaces/%d/switch", b.id), nil); status != http.StatusForbidden && status != http.StatusNotFound {
+			t.Errorf("switch back after removal: status %d, want 403 or 404", status)
+		}
+	})
+}
@@ -0,0 +1,328 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/joy_project/todo-list-backend/internal/middleware"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/validator"
+)
+
+func (s *Server) handleTodos(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		filter, ok := s.todoFilter(w, r.URL.Query(), userID)
+		if !ok {
+			return
+		}
+		s.listTodos(w, r, userID, filter)
+	case http.MethodPost:
+		s.createTodo(w, r, userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleTodo(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// 路径格式为 /todos/{id} 或 /todos/{id}/{子资源}
+	idStr, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/todos/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		s.logger.Printf("Invalid todo ID: %v", err)
+		http.Error(w, "Invalid todo ID", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case sub == "" && r.Method == http.MethodGet:
+		s.getTodo(w, r, userID, id)
+	case sub == "" && r.Method == http.MethodPut:
+		s.updateTodo(w, r, userID, id)
+	case sub == "" && r.Method == http.MethodDelete:
+		s.deleteTodo(w, r, userID, id)
+	case sub == "subtree" && r.Method == http.MethodGet:
+		s.getSubtree(w, r, userID, id)
+	case sub == "move" && r.Method == http.MethodPost:
+		s.moveTodo(w, r, userID, id)
+	case sub == "tags" || strings.HasPrefix(sub, "tags/"):
+		s.handleTodoTags(w, r, userID, id, strings.TrimPrefix(strings.TrimPrefix(sub, "tags"), "/"))
+	case sub == "comments" || strings.HasPrefix(sub, "comments/"):
+		s.handleComments(w, r, userID, id, strings.TrimPrefix(strings.TrimPrefix(sub, "comments"), "/"))
+	case sub == "attachments" || strings.HasPrefix(sub, "attachments/"):
+		s.handleAttachments(w, r, userID, id, strings.TrimPrefix(strings.TrimPrefix(sub, "attachments"), "/"))
+	case sub == "" || sub == "subtree" || sub == "move":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) getTodo(w http.ResponseWriter, r *http.Request, userID int, id int) {
+	todo, err := s.todos.GetTodo(id, userID)
+	if err != nil {
+		s.logger.Printf("Error getting todo: %v", err)
+		if errors.Is(err, store.ErrTodoNotFound) || errors.Is(err, store.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(todo)
+}
+
+// listTodos 返回满足过滤条件的待办事项，page 为页码分页，after、before、limit 为游标分页，都没有时返回全部
+func (s *Server) listTodos(w http.ResponseWriter, r *http.Request, userID int, filter store.TodoFilter) {
+	switch query := r.URL.Query(); {
+	case query.Get("page") != "":
+		s.getTodosWithPagination(w, r, userID, filter)
+	case isCursorRequest(query):
+		s.getTodosWithCursor(w, r, userID, filter)
+	default:
+		s.getTodos(w, r, userID, filter)
+	}
+}
+
+func (s *Server) getTodos(w http.ResponseWriter, r *http.Request, userID int, filter store.TodoFilter) {
+	todos, err := s.todos.GetAllTodos(userID, filter)
+	if err != nil {
+		s.logger.Printf("Error getting todos: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Printf("Retrieved %d todos for user %d", len(todos), userID)
+	for _, todo := range todos {
+		s.logger.Printf("Todo: ID=%d, Title=%s, Completed=%v, Priority=%s", todo.ID, todo.Title, todo.Completed, todo.Priority)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(todos)
+}
+
+func (s *Server) getTodosWithPagination(w http.ResponseWriter, r *http.Request, userID int, filter store.TodoFilter) {
+	// 解析分页参数
+	pageStr := r.URL.Query().Get("page")
+	pageSizeStr := r.URL.Query().Get("pageSize")
+
+	page := 1
+	pageSize := 10
+
+	if pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
+			pageSize = ps
+		}
+	}
+
+	// 获取分页数据
+	todos, total, err := s.todos.GetTodosWithPagination(userID, filter, page, pageSize)
+	if err != nil {
+		s.logger.Printf("Error getting todos with pagination: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Printf("Retrieved %d todos (page %d of %d) for user %d", len(todos), page, (total+pageSize-1)/pageSize, userID)
+
+	// 构建响应
+	response := map[string]interface{}{
+		"todos": todos,
+		"pagination": map[string]int{
+			"page":       page,
+			"pageSize":   pageSize,
+			"total":      total,
+			"totalPages": (total + pageSize - 1) / pageSize,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) createTodo(w http.ResponseWriter, r *http.Request, userID int) {
+	var todo models.Todo
+	err := json.NewDecoder(r.Body).Decode(&todo)
+	if err != nil {
+		s.logger.Printf("Error decoding todo: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	validationErrors := validator.ValidateTodo(todo)
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+
+	todo.UserID = userID
+	todo.Recurrence, todo.Occurrence = normalizeRecurrence(todo.Recurrence), 0
+	if todo.Recurrence != "" {
+		todo.Occurrence = 1
+	}
+	// 未指定项目的顶层待办放入收件箱，子项由存储层使用父项的项目
+	if todo.ProjectID == nil && todo.ParentID == nil {
+		inbox, err := s.projects.EnsureInbox(userID)
+		if err != nil {
+			s.logger.Printf("Error getting inbox: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		todo.ProjectID = &inbox.ID
+	}
+	id, err := s.todos.CreateTodo(todo)
+	if err != nil {
+		s.logger.Printf("Error creating todo: %v", err)
+		if errors.Is(err, store.ErrInvalidParent) || errors.Is(err, store.ErrInvalidProject) || errors.Is(err, store.ErrInvalidAssignee) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else if errors.Is(err, store.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]int64{"id": id})
+}
+
+func (s *Server) updateTodo(w http.ResponseWriter, r *http.Request, userID int, id int) {
+	var todo models.Todo
+	err := json.NewDecoder(r.Body).Decode(&todo)
+	if err != nil {
+		s.logger.Printf("Error decoding todo: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	validationErrors := validator.ValidateTodo(todo)
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+
+	// 读取更新前的状态，用于判断重复待办是否刚被完成
+	existing, err := s.todos.GetTodo(id, userID)
+	if err != nil {
+		s.logger.Printf("Error getting todo: %v", err)
+		if errors.Is(err, store.ErrTodoNotFound) || errors.Is(err, store.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	todo.ID = id
+	todo.UserID = userID
+	todo.Recurrence = normalizeRecurrence(todo.Recurrence)
+	todo.Occurrence = existing.Occurrence
+	todo.ParentID = existing.ParentID // 父项和项目只能通过 /todos/{id}/move 修改
+	todo.ProjectID = existing.ProjectID
+	err = s.todos.UpdateTodo(todo)
+	if err != nil {
+		s.logger.Printf("Error updating todo: %v", err)
+		if errors.Is(err, store.ErrTodoNotFound) || errors.Is(err, store.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+		} else if errors.Is(err, store.ErrInvalidAssignee) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if existing.Completed || !todo.Completed {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// 重新读取保存后的内容，生成下一次重复时以它为准
+	updated, err := s.todos.GetTodo(id, userID)
+	if err != nil {
+		s.logger.Printf("Error getting todo: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if updated.ParentID != nil {
+		if err := s.completeParents(*updated.ParentID, userID); err != nil {
+			// 父项自动完成失败不影响本次更新的结果
+			s.logger.Printf("Error auto-completing parents of todo %d: %v", updated.ID, err)
+		}
+	}
+
+	if updated.Recurrence != "" {
+		nextID, err := s.spawnNextOccurrence(updated)
+		if err != nil {
+			s.logger.Printf("Error creating next occurrence of todo %d: %v", todo.ID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if nextID != 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(map[string]int64{"next_id": nextID})
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) deleteTodo(w http.ResponseWriter, r *http.Request, userID int, id int) {
+	// 先记下子树中附件引用的内容，删除后清理不再被引用的部分
+	hashes, err := s.attachments.GetTreeAttachmentHashes(id, userID)
+	if err == nil {
+		// 默认拒绝删除有子项的待办，?cascade=true 时连同子树一起删除
+		if r.URL.Query().Get("cascade") == "true" {
+			err = s.todos.DeleteTodoTree(id, userID)
+		} else {
+			err = s.todos.DeleteTodo(id, userID)
+		}
+	}
+	if err != nil {
+		s.logger.Printf("Error deleting todo: %v", err)
+		if errors.Is(err, store.ErrTodoNotFound) || errors.Is(err, store.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+		} else if errors.Is(err, store.ErrHasSubtasks) {
+			http.Error(w, "Todo has subtasks, use ?cascade=true to delete them too", http.StatusConflict)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.removeOrphanedBlobs(hashes)
+	w.WriteHeader(http.StatusOK)
+}
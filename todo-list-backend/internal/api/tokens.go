@@ -0,0 +1,170 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/auth"
+	"github.com/joy_project/todo-list-backend/internal/middleware"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// issueSession 为登录或注册开启新会话：创建新的刷新令牌族，返回访问令牌和刷新令牌
+func (s *Server) issueSession(user models.User, workspaceID int) (token string, refreshToken string, err error) {
+	familyID, err := auth.NewID()
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, hash, err := auth.NewToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	err = s.sessions.CreateRefreshToken(models.RefreshToken{
+		UserID:      user.ID,
+		WorkspaceID: workspaceID,
+		FamilyID:    familyID,
+		TokenHash:   hash,
+		ExpiresAt:   time.Now().Add(s.refreshTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err = s.issueToken(user, workspaceID, familyID)
+	if err != nil {
+		return "", "", err
+	}
+	return token, refreshToken, nil
+}
+
+// 刷新令牌处理：旧令牌作废，已作废的令牌再次出现时撤销整个会话
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Printf("Error decoding refresh request: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if req.RefreshToken == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"refresh_token": "Refresh token is required"})
+		return
+	}
+
+	refreshToken, hash, err := auth.NewToken()
+	if err != nil {
+		s.logger.Printf("Error generating refresh token: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	next, err := s.sessions.RotateRefreshToken(auth.HashToken(req.RefreshToken), models.RefreshToken{
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrTokenReused):
+			s.logger.Printf("Refresh token reuse detected from %s, session revoked", r.RemoteAddr)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		case errors.Is(err, store.ErrInvalidToken):
+			s.logger.Printf("Error refreshing token: %v", err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		default:
+			s.logger.Printf("Error refreshing token: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	user, err := s.users.GetUserByID(next.UserID)
+	if err != nil {
+		s.logger.Printf("Error getting user: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// 用户可能已被移出会话所在的工作区，此时与登录一样进入最早加入的工作区
+	workspaceID := next.WorkspaceID
+	if _, err := s.workspaces.GetWorkspace(workspaceID, user.ID); errors.Is(err, store.ErrWorkspaceNotFound) {
+		if workspaceID, err = s.loginWorkspace(user, 0); err == nil {
+			err = s.sessions.SetFamilyWorkspace(next.FamilyID, workspaceID)
+		}
+		if err != nil {
+			s.workspaceError(w, "choosing workspace", err)
+			return
+		}
+	} else if err != nil {
+		s.workspaceError(w, "checking workspace membership", err)
+		return
+	}
+
+	token, err := s.issueToken(user, workspaceID, next.FamilyID)
+	if err != nil {
+		s.logger.Printf("Error generating token: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.TokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.tokens.TTL().Seconds()),
+		WorkspaceID:  workspaceID,
+	})
+}
+
+// 注销处理：当前访问令牌立即失效，同一会话的刷新令牌全部撤销
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetClaims(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if claims.ID != "" && claims.ExpiresAt != nil {
+		if err := s.sessions.RevokeAccessToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+			s.logger.Printf("Error revoking access token: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+	if claims.SessionID != "" {
+		if err := s.sessions.RevokeTokenFamily(claims.SessionID); err != nil {
+			s.logger.Printf("Error revoking session: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	s.logger.Printf("User %d logged out", claims.UserID)
+	w.WriteHeader(http.StatusOK)
+}
+
+// 公钥集合处理：其他服务通过 kid 在其中查找公钥来验证令牌，无需共享密钥
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// 允许短时间缓存；轮换时新密钥应先加入密钥库，等缓存过期后再用于签发
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(s.tokens.JWKS())
+}
@@ -0,0 +1,40 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/storetest"
+)
+
+func TestRefreshReuseRevokesAccessTokens(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		srv := newServer(t, st)
+
+		var reg struct {
+			Token        string `json:"token"`
+			RefreshToken string `json:"refresh_token"`
+		}
+		anon := &client{t: t, srv: srv}
+		anon.decode(http.MethodPost, "/register", map[string]string{
+			"username": "alice",
+			"email":    "alice@example.com",
+			"password": "password123",
+		}, http.StatusCreated, &reg)
+
+		var next models.TokenResponse
+		anon.decode(http.MethodPost, "/token/refresh", models.RefreshRequest{RefreshToken: reg.RefreshToken}, http.StatusOK, &next)
+
+		first := &client{t: t, srv: srv, token: reg.Token}
+		rotated := &client{t: t, srv: srv, token: next.Token}
+		first.decode(http.MethodGet, "/todos", nil, http.StatusOK, nil)
+		rotated.decode(http.MethodGet, "/todos", nil, http.StatusOK, nil)
+
+		// 重放已轮换的刷新令牌会撤销整个令牌族，该族签发的访问令牌随之失效
+		anon.decode(http.MethodPost, "/token/refresh", models.RefreshRequest{RefreshToken: reg.RefreshToken}, http.StatusUnauthorized, nil)
+		first.decode(http.MethodGet, "/todos", nil, http.StatusUnauthorized, nil)
+		rotated.decode(http.MethodGet, "/todos", nil, http.StatusUnauthorized, nil)
+	})
+}
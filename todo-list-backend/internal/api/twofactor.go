@@ -0,0 +1,376 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/auth"
+	"github.com/joy_project/todo-list-backend/internal/middleware"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// codeAttemptsPerUser 每个用户每 15 分钟最多提交验证码的次数（包括成功的提交），6 位验证码因此无法被穷举
+const codeAttemptsPerUser = 10
+
+var errInvalidCode = errors.New("invalid two-factor code")
+
+// handleTwoFactor 处理 GET /2fa，返回当前用户的两步验证状态
+func (s *Server) handleTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status := models.TwoFactorStatus{}
+	t, err := s.twoFactor.GetTOTP(userID)
+	if err != nil && !errors.Is(err, store.ErrTwoFactorNotFound) {
+		s.logger.Printf("Error getting two-factor settings: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err == nil && t.EnabledAt != nil {
+		status.Enabled, status.EnabledAt = true, t.EnabledAt
+		if status.RecoveryCodesRemaining, err = s.twoFactor.CountRecoveryCodes(userID); err != nil {
+			s.logger.Printf("Error counting recovery codes: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleTwoFactorEnroll 处理 POST /2fa/enroll，生成新密钥，在 POST /2fa/confirm 提交正确的验证码之前不会生效
+func (s *Server) handleTwoFactorEnroll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		s.logger.Printf("Error getting user: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		s.logger.Printf("Error generating TOTP secret: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := s.twoFactor.SetTOTPSecret(userID, secret); err != nil {
+		s.twoFactorError(w, "enrolling two-factor authentication", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.EnrollTwoFactorResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, s.totpIssuer, user.Email),
+	})
+}
+
+// handleTwoFactorConfirm 处理 POST /2fa/confirm，用验证码确认绑定后开启两步验证，并返回一组恢复码
+func (s *Server) handleTwoFactorConfirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Printf("Error decoding two-factor request: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if !s.allowCodeAttempt(w, userID) {
+		return
+	}
+
+	t, err := s.twoFactor.GetTOTP(userID)
+	if err != nil {
+		s.twoFactorError(w, "confirming two-factor authentication", err)
+		return
+	}
+	if t.EnabledAt != nil {
+		s.twoFactorError(w, "confirming two-factor authentication", store.ErrTwoFactorEnabled)
+		return
+	}
+	// 确认绑定只接受验证码，此时还没有恢复码
+	step, ok := totp.Validate(t.Secret, normalizeCode(req.Code), time.Now(), t.LastStep)
+	if !ok {
+		s.twoFactorError(w, "confirming two-factor authentication", errInvalidCode)
+		return
+	}
+
+	codes, hashes, err := auth.NewRecoveryCodes()
+	if err != nil {
+		s.logger.Printf("Error generating recovery codes: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := s.twoFactor.EnableTOTP(userID, step, hashes); err != nil {
+		s.twoFactorError(w, "confirming two-factor authentication", err)
+		return
+	}
+	// 与重置密码一样让其他设备上的会话失效，只保留发起确认的当前会话
+	var sessionID string
+	if claims, ok := middleware.GetClaims(r); ok {
+		sessionID = claims.SessionID
+	}
+	if err := s.sessions.RevokeOtherSessions(userID, sessionID); err != nil {
+		s.logger.Printf("Error revoking sessions: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Printf("User %d enabled two-factor authentication", userID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// handleTwoFactorDisable 处理 POST /2fa/disable，需要密码和验证码（或恢复码）才能关闭两步验证
+func (s *Server) handleTwoFactorDisable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.DisableTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Printf("Error decoding two-factor request: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if !s.allowCodeAttempt(w, userID) {
+		return
+	}
+
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		s.logger.Printf("Error getting user: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		s.logger.Printf("Invalid password: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"password": "Password is incorrect"})
+		return
+	}
+
+	if err := s.verifySecondFactor(userID, req.Code); err != nil {
+		s.twoFactorError(w, "disabling two-factor authentication", err)
+		return
+	}
+	if err := s.twoFactor.DisableTOTP(userID); err != nil {
+		s.logger.Printf("Error disabling two-factor authentication: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Printf("User %d disabled two-factor authentication", userID)
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleRecoveryCodes 处理 POST /2fa/recovery-codes，验证码（或恢复码）正确时作废旧恢复码并返回一组新的恢复码
+func (s *Server) handleRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Printf("Error decoding two-factor request: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if !s.allowCodeAttempt(w, userID) {
+		return
+	}
+
+	if err := s.verifySecondFactor(userID, req.Code); err != nil {
+		s.twoFactorError(w, "regenerating recovery codes", err)
+		return
+	}
+
+	codes, hashes, err := auth.NewRecoveryCodes()
+	if err != nil {
+		s.logger.Printf("Error generating recovery codes: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := s.twoFactor.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		s.logger.Printf("Error saving recovery codes: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// handleLoginTwoFactor 处理 POST /login/2fa，质询令牌和验证码（或恢复码）都正确时签发令牌，质询令牌只能成功使用一次
+func (s *Server) handleLoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Printf("Error decoding two-factor login request: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"code": "Code is required"})
+		return
+	}
+
+	claims, err := s.tokens.ValidateChallenge(req.ChallengeToken)
+	if err == nil {
+		var revoked bool
+		if revoked, err = s.sessions.IsAccessTokenRevoked(claims.ID); err == nil && revoked {
+			err = errors.New("challenge token was already used")
+		}
+	}
+	if err != nil {
+		s.logger.Printf("Invalid challenge token: %v", err)
+		http.Error(w, "Invalid or expired challenge token, please log in again", http.StatusUnauthorized)
+		return
+	}
+	if !s.allowCodeAttempt(w, claims.UserID) {
+		return
+	}
+
+	if err := s.verifySecondFactor(claims.UserID, req.Code); err != nil {
+		s.logger.Printf("Error verifying two-factor login: %v", err)
+		switch {
+		case errors.Is(err, store.ErrTwoFactorNotFound):
+			// 两步验证在质询签发后被关闭，同样要求重新登录
+			http.Error(w, "Invalid or expired challenge token, please log in again", http.StatusUnauthorized)
+		case isInvalidCode(err):
+			http.Error(w, "Invalid or already used code", http.StatusUnauthorized)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	if err := s.sessions.RevokeAccessToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+		s.logger.Printf("Error revoking challenge token: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := s.users.GetUserByID(claims.UserID)
+	if err != nil {
+		s.logger.Printf("Error getting user: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	workspaceID, err := s.loginWorkspace(user, claims.WorkspaceID)
+	if err != nil {
+		s.workspaceError(w, "choosing workspace", err)
+		return
+	}
+
+	s.writeSession(w, user, workspaceID)
+}
+
+// verifySecondFactor 验证并用掉验证码或恢复码，6 位数字按验证码处理，其余按恢复码处理
+func (s *Server) verifySecondFactor(userID int, code string) error {
+	t, err := s.twoFactor.GetTOTP(userID)
+	if err != nil {
+		return err
+	}
+	if t.EnabledAt == nil {
+		return store.ErrTwoFactorNotFound
+	}
+
+	code = normalizeCode(code)
+	if _, err := strconv.Atoi(code); err == nil && len(code) == totp.Digits {
+		step, ok := totp.Validate(t.Secret, code, time.Now(), t.LastStep)
+		if !ok {
+			return errInvalidCode
+		}
+		return s.twoFactor.UseTOTPStep(userID, step)
+	}
+	return s.twoFactor.UseRecoveryCode(userID, auth.HashRecoveryCode(code))
+}
+
+// allowCodeAttempt 按用户限制提交验证码的频率，超过限制时写入 429 并返回 false
+func (s *Server) allowCodeAttempt(w http.ResponseWriter, userID int) bool {
+	ok, retry := s.codeLimit.Allow("2fa:" + strconv.Itoa(userID))
+	if !ok {
+		tooManyRequests(w, retry)
+	}
+	return ok
+}
+
+// isInvalidCode 判断错误是否表示验证码或恢复码错误、已使用
+func isInvalidCode(err error) bool {
+	return errors.Is(err, errInvalidCode) || errors.Is(err, store.ErrCodeReused) || errors.Is(err, store.ErrInvalidRecoveryCode)
+}
+
+// twoFactorError 把已登录用户管理两步验证时的错误映射为 HTTP 状态码
+func (s *Server) twoFactorError(w http.ResponseWriter, action string, err error) {
+	s.logger.Printf("Error %s: %v", action, err)
+	switch {
+	case isInvalidCode(err):
+		http.Error(w, "Invalid or already used code", http.StatusBadRequest)
+	case errors.Is(err, store.ErrTwoFactorNotFound), errors.Is(err, store.ErrTwoFactorEnabled):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// normalizeCode 去掉用户输入中的空白，验证器应用常把验证码显示为两组三位数字
+func normalizeCode(code string) string {
+	return strings.Join(strings.Fields(code), "")
+}
@@ -0,0 +1,99 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/storetest"
+	"github.com/joy_project/todo-list-backend/internal/totp"
+)
+
+func TestTwoFactorConfirmRevokesOtherSessions(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		srv := newServer(t, st)
+		alice := register(t, srv, "alice")
+
+		// 另一台设备上的会话
+		var login struct {
+			Token        string `json:"token"`
+			RefreshToken string `json:"refresh_token"`
+		}
+		anon := &client{t: t, srv: srv}
+		anon.decode(http.MethodPost, "/login", models.LoginRequest{
+			Email:    "alice@example.com",
+			Password: "password123",
+		}, http.StatusOK, &login)
+		other := &client{t: t, srv: srv, token: login.Token}
+		other.decode(http.MethodGet, "/me", nil, http.StatusOK, nil)
+
+		var enroll models.EnrollTwoFactorResponse
+		alice.decode(http.MethodPost, "/2fa/enroll", nil, http.StatusOK, &enroll)
+		code, err := totp.Code(enroll.Secret, totp.Step(time.Now()))
+		if err != nil {
+			t.Fatalf("totp code: %v", err)
+		}
+		alice.decode(http.MethodPost, "/2fa/confirm", models.TwoFactorCodeRequest{Code: code}, http.StatusOK, nil)
+
+		// 发起确认的会话继续可用，其他会话的访问令牌和刷新令牌都失效
+		alice.decode(http.MethodGet, "/me", nil, http.StatusOK, nil)
+		other.decode(http.MethodGet, "/me", nil, http.StatusUnauthorized, nil)
+		anon.decode(http.MethodPost, "/token/refresh", models.RefreshRequest{RefreshToken: login.RefreshToken}, http.StatusUnauthorized, nil)
+	})
+}
+
+func TestTwoFactorLoginChoosesWorkspaceAfterCode(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		srv := newServer(t, st)
+
+		// 直接在存储中创建的用户不属于任何工作区，登录时才为其创建个人工作区
+		id, err := st.CreateUser(models.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"})
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		userID := int(id)
+		secret, err := totp.GenerateSecret()
+		if err != nil {
+			t.Fatalf("GenerateSecret: %v", err)
+		}
+		if err := st.SetTOTPSecret(userID, secret); err != nil {
+			t.Fatalf("SetTOTPSecret: %v", err)
+		}
+		if err := st.EnableTOTP(userID, 0, nil); err != nil {
+			t.Fatalf("EnableTOTP: %v", err)
+		}
+
+		var challenge models.TwoFactorChallengeResponse
+		anon := &client{t: t, srv: srv}
+		anon.decode(http.MethodPost, "/login", models.LoginRequest{
+			Email:    "alice@example.com",
+			Password: "password123",
+		}, http.StatusOK, &challenge)
+		if !challenge.TwoFactorRequired {
+			t.Fatal("login did not ask for the second factor")
+		}
+		// 只知道密码不能引起任何写入
+		if workspaces, err := st.GetWorkspaces(userID); err != nil || len(workspaces) != 0 {
+			t.Fatalf("after password step: workspaces = %v, %v; want none", workspaces, err)
+		}
+
+		code, err := totp.Code(secret, totp.Step(time.Now()))
+		if err != nil {
+			t.Fatalf("totp code: %v", err)
+		}
+		var session struct {
+			WorkspaceID int `json:"workspace_id"`
+		}
+		anon.decode(http.MethodPost, "/login/2fa", models.TwoFactorLoginRequest{
+			ChallengeToken: challenge.ChallengeToken,
+			Code:           code,
+		}, http.StatusOK, &session)
+
+		workspaces, err := st.GetWorkspaces(userID)
+		if err != nil || len(workspaces) != 1 || workspaces[0].ID != session.WorkspaceID {
+			t.Errorf("after second factor: workspaces = %v, %v; want the session's workspace %d", workspaces, err, session.WorkspaceID)
+		}
+	})
+}
@@ -0,0 +1,252 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/joy_project/todo-list-backend/internal/auth"
+	"github.com/joy_project/todo-list-backend/internal/middleware"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/validator"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 用户注册处理
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.RegisterRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.logger.Printf("Error decoding register request: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	validationErrors := validator.ValidateRegister(req)
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+
+	userID, err := s.users.CreateUser(req)
+	if err != nil {
+		s.logger.Printf("Error creating user: %v", err)
+		if errors.Is(err, store.ErrEmailExists) || errors.Is(err, store.ErrUsernameExists) {
+			http.Error(w, err.Error(), http.StatusConflict)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	user, err := s.users.GetUserByID(int(userID))
+	if err != nil {
+		s.logger.Printf("Error getting user: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// 每个用户都有一个个人工作区，注册后直接进入
+	workspaceID, err := s.workspaces.CreateWorkspace(personalWorkspaceName(user), user.ID)
+	if err != nil {
+		s.logger.Printf("Error creating workspace: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	token, refreshToken, err := s.issueSession(user, int(workspaceID))
+	if err != nil {
+		s.logger.Printf("Error generating token: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// 验证邮件发送失败不影响注册，用户可以通过 /verify-email/request 重新发送
+	s.accountLimit.Allow("verify:" + strconv.Itoa(user.ID))
+	if err := s.sendEmailToken(user, models.PurposeVerifyEmail); err != nil {
+		s.logger.Printf("Error creating verification token: %v", err)
+	}
+
+	response := models.UserResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		Timezone:      user.Timezone,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		Token:         token,
+		RefreshToken:  refreshToken,
+		ExpiresIn:     int(s.tokens.TTL().Seconds()),
+		WorkspaceID:   int(workspaceID),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// 用户登录处理
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.LoginRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.logger.Printf("Error decoding login request: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	validationErrors := validator.ValidateLogin(req)
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+
+	user, err := s.users.GetUserByEmail(req.Email)
+	if err != nil {
+		s.logger.Printf("Error getting user: %v", err)
+		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+		return
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+	if err != nil {
+		s.logger.Printf("Invalid password: %v", err)
+		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+		return
+	}
+
+	// 开启了两步验证时只返回质询令牌，提交验证码到 POST /login/2fa 后才选择工作区并签发访问令牌
+	t, err := s.twoFactor.GetTOTP(user.ID)
+	if err != nil && !errors.Is(err, store.ErrTwoFactorNotFound) {
+		s.logger.Printf("Error getting two-factor settings: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err == nil && t.EnabledAt != nil {
+		challenge, err := s.tokens.GenerateChallenge(user, req.WorkspaceID)
+		if err != nil {
+			s.logger.Printf("Error generating challenge token: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(models.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+			ExpiresIn:         int(auth.ChallengeTTL.Seconds()),
+		})
+		return
+	}
+
+	workspaceID, err := s.loginWorkspace(user, req.WorkspaceID)
+	if err != nil {
+		s.workspaceError(w, "choosing workspace", err)
+		return
+	}
+	s.writeSession(w, user, workspaceID)
+}
+
+// writeSession 为通过登录验证的用户开启新会话，返回用户信息、访问令牌和刷新令牌
+func (s *Server) writeSession(w http.ResponseWriter, user models.User, workspaceID int) {
+	token, refreshToken, err := s.issueSession(user, workspaceID)
+	if err != nil {
+		s.logger.Printf("Error generating token: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := models.UserResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		Timezone:      user.Timezone,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		Token:         token,
+		RefreshToken:  refreshToken,
+		ExpiresIn:     int(s.tokens.TTL().Seconds()),
+		WorkspaceID:   workspaceID,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// 当前用户资料：GET 查看，PUT 修改时区
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req models.UpdateProfileRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Printf("Error decoding profile request: %v", err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		msg := validator.ValidateTimezone(req.Timezone)
+		if req.Timezone == "" {
+			msg = "Timezone is required"
+		}
+		if msg != "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"timezone": msg})
+			return
+		}
+
+		if err := s.users.UpdateUserTimezone(userID, req.Timezone); err != nil {
+			s.logger.Printf("Error updating timezone: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		s.logger.Printf("Error getting user: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := models.UserResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		Timezone:      user.Timezone,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		EmailVerified: user.EmailVerifiedAt != nil,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,318 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/joy_project/todo-list-backend/internal/middleware"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/validator"
+)
+
+// handleWorkspaces 处理 /workspaces：GET 列出加入的工作区，POST 创建工作区
+func (s *Server) handleWorkspaces(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		workspaces, err := s.workspaces.GetWorkspaces(userID)
+		if err != nil {
+			s.logger.Printf("Error getting workspaces: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(workspaces)
+	case http.MethodPost:
+		var workspace models.Workspace
+		if err := json.NewDecoder(r.Body).Decode(&workspace); err != nil {
+			s.logger.Printf("Error decoding workspace: %v", err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		validationErrors := validator.ValidateWorkspace(workspace)
+		if len(validationErrors) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(validationErrors)
+			return
+		}
+
+		id, err := s.workspaces.CreateWorkspace(workspace.Name, userID)
+		if err != nil {
+			s.logger.Printf("Error creating workspace: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]int64{"id": id})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleWorkspace 处理 /workspaces/{id}（GET 查看，PUT 重命名）及其子资源 members 和 switch
+func (s *Server) handleWorkspace(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// 路径格式为 /workspaces/{id} 或 /workspaces/{id}/{子资源}
+	idStr, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/workspaces/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		s.logger.Printf("Invalid workspace ID: %v", err)
+		http.Error(w, "Invalid workspace ID", http.StatusBadRequest)
+		return
+	}
+
+	// 个人访问令牌只能访问创建时所在的工作区，也不能通过切换工作区换取不受权限范围限制的 JWT
+	if token, ok := middleware.GetPersonalToken(r); ok && (id != token.WorkspaceID || sub == "switch") {
+		http.Error(w, "Personal access tokens are limited to their own workspace and cannot switch workspaces", http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case sub == "members" || strings.HasPrefix(sub, "members/"):
+		s.handleWorkspaceMembers(w, r, userID, id, strings.TrimPrefix(strings.TrimPrefix(sub, "members"), "/"))
+		return
+	case sub == "switch":
+		s.switchWorkspace(w, r, userID, id)
+		return
+	case sub != "":
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		workspace, err := s.workspaces.GetWorkspace(id, userID)
+		if err != nil {
+			s.workspaceError(w, "getting workspace", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(workspace)
+	case http.MethodPut:
+		var workspace models.Workspace
+		if err := json.NewDecoder(r.Body).Decode(&workspace); err != nil {
+			s.logger.Printf("Error decoding workspace: %v", err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		validationErrors := validator.ValidateWorkspace(workspace)
+		if len(validationErrors) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(validationErrors)
+			return
+		}
+
+		if err := s.workspaces.RenameWorkspace(id, workspace.Name, userID); err != nil {
+			s.workspaceError(w, "renaming workspace", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleWorkspaceMembers 处理 /workspaces/{id}/members（GET 列表，POST 添加）和 /workspaces/{id}/members/{userID}（PUT 修改角色，DELETE 移除）
+func (s *Server) handleWorkspaceMembers(w http.ResponseWriter, r *http.Request, userID int, workspaceID int, rest string) {
+	if rest == "" {
+		switch r.Method {
+		case http.MethodGet:
+			members, err := s.workspaces.GetWorkspaceMembers(workspaceID, userID)
+			if err != nil {
+				s.workspaceError(w, "getting workspace members", err)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(members)
+		case http.MethodPost:
+			var req models.AddWorkspaceMemberRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				s.logger.Printf("Error decoding workspace member request: %v", err)
+				http.Error(w, "Bad request", http.StatusBadRequest)
+				return
+			}
+
+			validationErrors := validator.ValidateWorkspaceMember(req)
+			if len(validationErrors) > 0 {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(validationErrors)
+				return
+			}
+
+			if err := s.workspaces.AddWorkspaceMember(workspaceID, req.Email, req.Role, userID); err != nil {
+				s.workspaceError(w, "adding workspace member", err)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	memberID, err := strconv.Atoi(rest)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		var req models.UpdateWorkspaceMemberRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Printf("Error decoding workspace member request: %v", err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		if msg := validator.ValidateWorkspaceRole(req.Role); msg != "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"role": msg})
+			return
+		}
+
+		if err := s.workspaces.UpdateWorkspaceMember(workspaceID, memberID, req.Role, userID); err != nil {
+			s.workspaceError(w, "updating workspace member", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		// 管理员可以移除任何成员，成员也可以移除自己以退出工作区
+		if err := s.workspaces.RemoveWorkspaceMember(workspaceID, memberID, userID); err != nil {
+			s.workspaceError(w, "removing workspace member", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// switchWorkspace 处理 POST /workspaces/{id}/switch，签发进入该工作区的新令牌
+func (s *Server) switchWorkspace(w http.ResponseWriter, r *http.Request, userID int, workspaceID int) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, err := s.workspaces.GetWorkspace(workspaceID, userID); err != nil {
+		s.workspaceError(w, "switching workspace", err)
+		return
+	}
+
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		s.logger.Printf("Error getting user: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// 新令牌沿用当前会话，之后刷新得到的令牌也进入该工作区
+	var sessionID string
+	if claims, ok := middleware.GetClaims(r); ok {
+		sessionID = claims.SessionID
+	}
+	token, err := s.issueToken(user, workspaceID, sessionID)
+	if err != nil {
+		s.logger.Printf("Error generating token: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if sessionID != "" {
+		if err := s.sessions.SetFamilyWorkspace(sessionID, workspaceID); err != nil {
+			s.logger.Printf("Error updating session workspace: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response := models.UserResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		Timezone:      user.Timezone,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		Token:         token,
+		ExpiresIn:     int(s.tokens.TTL().Seconds()),
+		WorkspaceID:   workspaceID,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// issueToken 签发会话 sessionID 中进入工作区的访问令牌，并确保用户在该工作区有收件箱
+func (s *Server) issueToken(user models.User, workspaceID int, sessionID string) (string, error) {
+	if _, err := s.scoped(workspaceID).EnsureInbox(user.ID); err != nil {
+		return "", err
+	}
+	return s.tokens.GenerateToken(user, workspaceID, sessionID)
+}
+
+// loginWorkspace 返回登录后进入的工作区：请求指定的、最早加入的，或者为已退出全部工作区的用户新建的个人工作区
+func (s *Server) loginWorkspace(user models.User, requested int) (int, error) {
+	if requested > 0 {
+		if _, err := s.workspaces.GetWorkspace(requested, user.ID); err != nil {
+			return 0, err
+		}
+		return requested, nil
+	}
+
+	workspaces, err := s.workspaces.GetWorkspaces(user.ID)
+	if err != nil {
+		return 0, err
+	}
+	if len(workspaces) > 0 {
+		return workspaces[0].ID, nil
+	}
+
+	id, err := s.workspaces.CreateWorkspace(personalWorkspaceName(user), user.ID)
+	return int(id), err
+}
+
+// personalWorkspaceName 返回注册时自动创建的个人工作区名称
+func personalWorkspaceName(user models.User) string {
+	return user.Username + "'s workspace"
+}
+
+// workspaceError 把工作区存储返回的错误映射为 HTTP 状态码
+func (s *Server) workspaceError(w http.ResponseWriter, action string, err error) {
+	s.logger.Printf("Error %s: %v", action, err)
+	switch {
+	case errors.Is(err, store.ErrWorkspaceNotFound), errors.Is(err, store.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, store.ErrAlreadyMember), errors.Is(err, store.ErrLastAdmin):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, store.ErrUserNotFound), errors.Is(err, store.ErrMemberNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
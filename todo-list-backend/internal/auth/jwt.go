@@ -2,33 +2,128 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/joy_project/todo-list-backend/internal/models"
 )
 
-var jwtKey = []byte("your_secret_key") // 在生产环境中应该使用环境变量
+// 两步验证登录的质询令牌，aud 为 ChallengeAudience，只能提交到 POST /login/2fa，不能作为访问令牌使用
+const (
+	ChallengeAudience = "2fa"
+	ChallengeTTL      = 5 * time.Minute
+)
 
 type Claims struct {
 	UserID int `json:"user_id"`
+	// WorkspaceID 令牌对应的当前工作区，所有数据访问都限定在该工作区内
+	WorkspaceID int `json:"workspace_id"`
+	// SessionID 签发该令牌的会话，即刷新令牌族的ID，注销时据此撤销整个会话
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 为用户生成JWT令牌
-func GenerateToken(user models.User) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// Manager 签发、验证JWT令牌，keys 为 nil 时使用 HS256 共享密钥，否则按 kid 使用密钥库中的非对称密钥
+type Manager struct {
+	key     []byte
+	keys    *KeyStore
+	signing *Key
+	ttl     time.Duration
+}
+
+// NewManager 创建使用 HS256 共享密钥的令牌管理器，密钥和有效期来自配置
+func NewManager(secret string, ttl time.Duration) *Manager {
+	return &Manager{key: []byte(secret), ttl: ttl}
+}
+
+// NewKeyManager 创建用 signingKeyID 签发、用密钥库中全部密钥验证的令牌管理器
+func NewKeyManager(keys *KeyStore, signingKeyID string, ttl time.Duration) (*Manager, error) {
+	signing, ok := keys.Key(signingKeyID)
+	if !ok {
+		return nil, fmt.Errorf("signing key %q not found in key store", signingKeyID)
+	}
+	if signing.Private == nil {
+		return nil, fmt.Errorf("signing key %q has no private key", signingKeyID)
+	}
+	return &Manager{keys: keys, signing: signing, ttl: ttl}, nil
+}
+
+// Algorithm 返回签发令牌使用的算法
+func (m *Manager) Algorithm() string {
+	if m.signing != nil {
+		return m.signing.Algorithm
+	}
+	return jwt.SigningMethodHS256.Alg()
+}
+
+// JWKS 返回可用于验证令牌的公钥集合，HS256 模式下没有可公开的密钥，返回空集合
+func (m *Manager) JWKS() JWKSet {
+	if m.keys == nil {
+		return JWKSet{Keys: []JWK{}}
+	}
+	return m.keys.JWKS()
+}
+
+// TTL 返回访问令牌的有效期
+func (m *Manager) TTL() time.Duration {
+	return m.ttl
+}
+
+// GenerateToken 为用户生成进入指定工作区的JWT访问令牌，每个令牌带有唯一的 jti，以便在过期前撤销
+func (m *Manager) GenerateToken(user models.User, workspaceID int, sessionID string) (string, error) {
+	jti, err := NewID()
+	if err != nil {
+		return "", err
+	}
+
+	expirationTime := time.Now().Add(m.ttl)
 	claims := &Claims{
-		UserID: user.ID,
+		UserID:      user.ID,
+		WorkspaceID: workspaceID,
+		SessionID:   sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   user.Username,
 		},
 	}
+	return m.sign(claims)
+}
+
+// GenerateChallenge 为通过密码验证、开启了两步验证的用户生成质询令牌，workspaceID 是登录请求指定的工作区，为 0 时验证通过后再选择
+func (m *Manager) GenerateChallenge(user models.User, workspaceID int) (string, error) {
+	jti, err := NewID()
+	if err != nil {
+		return "", err
+	}
+
+	claims := &Claims{
+		UserID:      user.ID,
+		WorkspaceID: workspaceID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Audience:  jwt.ClaimStrings{ChallengeAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   user.Username,
+		},
+	}
+	return m.sign(claims)
+}
+
+// sign 使用配置的密钥签名
+func (m *Manager) sign(claims *Claims) (string, error) {
+	if m.signing != nil {
+		token := jwt.NewWithClaims(jwt.GetSigningMethod(m.signing.Algorithm), claims)
+		token.Header["kid"] = m.signing.ID
+		return token.SignedString(m.signing.Private)
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtKey)
+	tokenString, err := token.SignedString(m.key)
 	if err != nil {
 		return "", err
 	}
@@ -36,21 +131,59 @@ func GenerateToken(user models.User) (string, error) {
 	return tokenString, nil
 }
 
-// ValidateToken 验证JWT令牌并返回用户ID
-func ValidateToken(tokenString string) (int, error) {
+// ValidateToken 验证JWT访问令牌并返回其中的声明，质询令牌会被拒绝
+func (m *Manager) ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := m.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(claims.Audience, ChallengeAudience) {
+		return nil, errors.New("challenge token cannot be used as an access token")
+	}
+	return claims, nil
+}
+
+// ValidateChallenge 验证两步验证的质询令牌并返回其中的声明
+func (m *Manager) ValidateChallenge(tokenString string) (*Claims, error) {
+	return m.parse(tokenString, jwt.WithAudience(ChallengeAudience))
+}
+
+// parse 验证令牌签名和有效期并解析声明
+func (m *Manager) parse(tokenString string, opts ...jwt.ParserOption) (*Claims, error) {
 	claims := &Claims{}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtKey, nil
-	})
+	var token *jwt.Token
+	var err error
+	if m.keys != nil {
+		opts = append(opts, jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA}))
+		token, err = jwt.ParseWithClaims(tokenString, claims, m.publicKey, opts...)
+	} else {
+		opts = append(opts, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+		token, err = jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+			return m.key, nil
+		}, opts...)
+	}
 
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	if !token.Valid {
-		return 0, errors.New("invalid token")
+		return nil, errors.New("invalid token")
 	}
 
-	return claims.UserID, nil
+	return claims, nil
+}
+
+// publicKey 按令牌头中的 kid 查找验证密钥，alg 必须与密钥类型一致，防止算法混淆
+func (m *Manager) publicKey(token *jwt.Token) (interface{}, error) {
+	id, _ := token.Header["kid"].(string)
+	key, ok := m.keys.Key(id)
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", id)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("key %q cannot verify %s tokens", id, token.Method.Alg())
+	}
+	return key.Public, nil
 }
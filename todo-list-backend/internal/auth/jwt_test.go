@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/joy_project/todo-list-backend/internal/models"
+)
+
+var testUser = models.User{ID: 7, Username: "alice"}
+
+func TestKeyManagerRoundTrip(t *testing.T) {
+	rsaKey, edKey := testKeys(t)
+	ks := newKeyStore(
+		&Key{ID: "rsa", Algorithm: AlgRS256, Private: rsaKey, Public: rsaKey.Public()},
+		&Key{ID: "ed", Algorithm: AlgEdDSA, Private: edKey, Public: edKey.Public()},
+	)
+
+	for _, id := range []string{"rsa", "ed"} {
+		m, err := NewKeyManager(ks, id, time.Minute)
+		if err != nil {
+			t.Fatalf("NewKeyManager(%s): %v", id, err)
+		}
+		tokenString, err := m.GenerateToken(testUser, 3, "session")
+		if err != nil {
+			t.Fatalf("%s: GenerateToken: %v", id, err)
+		}
+
+		token, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
+		if err != nil {
+			t.Fatalf("%s: ParseUnverified: %v", id, err)
+		}
+		if token.Header["kid"] != id || token.Header["alg"] != m.Algorithm() {
+			t.Errorf("%s: header = %v, want kid %s and alg %s", id, token.Header, id, m.Algorithm())
+		}
+
+		claims, err := m.ValidateToken(tokenString)
+		if err != nil {
+			t.Fatalf("%s: ValidateToken: %v", id, err)
+		}
+		if claims.UserID != testUser.ID || claims.WorkspaceID != 3 || claims.SessionID != "session" || claims.Subject != testUser.Username {
+			t.Errorf("%s: claims = %+v", id, claims)
+		}
+	}
+
+	if _, err := NewKeyManager(ks, "missing", time.Minute); err == nil {
+		t.Error("NewKeyManager with an unknown signing key succeeded")
+	}
+	verifyOnly := newKeyStore(&Key{ID: "rsa", Algorithm: AlgRS256, Public: rsaKey.Public()})
+	if _, err := NewKeyManager(verifyOnly, "rsa", time.Minute); err == nil {
+		t.Error("NewKeyManager with a public-only signing key succeeded")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	rsaKey, edKey := testKeys(t)
+	old := &Key{ID: "old", Algorithm: AlgRS256, Private: rsaKey, Public: rsaKey.Public()}
+	current := &Key{ID: "new", Algorithm: AlgEdDSA, Private: edKey, Public: edKey.Public()}
+
+	before, err := NewKeyManager(newKeyStore(old), "old", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenString, err := before.GenerateToken(testUser, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 轮换期间旧密钥只保留公钥，仍能验证它签发的令牌
+	retired := &Key{ID: "old", Algorithm: AlgRS256, Public: rsaKey.Public()}
+	during, err := NewKeyManager(newKeyStore(retired, current), "new", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := during.ValidateToken(tokenString); err != nil {
+		t.Errorf("token signed with the retired key rejected during rotation: %v", err)
+	}
+
+	// 旧密钥移出密钥库后，它签发的令牌失效
+	after, err := NewKeyManager(newKeyStore(current), "new", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := after.ValidateToken(tokenString); err == nil {
+		t.Error("token signed with a removed key accepted")
+	}
+}
+
+func TestKeyManagerRejectsAlgorithmConfusion(t *testing.T) {
+	rsaKey, edKey := testKeys(t)
+	m, err := NewKeyManager(newKeyStore(
+		&Key{ID: "rsa", Algorithm: AlgRS256, Private: rsaKey, Public: rsaKey.Public()},
+		&Key{ID: "ed", Algorithm: AlgEdDSA, Private: edKey, Public: edKey.Public()},
+	), "rsa", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := &Claims{
+		UserID: testUser.ID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			Subject:   testUser.Username,
+		},
+	}
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		t.Helper()
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("sign %s: %v", method.Alg(), err)
+		}
+		return s
+	}
+	pub, err := x509.MarshalPKIXPublicKey(rsaKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		// 以公开的 RSA 公钥作为 HMAC 密钥伪造的令牌
+		{"HS256 with RSA kid", sign(jwt.SigningMethodHS256, "rsa", pub), "signing method HS256 is invalid"},
+		{"EdDSA with RSA kid", sign(jwt.SigningMethodEdDSA, "rsa", edKey), `key "rsa" cannot verify EdDSA tokens`},
+		{"RS256 with Ed25519 kid", sign(jwt.SigningMethodRS256, "ed", rsaKey), `key "ed" cannot verify RS256 tokens`},
+		{"unknown kid", sign(jwt.SigningMethodRS256, "other", rsaKey), `unknown key id "other"`},
+		{"missing kid", sign(jwt.SigningMethodRS256, "", rsaKey), `unknown key id ""`},
+		{"alg none", sign(jwt.SigningMethodNone, "rsa", jwt.UnsafeAllowNoneSignatureType), "signing method none is invalid"},
+	}
+	for _, tt := range tests {
+		_, err := m.ValidateToken(tt.token)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: ValidateToken error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestSharedSecretManager(t *testing.T) {
+	m := NewManager("secret", time.Minute)
+	if m.Algorithm() != "HS256" || len(m.JWKS().Keys) != 0 {
+		t.Errorf("HS256 manager: alg %s, %d public keys", m.Algorithm(), len(m.JWKS().Keys))
+	}
+	tokenString, err := m.GenerateToken(testUser, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ValidateToken(tokenString); err != nil {
+		t.Errorf("ValidateToken: %v", err)
+	}
+	if _, err := NewManager("other", time.Minute).ValidateToken(tokenString); err == nil {
+		t.Error("token accepted with a different secret")
+	}
+
+	// 配置了密钥库的服务不接受共享密钥签发的令牌
+	rsaKey, _ := testKeys(t)
+	km, err := NewKeyManager(newKeyStore(&Key{ID: "rsa", Algorithm: AlgRS256, Private: rsaKey, Public: rsaKey.Public()}), "rsa", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := km.ValidateToken(tokenString); err == nil {
+		t.Error("HS256 token accepted by the key manager")
+	}
+}
@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 支持的非对称签名算法，与 JWT 头中的 alg 一致
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// minRSABits RSA 密钥的最小长度
+const minRSABits = 2048
+
+// Key 密钥库中的一个密钥。Private 为 nil 时只能用于验证，例如已退役但仍需验证未过期令牌的旧密钥
+type Key struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+}
+
+// KeyStore 按 kid 索引的签名和验证密钥
+type KeyStore struct {
+	keys map[string]*Key
+	ids  []string // 按 kid 排序，保证 JWKS 输出稳定
+}
+
+// LoadKeyStore 读取 dir 中全部 .pem 文件，文件名（不含扩展名）作为 kid
+func LoadKeyStore(dir string) (*KeyStore, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &KeyStore{keys: make(map[string]*Key)}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		id := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := parseKey(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("load key %s: %w", path, err)
+		}
+		ks.keys[id] = key
+		ks.ids = append(ks.ids, id)
+	}
+	if len(ks.keys) == 0 {
+		return nil, fmt.Errorf("no .pem keys found in %s", dir)
+	}
+	sort.Strings(ks.ids)
+
+	return ks, nil
+}
+
+// parseKey 解析 PEM 编码的私钥或公钥
+func parseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	key := &Key{ID: id}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", parsed)
+		}
+		key.Private, key.Public = signer, signer.Public()
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Private, key.Public = parsed, parsed.Public()
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Public = parsed
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSABits)
+		}
+		key.Algorithm = AlgRS256
+	case ed25519.PublicKey:
+		key.Algorithm = AlgEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, only RSA and Ed25519 are supported", pub)
+	}
+
+	return key, nil
+}
+
+// Key 返回 kid 对应的密钥
+func (ks *KeyStore) Key(id string) (*Key, bool) {
+	key, ok := ks.keys[id]
+	return key, ok
+}
+
+// JWK 单个公钥的 JSON Web Key 表示（RFC 7517），RSA 使用 n、e，Ed25519 使用 crv、x（RFC 8037）
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKSet /.well-known/jwks.json 的响应
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 返回全部密钥的公钥部分，包括只用于验证的密钥
+func (ks *KeyStore) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, id := range ks.ids {
+		key := ks.keys[id]
+		jwk := JWK{KeyID: key.ID, Algorithm: key.Algorithm, Use: "sig"}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// GenerateKey 生成 algorithm 对应的新私钥，RSA 为 2048 位
+func GenerateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case AlgRS256:
+		return rsa.GenerateKey(rand.Reader, minRSABits)
+	case AlgEdDSA:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+}
+
+// WriteKey 把私钥以 PKCS#8 PEM 格式写入 dir/<id>.pem，文件已存在时返回错误
+func WriteKey(dir, id string, key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, id+".pem")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+)
+
+var (
+	testKeysOnce sync.Once
+	testRSA      *rsa.PrivateKey
+	testEd       ed25519.PrivateKey
+)
+
+// testKeys 返回测试共用的 RSA 和 Ed25519 私钥，RSA 密钥生成较慢，只生成一次
+func testKeys(t *testing.T) (*rsa.PrivateKey, ed25519.PrivateKey) {
+	t.Helper()
+
+	testKeysOnce.Do(func() {
+		rsaKey, err := GenerateKey(AlgRS256)
+		if err != nil {
+			t.Fatalf("GenerateKey(%s): %v", AlgRS256, err)
+		}
+		edKey, err := GenerateKey(AlgEdDSA)
+		if err != nil {
+			t.Fatalf("GenerateKey(%s): %v", AlgEdDSA, err)
+		}
+		testRSA, testEd = rsaKey.(*rsa.PrivateKey), edKey.(ed25519.PrivateKey)
+	})
+	if testRSA == nil {
+		t.Fatal("test keys were not generated")
+	}
+	return testRSA, testEd
+}
+
+// encodePEM 把 DER 数据编码为 type 类型的 PEM 块
+func encodePEM(t *testing.T, typ string, der []byte, err error) []byte {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("marshal %s: %v", typ, err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+}
+
+func TestParseKey(t *testing.T) {
+	rsaKey, edKey := testKeys(t)
+	pkcs8 := func(key crypto.Signer) []byte {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		return encodePEM(t, "PRIVATE KEY", der, err)
+	}
+	pkix := func(key crypto.PublicKey) []byte {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		return encodePEM(t, "PUBLIC KEY", der, err)
+	}
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("generate weak RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ECDSA key: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		wantAlg     string
+		wantPrivate bool
+		wantErr     bool
+	}{
+		{"RSA PKCS#8", pkcs8(rsaKey), AlgRS256, true, false},
+		{"RSA PKCS#1", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}), AlgRS256, true, false},
+		{"RSA public", pkix(rsaKey.Public()), AlgRS256, false, false},
+		{"Ed25519 PKCS#8", pkcs8(edKey), AlgEdDSA, true, false},
+		{"Ed25519 public", pkix(edKey.Public()), AlgEdDSA, false, false},
+		{"RSA shorter than 2048 bits", pkcs8(weak), "", false, true},
+		{"ECDSA", pkcs8(ecKey), "", false, true},
+		{"ECDSA public", pkix(ecKey.Public()), "", false, true},
+		{"certificate block", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1}}), "", false, true},
+		{"corrupt DER", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("garbage")}), "", false, true},
+		{"not PEM", []byte("not a key"), "", false, true},
+	}
+	for _, tt := range tests {
+		key, err := parseKey("k", tt.data)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: parseKey succeeded, want error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: parseKey: %v", tt.name, err)
+			continue
+		}
+		if key.ID != "k" || key.Algorithm != tt.wantAlg || (key.Private != nil) != tt.wantPrivate {
+			t.Errorf("%s: got id %q, alg %q, private %v; want k, %s, %v", tt.name, key.ID, key.Algorithm, key.Private != nil, tt.wantAlg, tt.wantPrivate)
+		}
+	}
+}
+
+func TestLoadKeyStore(t *testing.T) {
+	rsaKey, edKey := testKeys(t)
+	dir := t.TempDir()
+	if _, err := WriteKey(dir, "b-rsa", rsaKey); err != nil {
+		t.Fatalf("WriteKey: %v", err)
+	}
+	if _, err := WriteKey(dir, "a-ed", edKey); err != nil {
+		t.Fatalf("WriteKey: %v", err)
+	}
+	if _, err := WriteKey(dir, "a-ed", edKey); err == nil {
+		t.Error("WriteKey overwrote an existing key")
+	}
+	der, err := x509.MarshalPKIXPublicKey(edKey.Public())
+	if err := os.WriteFile(filepath.Join(dir, "c-retired.pem"), encodePEM(t, "PUBLIC KEY", der, err), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// 非 .pem 文件被忽略
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := LoadKeyStore(dir)
+	if err != nil {
+		t.Fatalf("LoadKeyStore: %v", err)
+	}
+	for _, want := range []struct {
+		id      string
+		alg     string
+		private bool
+	}{{"a-ed", AlgEdDSA, true}, {"b-rsa", AlgRS256, true}, {"c-retired", AlgEdDSA, false}} {
+		key, ok := ks.Key(want.id)
+		if !ok {
+			t.Errorf("key %s not loaded", want.id)
+			continue
+		}
+		if key.Algorithm != want.alg || (key.Private != nil) != want.private {
+			t.Errorf("key %s: alg %q, private %v; want %s, %v", want.id, key.Algorithm, key.Private != nil, want.alg, want.private)
+		}
+	}
+	if _, ok := ks.Key("README"); ok {
+		t.Error("non-.pem file loaded as a key")
+	}
+
+	if _, err := LoadKeyStore(t.TempDir()); err == nil {
+		t.Error("LoadKeyStore of an empty directory succeeded")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.pem"), []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKeyStore(dir); err == nil {
+		t.Error("LoadKeyStore with an invalid key succeeded")
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, edKey := testKeys(t)
+	ks := newKeyStore(
+		&Key{ID: "rsa", Algorithm: AlgRS256, Public: rsaKey.Public()},
+		&Key{ID: "ed", Algorithm: AlgEdDSA, Private: edKey, Public: edKey.Public()},
+	)
+
+	set := ks.JWKS()
+	if len(set.Keys) != 2 || set.Keys[0].KeyID != "ed" || set.Keys[1].KeyID != "rsa" {
+		t.Fatalf("JWKS = %+v, want ed then rsa", set.Keys)
+	}
+
+	ed := set.Keys[0]
+	x, err := base64.RawURLEncoding.DecodeString(ed.X)
+	if ed.KeyType != "OKP" || ed.Curve != "Ed25519" || ed.Algorithm != AlgEdDSA || ed.Use != "sig" || err != nil || !edKey.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(x)) {
+		t.Errorf("Ed25519 JWK = %+v", ed)
+	}
+	if ed.N != "" || ed.E != "" {
+		t.Errorf("Ed25519 JWK has RSA members: %+v", ed)
+	}
+
+	r := set.Keys[1]
+	n, errN := base64.RawURLEncoding.DecodeString(r.N)
+	e, errE := base64.RawURLEncoding.DecodeString(r.E)
+	if r.KeyType != "RSA" || r.Algorithm != AlgRS256 || r.Use != "sig" || errN != nil || errE != nil {
+		t.Fatalf("RSA JWK = %+v", r)
+	}
+	pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	if !rsaKey.PublicKey.Equal(pub) {
+		t.Errorf("RSA JWK does not encode the public key: %+v", r)
+	}
+	if r.Curve != "" || r.X != "" {
+		t.Errorf("RSA JWK has OKP members: %+v", r)
+	}
+}
+
+// newKeyStore 用给定密钥构造密钥库
+func newKeyStore(keys ...*Key) *KeyStore {
+	ks := &KeyStore{keys: make(map[string]*Key)}
+	for _, key := range keys {
+		ks.keys[key.ID] = key
+		ks.ids = append(ks.ids, key.ID)
+	}
+	sort.Strings(ks.ids)
+	return ks
+}
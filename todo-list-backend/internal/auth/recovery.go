@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+)
+
+// RecoveryCodeCount 每次生成的两步验证恢复码数量
+const RecoveryCodeCount = 10
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewRecoveryCodes 生成一组 xxxx-xxxx-xxxx-xxxx 格式的恢复码及其 SHA-256 摘要
+func NewRecoveryCodes() (codes []string, hashes []string, err error) {
+	for range RecoveryCodeCount {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))
+		codes = append(codes, raw[0:4]+"-"+raw[4:8]+"-"+raw[8:12]+"-"+raw[12:16])
+		hashes = append(hashes, HashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode 返回用户输入的恢复码的摘要，忽略大小写、连字符和空格
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return HashToken(code)
+}
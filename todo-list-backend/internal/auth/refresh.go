@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewID 生成随机的 128 位十六进制ID，用作 jti 和刷新令牌族的ID
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// NewToken 生成不透明的随机令牌（刷新令牌、邮件中的一次性令牌），返回交给客户端的令牌和需要保存的摘要
+func NewToken() (token string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken 返回令牌的 SHA-256 摘要。令牌本身是高熵随机值，不需要加盐或慢哈希
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// PersonalTokenPrefix 个人访问令牌的固定前缀，认证中间件据此区分个人访问令牌和 JWT，也便于密钥扫描工具识别
+const PersonalTokenPrefix = "tdp_"
+
+// NewPersonalToken 生成个人访问令牌，返回交给用户的令牌、用于辨认的前缀和需要保存的摘要
+func NewPersonalToken() (token string, prefix string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	token = PersonalTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, token[:len(PersonalTokenPrefix)+8], HashToken(token), nil
+}
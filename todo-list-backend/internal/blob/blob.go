@@ -0,0 +1,34 @@
+// Package blob 按内容寻址的文件存储，以 SHA-256 摘要为键，相同内容只保存一份
+package blob
+
+import (
+	"errors"
+	"io"
+	"regexp"
+)
+
+// ErrNotFound 摘要对应的内容不存在
+var ErrNotFound = errors.New("blob not found")
+
+// ErrInvalidHash 摘要不是 64 位小写十六进制字符串
+var ErrInvalidHash = errors.New("invalid blob hash")
+
+var hashRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Store 内容存储的接口，实现必须可以并发使用
+type Store interface {
+	// Put 读取 r 直到 EOF 并保存，返回内容的摘要和字节数；读取出错时不保存任何内容并返回该错误
+	Put(r io.Reader) (hash string, size int64, err error)
+	// Open 打开摘要对应的内容，不存在时返回 ErrNotFound
+	Open(hash string) (io.ReadCloser, error)
+	// Delete 删除摘要对应的内容，不存在时不做任何操作
+	Delete(hash string) error
+}
+
+// checkHash 检查摘要的格式，防止被拼接成任意路径
+func checkHash(hash string) error {
+	if !hashRegex.MatchString(hash) {
+		return ErrInvalidHash
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Disk 把内容保存在本地目录中，路径为 {dir}/{摘要前两位}/{摘要}，未完成的上传写在 {dir}/tmp 下
+type Disk struct {
+	dir string
+}
+
+// 编译期检查 Disk 是否实现了 Store
+var _ Store = (*Disk)(nil)
+
+// NewDisk 使用 dir 作为存储目录，目录不存在时创建
+func NewDisk(dir string) (*Disk, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "tmp"), 0o755); err != nil {
+		return nil, err
+	}
+	return &Disk{dir: dir}, nil
+}
+
+func (d *Disk) path(hash string) string {
+	return filepath.Join(d.dir, hash[:2], hash)
+}
+
+// Put 先写入临时文件并计算摘要，再重命名到最终位置；内容已存在时丢弃临时文件
+func (d *Disk) Put(r io.Reader) (string, int64, error) {
+	tmp, err := os.CreateTemp(filepath.Join(d.dir, "tmp"), "upload-*")
+	if err != nil {
+		return "", 0, err
+	}
+	// 重命名成功后 Remove 会失败，可以忽略
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	size, err := io.Copy(tmp, io.TeeReader(r, h))
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	path := d.path(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, size, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, err
+	}
+	return hash, size, nil
+}
+
+// Open 打开摘要对应的文件，返回的 *os.File 同时实现了 io.ReadSeeker
+func (d *Disk) Open(hash string) (io.ReadCloser, error) {
+	if err := checkHash(hash); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(d.path(hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete 删除摘要对应的文件
+func (d *Disk) Delete(hash string) error {
+	if err := checkHash(hash); err != nil {
+		return err
+	}
+	err := os.Remove(d.path(hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+package blob
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"sync"
+)
+
+// Memory 把内容保存在进程内存中，与 memstore 搭配用于演示模式
+type Memory struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+// 编译期检查 Memory 是否实现了 Store
+var _ Store = (*Memory)(nil)
+
+// NewMemory 创建一个空的内存存储
+func NewMemory() *Memory {
+	return &Memory{blobs: make(map[string][]byte)}
+}
+
+// Put 读取全部内容并按摘要保存
+func (m *Memory) Put(r io.Reader) (string, int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", 0, err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.blobs[hash]; !ok {
+		m.blobs[hash] = data
+	}
+	return hash, int64(len(data)), nil
+}
+
+// memReader 与 *os.File 一样同时实现 io.ReadSeeker 和 io.Closer
+type memReader struct {
+	*bytes.Reader
+}
+
+func (memReader) Close() error { return nil }
+
+// Open 返回内容的只读视图，保存的内容不会被修改，因此无需复制
+func (m *Memory) Open(hash string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, ok := m.blobs[hash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return memReader{bytes.NewReader(data)}, nil
+}
+
+// Delete 删除摘要对应的内容
+func (m *Memory) Delete(hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.blobs, hash)
+	return nil
+}
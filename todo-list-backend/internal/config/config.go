@@ -0,0 +1,413 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 运行环境
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvProduction  = "production"
+)
+
+// PlaceholderJWTSecret 仅允许在开发环境使用的默认密钥
+const PlaceholderJWTSecret = "your_secret_key"
+
+// JWT 签名算法，HS256 使用共享的 jwt_secret，RS256 和 EdDSA 使用 keys_dir 中的密钥对
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Config API 服务的全部配置
+type Config struct {
+	Environment string            `yaml:"environment"`
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Auth        AuthConfig        `yaml:"auth"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Attachments AttachmentsConfig `yaml:"attachments"`
+	Mail        MailConfig        `yaml:"mail"`
+	Todos       TodosConfig       `yaml:"todos"`
+}
+
+// ServerConfig HTTP 服务配置
+type ServerConfig struct {
+	Addr         string        `yaml:"addr"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+}
+
+// DatabaseConfig 存储后端和连接池配置
+type DatabaseConfig struct {
+	Driver          string        `yaml:"driver"`
+	DSN             string        `yaml:"dsn"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	AutoMigrate     bool          `yaml:"auto_migrate"`
+}
+
+// AuthConfig JWT 签发配置
+type AuthConfig struct {
+	Algorithm string        `yaml:"algorithm"`
+	JWTSecret string        `yaml:"jwt_secret"` // 仅 HS256 使用
+	TokenTTL  time.Duration `yaml:"token_ttl"`  // 访问令牌的有效期，应当较短，过期后用刷新令牌续期
+	// RefreshTTL 刷新令牌的有效期，每次刷新都会签发新的刷新令牌并重新计时
+	RefreshTTL time.Duration `yaml:"refresh_ttl"`
+	// KeysDir 存放 PEM 密钥的目录，文件名（不含 .pem）即 kid，只有公钥的文件只用于验证
+	KeysDir string `yaml:"keys_dir"`
+	// SigningKeyID 用于签发令牌的密钥的 kid，其类型必须与 Algorithm 一致
+	SigningKeyID string `yaml:"signing_key_id"`
+	// TOTPIssuer 两步验证器应用中显示的服务名称
+	TOTPIssuer string `yaml:"totp_issuer"`
+}
+
+// CORSConfig 跨域配置，AllowedOrigins 包含 "*" 时允许任意来源
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+// AttachmentsConfig 附件上传配置。文件类型根据内容检测，不信任客户端声明的 Content-Type
+type AttachmentsConfig struct {
+	Dir          string   `yaml:"dir"`           // 本地存储目录，memory 驱动下附件只保存在内存中
+	MaxSize      int64    `yaml:"max_size"`      // 单个文件的最大字节数
+	AllowedTypes []string `yaml:"allowed_types"` // 允许上传的 MIME 类型
+}
+
+// 邮件发送方式
+const (
+	MailLog  = "log"  // 写入服务日志，适合本地运行
+	MailFile = "file" // 写入 mail.dir 中的 .eml 文件
+	MailSMTP = "smtp"
+)
+
+// MailConfig 验证邮件和密码重置邮件的发送配置
+type MailConfig struct {
+	Driver       string `yaml:"driver"`
+	From         string `yaml:"from"`
+	Dir          string `yaml:"dir"`
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+	// LinkBaseURL 邮件中链接指向的前端地址，令牌以 ?token= 附加在其后
+	LinkBaseURL string `yaml:"link_base_url"`
+}
+
+// TodosConfig 待办事项的后台任务配置
+type TodosConfig struct {
+	RebalanceInterval time.Duration `yaml:"rebalance_interval"` // 重新编号手动排序的周期，为 0 时不运行
+}
+
+// Default 返回与此前硬编码值一致的默认配置，环境默认为 production
+func Default() *Config {
+	return &Config{
+		Environment: EnvProduction,
+		Server: ServerConfig{
+			Addr:         ":8081",
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Driver:          "mysql",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+		},
+		Auth: AuthConfig{
+			Algorithm:  AlgHS256,
+			JWTSecret:  PlaceholderJWTSecret,
+			TokenTTL:   15 * time.Minute,
+			RefreshTTL: 30 * 24 * time.Hour,
+			TOTPIssuer: "Todo List",
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+		},
+		Attachments: AttachmentsConfig{
+			Dir:     "attachments",
+			MaxSize: 10 << 20,
+			AllowedTypes: []string{
+				"image/png", "image/jpeg", "image/gif", "image/webp",
+				"application/pdf", "text/plain",
+			},
+		},
+		Mail: MailConfig{
+			Driver:      MailLog,
+			From:        "Todo List <no-reply@localhost>",
+			Dir:         "mail",
+			SMTPPort:    587,
+			LinkBaseURL: "http://localhost:8083",
+		},
+		Todos: TodosConfig{
+			RebalanceInterval: time.Hour,
+		},
+	}
+}
+
+// Load 依次应用默认值、配置文件和环境变量，path 为空时使用 CONFIG_FILE，不做校验
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parse config file %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// applyEnv 用环境变量覆盖配置
+func (c *Config) applyEnv() error {
+	var errs []error
+
+	setString(&c.Environment, "APP_ENV")
+	setString(&c.Server.Addr, "SERVER_ADDR")
+	errs = append(errs,
+		setDuration(&c.Server.ReadTimeout, "SERVER_READ_TIMEOUT"),
+		setDuration(&c.Server.WriteTimeout, "SERVER_WRITE_TIMEOUT"),
+		setDuration(&c.Server.IdleTimeout, "SERVER_IDLE_TIMEOUT"),
+	)
+
+	setString(&c.Database.Driver, "DB_DRIVER")
+	setString(&c.Database.DSN, "DB_DSN")
+	errs = append(errs,
+		setInt(&c.Database.MaxOpenConns, "DB_MAX_OPEN_CONNS"),
+		setInt(&c.Database.MaxIdleConns, "DB_MAX_IDLE_CONNS"),
+		setDuration(&c.Database.ConnMaxLifetime, "DB_CONN_MAX_LIFETIME"),
+		setBool(&c.Database.AutoMigrate, "DB_AUTO_MIGRATE"),
+	)
+
+	setString(&c.Auth.Algorithm, "JWT_ALGORITHM")
+	setString(&c.Auth.JWTSecret, "JWT_SECRET")
+	setString(&c.Auth.KeysDir, "JWT_KEYS_DIR")
+	setString(&c.Auth.SigningKeyID, "JWT_SIGNING_KEY_ID")
+	errs = append(errs, setDuration(&c.Auth.TokenTTL, "JWT_TOKEN_TTL"))
+	errs = append(errs, setDuration(&c.Auth.RefreshTTL, "JWT_REFRESH_TTL"))
+	setString(&c.Auth.TOTPIssuer, "TOTP_ISSUER")
+
+	if v, ok := os.LookupEnv("CORS_ALLOWED_ORIGINS"); ok {
+		c.CORS.AllowedOrigins = splitList(v)
+	}
+
+	setString(&c.Attachments.Dir, "ATTACHMENTS_DIR")
+	errs = append(errs, setInt64(&c.Attachments.MaxSize, "ATTACHMENTS_MAX_SIZE"))
+	if v, ok := os.LookupEnv("ATTACHMENTS_ALLOWED_TYPES"); ok {
+		c.Attachments.AllowedTypes = splitList(v)
+	}
+
+	setString(&c.Mail.Driver, "MAIL_DRIVER")
+	setString(&c.Mail.From, "MAIL_FROM")
+	setString(&c.Mail.Dir, "MAIL_DIR")
+	setString(&c.Mail.SMTPHost, "SMTP_HOST")
+	errs = append(errs, setInt(&c.Mail.SMTPPort, "SMTP_PORT"))
+	setString(&c.Mail.SMTPUsername, "SMTP_USERNAME")
+	setString(&c.Mail.SMTPPassword, "SMTP_PASSWORD")
+	setString(&c.Mail.LinkBaseURL, "MAIL_LINK_BASE_URL")
+
+	errs = append(errs, setDuration(&c.Todos.RebalanceInterval, "TODOS_REBALANCE_INTERVAL"))
+
+	return errors.Join(errs...)
+}
+
+// Validate 校验完整配置
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.Environment {
+	case EnvDevelopment, EnvTest, EnvProduction:
+	default:
+		errs = append(errs, fmt.Errorf("environment must be %s, %s or %s", EnvDevelopment, EnvTest, EnvProduction))
+	}
+
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr is required"))
+	}
+	if c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 {
+		errs = append(errs, errors.New("server timeouts must not be negative"))
+	}
+
+	errs = append(errs, c.Database.Validate())
+
+	switch c.Auth.Algorithm {
+	case AlgHS256:
+		if c.Auth.JWTSecret == "" {
+			errs = append(errs, errors.New("auth.jwt_secret is required"))
+		} else if c.Auth.JWTSecret == PlaceholderJWTSecret && c.Environment != EnvDevelopment {
+			errs = append(errs, errors.New("auth.jwt_secret must be changed from the placeholder outside development mode"))
+		}
+	case AlgRS256, AlgEdDSA:
+		if c.Auth.KeysDir == "" {
+			errs = append(errs, fmt.Errorf("auth.keys_dir is required for %s", c.Auth.Algorithm))
+		}
+		if c.Auth.SigningKeyID == "" {
+			errs = append(errs, fmt.Errorf("auth.signing_key_id is required for %s", c.Auth.Algorithm))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("auth.algorithm must be %s, %s or %s", AlgHS256, AlgRS256, AlgEdDSA))
+	}
+	if c.Auth.TokenTTL <= 0 {
+		errs = append(errs, errors.New("auth.token_ttl must be positive"))
+	}
+	if c.Auth.RefreshTTL <= 0 {
+		errs = append(errs, errors.New("auth.refresh_ttl must be positive"))
+	}
+	if c.Auth.TOTPIssuer == "" || strings.Contains(c.Auth.TOTPIssuer, ":") {
+		errs = append(errs, errors.New("auth.totp_issuer is required and must not contain a colon"))
+	}
+
+	if len(c.CORS.AllowedOrigins) == 0 {
+		errs = append(errs, errors.New("cors.allowed_origins must not be empty"))
+	}
+
+	if c.Attachments.Dir == "" && c.Database.Driver != "memory" {
+		errs = append(errs, errors.New("attachments.dir is required"))
+	}
+	if c.Attachments.MaxSize <= 0 {
+		errs = append(errs, errors.New("attachments.max_size must be positive"))
+	}
+	if len(c.Attachments.AllowedTypes) == 0 {
+		errs = append(errs, errors.New("attachments.allowed_types must not be empty"))
+	}
+
+	if c.Mail.From == "" {
+		errs = append(errs, errors.New("mail.from is required"))
+	}
+	if c.Mail.LinkBaseURL == "" {
+		errs = append(errs, errors.New("mail.link_base_url is required"))
+	}
+	switch c.Mail.Driver {
+	case MailLog:
+	case MailFile:
+		if c.Mail.Dir == "" {
+			errs = append(errs, errors.New("mail.dir is required for the file driver"))
+		}
+	case MailSMTP:
+		if c.Mail.SMTPHost == "" {
+			errs = append(errs, errors.New("mail.smtp_host is required for the smtp driver"))
+		}
+		if c.Mail.SMTPPort <= 0 {
+			errs = append(errs, errors.New("mail.smtp_port must be positive"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("mail.driver must be %s, %s or %s", MailLog, MailFile, MailSMTP))
+	}
+
+	if c.Todos.RebalanceInterval < 0 {
+		errs = append(errs, errors.New("todos.rebalance_interval must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate 校验数据库配置，供只需要连接数据库的命令单独使用
+func (d *DatabaseConfig) Validate() error {
+	var errs []error
+
+	switch d.Driver {
+	case "mysql", "sqlite3", "memory":
+	default:
+		errs = append(errs, fmt.Errorf("database.driver %q is not supported (mysql, sqlite3, memory)", d.Driver))
+	}
+	if d.MaxOpenConns < 0 || d.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("database pool sizes must not be negative"))
+	}
+	if d.ConnMaxLifetime < 0 {
+		errs = append(errs, errors.New("database.conn_max_lifetime must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func setString(dst *string, key string) {
+	if v, ok := os.LookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+func setInt(dst *int, key string) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("%s: invalid integer %q", key, v)
+	}
+	*dst = n
+	return nil
+}
+
+func setInt64(dst *int64, key string) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s: invalid integer %q", key, v)
+	}
+	*dst = n
+	return nil
+}
+
+func setBool(dst *bool, key string) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("%s: invalid boolean %q", key, v)
+	}
+	*dst = b
+	return nil
+}
+
+func setDuration(dst *time.Duration, key string) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("%s: invalid duration %q", key, v)
+	}
+	*dst = d
+	return nil
+}
+
+// splitList 解析逗号分隔的列表并去掉空白项
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestPlaceholderSecretRequiresExplicitDevelopment(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string // 空字符串表示不设置 APP_ENV
+		wantErr bool
+	}{
+		{"APP_ENV unset", "", true},
+		{"production", EnvProduction, true},
+		{"test", EnvTest, true},
+		{"development", EnvDevelopment, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_FILE", "")
+			t.Setenv("JWT_SECRET", PlaceholderJWTSecret)
+			t.Setenv("APP_ENV", tt.env)
+			if tt.env == "" {
+				os.Unsetenv("APP_ENV")
+			}
+
+			cfg, err := Load("")
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
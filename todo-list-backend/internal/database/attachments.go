@@ -0,0 +1,163 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 附件相关操作
+
+const attachmentColumns = "id, todo_id, user_id, filename, content_type, size, hash, created_at"
+
+func scanAttachment(row interface{ Scan(...interface{}) error }) (models.Attachment, error) {
+	var a models.Attachment
+	err := row.Scan(&a.ID, &a.TodoID, &a.UserID, &a.Filename, &a.ContentType, &a.Size, &a.Hash, &a.CreatedAt)
+	return a, err
+}
+
+// attachment 获取待办事项下的单个附件，调用方需先检查待办事项的权限
+func (s *Store) attachment(id int, todoID int) (models.Attachment, error) {
+	a, err := scanAttachment(s.db.QueryRow("SELECT "+attachmentColumns+" FROM attachments WHERE id = ? AND todo_id = ?", id, todoID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Attachment{}, store.ErrAttachmentNotFound
+	}
+	return a, err
+}
+
+// GetAttachments 获取待办事项的全部附件
+func (s *Store) GetAttachments(todoID int, userID int) ([]models.Attachment, error) {
+	if err := s.requireTodo(todoID, userID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query("SELECT "+attachmentColumns+" FROM attachments WHERE todo_id = ? ORDER BY created_at, id", todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []models.Attachment{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+// GetAttachment 获取单个附件
+func (s *Store) GetAttachment(id int, todoID int, userID int) (models.Attachment, error) {
+	if err := s.requireTodo(todoID, userID, models.RoleViewer); err != nil {
+		return models.Attachment{}, err
+	}
+	return s.attachment(id, todoID)
+}
+
+// CreateAttachment 保存附件元数据
+func (s *Store) CreateAttachment(attachment models.Attachment) (int64, error) {
+	if err := s.requireTodo(attachment.TodoID, attachment.UserID, models.RoleEditor); err != nil {
+		return 0, err
+	}
+
+	result, err := s.db.Exec("INSERT INTO attachments (todo_id, user_id, filename, content_type, size, hash, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		attachment.TodoID, attachment.UserID, attachment.Filename, attachment.ContentType, attachment.Size, attachment.Hash, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// DeleteAttachment 删除附件
+func (s *Store) DeleteAttachment(id int, todoID int, userID int) (string, error) {
+	if err := s.requireTodo(todoID, userID, models.RoleViewer); err != nil {
+		return "", err
+	}
+	current, err := s.attachment(id, todoID)
+	if err != nil {
+		return "", err
+	}
+	if current.UserID != userID {
+		// 不是上传者时需要能修改该待办事项
+		if err := s.requireTodo(todoID, userID, models.RoleEditor); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := s.db.Exec("DELETE FROM attachments WHERE id = ?", id); err != nil {
+		return "", err
+	}
+	return current.Hash, nil
+}
+
+// GetTreeAttachmentHashes 获取子树中附件引用的内容摘要
+func (s *Store) GetTreeAttachmentHashes(todoID int, userID int) ([]string, error) {
+	if err := s.requireTodo(todoID, userID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(subtreeIDs+" SELECT DISTINCT hash FROM attachments WHERE todo_id IN (SELECT id FROM subtree)", todoID, s.workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return scanHashes(rows)
+}
+
+// UnreferencedHashes 筛选出已没有附件引用的内容摘要
+func (s *Store) UnreferencedHashes(hashes []string) ([]string, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(hashes))
+	args := make([]interface{}, len(hashes))
+	for i, h := range hashes {
+		placeholders[i] = "?"
+		args[i] = h
+	}
+
+	rows, err := s.db.Query("SELECT DISTINCT hash FROM attachments WHERE hash IN ("+strings.Join(placeholders, ", ")+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	referenced, err := scanHashes(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	inUse := make(map[string]bool, len(referenced))
+	for _, h := range referenced {
+		inUse[h] = true
+	}
+	var unreferenced []string
+	for _, h := range hashes {
+		if !inUse[h] {
+			unreferenced = append(unreferenced, h)
+			inUse[h] = true
+		}
+	}
+	return unreferenced, nil
+}
+
+// scanHashes 读取只有 hash 一列的结果集并关闭它
+func scanHashes(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+
+	hashes := []string{}
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes, rows.Err()
+}
@@ -0,0 +1,192 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 评论相关操作
+
+const commentSelect = "SELECT c.id, c.todo_id, c.user_id, u.username, c.body, c.created_at, c.updated_at FROM comments c JOIN users u ON u.id = c.user_id"
+
+func scanComment(row interface{ Scan(...interface{}) error }) (models.Comment, error) {
+	var c models.Comment
+	err := row.Scan(&c.ID, &c.TodoID, &c.UserID, &c.Username, &c.Body, &c.CreatedAt, &c.UpdatedAt)
+	return c, err
+}
+
+// comment 获取待办事项下的单条评论，调用方需先检查待办事项的权限
+func (s *Store) comment(id int, todoID int) (models.Comment, error) {
+	c, err := scanComment(s.db.QueryRow(commentSelect+" WHERE c.id = ? AND c.todo_id = ?", id, todoID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Comment{}, store.ErrCommentNotFound
+	}
+	return c, err
+}
+
+// GetComments 获取待办事项的全部评论
+func (s *Store) GetComments(todoID int, userID int) ([]models.Comment, error) {
+	if err := s.requireTodo(todoID, userID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(commentSelect+" WHERE c.todo_id = ? ORDER BY c.created_at, c.id", todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+
+	return comments, rows.Err()
+}
+
+// CreateComment 发表评论
+func (s *Store) CreateComment(comment models.Comment) (int64, error) {
+	if err := s.requireTodo(comment.TodoID, comment.UserID, models.RoleViewer); err != nil {
+		return 0, err
+	}
+
+	result, err := s.db.Exec("INSERT INTO comments (todo_id, user_id, body, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+		comment.TodoID, comment.UserID, comment.Body, time.Now(), time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// UpdateComment 修改评论正文
+func (s *Store) UpdateComment(comment models.Comment) error {
+	if err := s.requireTodo(comment.TodoID, comment.UserID, models.RoleViewer); err != nil {
+		return err
+	}
+	current, err := s.comment(comment.ID, comment.TodoID)
+	if err != nil {
+		return err
+	}
+	if current.UserID != comment.UserID {
+		return store.ErrForbidden
+	}
+
+	_, err = s.db.Exec("UPDATE comments SET body = ?, updated_at = ? WHERE id = ?", comment.Body, time.Now(), comment.ID)
+	return err
+}
+
+// DeleteComment 删除评论
+func (s *Store) DeleteComment(id int, todoID int, userID int) error {
+	if err := s.requireTodo(todoID, userID, models.RoleViewer); err != nil {
+		return err
+	}
+	current, err := s.comment(id, todoID)
+	if err != nil {
+		return err
+	}
+	if current.UserID != userID {
+		// 不是作者时需要能修改该待办事项
+		if err := s.requireTodo(todoID, userID, models.RoleEditor); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.db.Exec("DELETE FROM comments WHERE id = ?", id)
+	return err
+}
+
+// loadCommentCounts 批量查询并填充待办事项的评论数
+func (s *Store) loadCommentCounts(todos []models.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(todos))
+	args := make([]interface{}, len(todos))
+	index := make(map[int]int, len(todos))
+	for i, t := range todos {
+		placeholders[i] = "?"
+		args[i] = t.ID
+		index[t.ID] = i
+	}
+
+	rows, err := s.db.Query("SELECT todo_id, COUNT(*) FROM comments WHERE todo_id IN ("+strings.Join(placeholders, ", ")+") GROUP BY todo_id", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID, count int
+		if err := rows.Scan(&todoID, &count); err != nil {
+			return err
+		}
+		todos[index[todoID]].CommentCount = count
+	}
+
+	return rows.Err()
+}
+
+// SearchTodos 用 LIKE 粗筛搜索候选，SQLite 的 LOWER 已替换为 strings.ToLower（见 sqlite.go），两种数据库都按 Unicode 规则忽略大小写
+func (s *Store) SearchTodos(userID int, terms []string, beforeID int, limit int) ([]models.Todo, map[int][]models.Comment, bool, error) {
+	where, args := s.todoWhere(userID, store.TodoFilter{})
+	for _, term := range terms {
+		where += " AND (LOWER(title) LIKE ? ESCAPE '!' OR EXISTS (SELECT 1 FROM comments c WHERE c.todo_id = todos.id AND LOWER(c.body) LIKE ? ESCAPE '!'))"
+		args = append(args, likePattern(term), likePattern(term))
+	}
+	if beforeID > 0 {
+		where += " AND id < ?"
+		args = append(args, beforeID)
+	}
+
+	todos, err := s.queryTodos("SELECT "+todoColumns+" FROM todos"+where+" ORDER BY id DESC LIMIT ?", append(args, limit+1)...)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	more := len(todos) > limit
+	if more {
+		todos = todos[:limit]
+	}
+	comments := make(map[int][]models.Comment)
+	if len(todos) == 0 || len(terms) == 0 {
+		return todos, comments, more, nil
+	}
+
+	ids := make([]string, len(todos))
+	args = nil
+	for i, t := range todos {
+		ids[i] = "?"
+		args = append(args, t.ID)
+	}
+	conds := make([]string, len(terms))
+	for i, term := range terms {
+		conds[i] = "LOWER(c.body) LIKE ? ESCAPE '!'"
+		args = append(args, likePattern(term))
+	}
+
+	rows, err := s.db.Query(commentSelect+" WHERE c.todo_id IN ("+strings.Join(ids, ", ")+") AND ("+strings.Join(conds, " OR ")+") ORDER BY c.created_at, c.id", args...)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		comments[c.TodoID] = append(comments[c.TodoID], c)
+	}
+
+	return todos, comments, more, rows.Err()
+}
@@ -2,211 +2,91 @@ package database
 
 import (
 	"database/sql"
-	"errors"
-	"log"
+	"fmt"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
-	"github.com/joy_project/todo-list-backend/internal/models"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/joy_project/todo-list-backend/internal/store"
 )
 
-var DB *sql.DB
-
-func InitDB() {
-	var err error
-	DB, err = sql.Open("mysql", "root:@tcp(127.0.0.1:3306)/todo_list?parseTime=true")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	if err = DB.Ping(); err != nil {
-		log.Fatal(err)
-	}
-
-	log.Println("Connected to the database successfully")
-}
-
-// 用户相关操作
-
-// CreateUser 创建新用户
-func CreateUser(user models.RegisterRequest) (int64, error) {
-	// 检查邮箱是否已存在
-	var count int
-	err := DB.QueryRow("SELECT COUNT(*) FROM users WHERE email = ?", user.Email).Scan(&count)
-	if err != nil {
-		return 0, err
-	}
-	if count > 0 {
-		return 0, errors.New("email already exists")
-	}
-
-	// 检查用户名是否已存在
-	err = DB.QueryRow("SELECT COUNT(*) FROM users WHERE username = ?", user.Username).Scan(&count)
-	if err != nil {
-		return 0, err
-	}
-	if count > 0 {
-		return 0, errors.New("username already exists")
-	}
-
-	// 哈希密码
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return 0, err
-	}
-
-	// 插入用户
-	result, err := DB.Exec(
-		"INSERT INTO users (username, email, password, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
-		user.Username, user.Email, hashedPassword, time.Now(), time.Now(),
-	)
-	if err != nil {
-		return 0, err
-	}
-
-	return result.LastInsertId()
-}
+// 支持的数据库驱动
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite3"
+)
 
-// GetUserByEmail 通过邮箱获取用户
-func GetUserByEmail(email string) (models.User, error) {
-	var user models.User
-	err := DB.QueryRow(
-		"SELECT id, username, email, password, created_at, updated_at FROM users WHERE email = ?",
-		email,
-	).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+// DefaultDSN 本地开发使用的 MySQL 连接串
+const DefaultDSN = "root:@tcp(127.0.0.1:3306)/todo_list?parseTime=true"
 
-	if err != nil {
-		return models.User{}, err
-	}
-
-	return user, nil
+// Store 基于 database/sql 的 store.Store 实现，workspaceID 不为 0 时是 Workspace 返回的视图
+type Store struct {
+	db          *sql.DB
+	driver      string
+	workspaceID int
 }
 
-// GetUserByID 通过ID获取用户
-func GetUserByID(id int) (models.User, error) {
-	var user models.User
-	err := DB.QueryRow(
-		"SELECT id, username, email, password, created_at, updated_at FROM users WHERE id = ?",
-		id,
-	).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt)
-
-	if err != nil {
-		return models.User{}, err
-	}
+// 编译期检查 Store 是否实现了 store.Store
+var _ store.Store = (*Store)(nil)
 
-	return user, nil
+// Options 打开数据库所需的参数
+type Options struct {
+	Driver          string
+	DSN             string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
 }
 
-// Todo相关操作
-
-// GetAllTodos 获取指定用户的所有待办事项
-func GetAllTodos(userID int) ([]models.Todo, error) {
-	rows, err := DB.Query("SELECT id, title, completed, priority, user_id, created_at, updated_at FROM todos WHERE user_id = ? ORDER BY created_at DESC", userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var todos []models.Todo
-	for rows.Next() {
-		var todo models.Todo
-		err := rows.Scan(&todo.ID, &todo.Title, &todo.Completed, &todo.Priority, &todo.UserID, &todo.CreatedAt, &todo.UpdatedAt)
-		if err != nil {
+// Open 按驱动打开数据库连接、设置连接池并检查连通性
+func Open(opts Options) (*Store, error) {
+	dsn, driverName := opts.DSN, opts.Driver
+	switch opts.Driver {
+	case DriverMySQL:
+		var err error
+		if dsn, err = mysqlDSN(dsn); err != nil {
 			return nil, err
 		}
-		todos = append(todos, todo)
+	case DriverSQLite:
+		dsn, driverName = sqliteDSN(dsn), sqliteDriver
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", opts.Driver)
 	}
 
-	if len(todos) == 0 {
-		return []models.Todo{}, nil
-	}
-
-	return todos, nil
-}
-
-// GetTodosWithPagination 获取指定用户的待办事项，支持分页
-func GetTodosWithPagination(userID int, page, pageSize int) ([]models.Todo, int, error) {
-	// 获取总记录数
-	var total int
-	err := DB.QueryRow("SELECT COUNT(*) FROM todos WHERE user_id = ?", userID).Scan(&total)
+	db, err := sql.Open(driverName, dsn)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	// 计算偏移量
-	offset := (page - 1) * pageSize
-
-	// 查询分页数据
-	rows, err := DB.Query(
-		"SELECT id, title, completed, priority, user_id, created_at, updated_at FROM todos WHERE user_id = ? ORDER BY created_at DESC LIMIT ? OFFSET ?",
-		userID, pageSize, offset,
-	)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer rows.Close()
-
-	var todos []models.Todo
-	for rows.Next() {
-		var todo models.Todo
-		err := rows.Scan(&todo.ID, &todo.Title, &todo.Completed, &todo.Priority, &todo.UserID, &todo.CreatedAt, &todo.UpdatedAt)
-		if err != nil {
-			return nil, 0, err
-		}
-		todos = append(todos, todo)
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	if opts.Driver == DriverSQLite {
+		configureSQLite(db)
 	}
 
-	if len(todos) == 0 {
-		return []models.Todo{}, total, nil
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
 	}
 
-	return todos, total, nil
+	return New(db, opts.Driver), nil
 }
 
-// CreateTodo 创建待办事项
-func CreateTodo(todo models.Todo) (int64, error) {
-	result, err := DB.Exec("INSERT INTO todos (title, completed, priority, user_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
-		todo.Title, todo.Completed, todo.Priority, todo.UserID, time.Now(), time.Now())
-	if err != nil {
-		return 0, err
-	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, err
-	}
-	return id, nil
+// New 使用已打开的连接创建 Store
+func New(db *sql.DB, driver string) *Store {
+	return &Store{db: db, driver: driver}
 }
 
-// UpdateTodo 更新待办事项
-func UpdateTodo(todo models.Todo) error {
-	// 验证待办事项属于当前用户
-	var count int
-	err := DB.QueryRow("SELECT COUNT(*) FROM todos WHERE id = ? AND user_id = ?", todo.ID, todo.UserID).Scan(&count)
-	if err != nil {
-		return err
-	}
-	if count == 0 {
-		return errors.New("todo not found or not owned by user")
-	}
-
-	_, err = DB.Exec("UPDATE todos SET title = ?, completed = ?, priority = ?, updated_at = ? WHERE id = ?",
-		todo.Title, todo.Completed, todo.Priority, time.Now(), todo.ID)
-	return err
+// Workspace 返回限定在 workspaceID 内的视图，与 s 共用同一个连接池
+func (s *Store) Workspace(workspaceID int) store.TenantStore {
+	return &Store{db: s.db, driver: s.driver, workspaceID: workspaceID}
 }
 
-// DeleteTodo 删除待办事项
-func DeleteTodo(id int, userID int) error {
-	// 验证待办事项属于当前用户
-	var count int
-	err := DB.QueryRow("SELECT COUNT(*) FROM todos WHERE id = ? AND user_id = ?", id, userID).Scan(&count)
-	if err != nil {
-		return err
-	}
-	if count == 0 {
-		return errors.New("todo not found or not owned by user")
-	}
+// DB 返回底层连接，供迁移等需要直接执行 SQL 的场景使用
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
 
-	_, err = DB.Exec("DELETE FROM todos WHERE id = ?", id)
-	return err
+// Close 关闭底层数据库连接
+func (s *Store) Close() error {
+	return s.db.Close()
 }
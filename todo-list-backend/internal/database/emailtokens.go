@@ -0,0 +1,65 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 邮件一次性令牌相关操作
+
+// CreateEmailToken 保存令牌并作废同一用途的旧令牌
+func (s *Store) CreateEmailToken(token models.EmailToken) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM email_tokens WHERE (user_id = ? AND purpose = ?) OR expires_at < ?", token.UserID, token.Purpose, time.Now().UTC()); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO email_tokens (user_id, purpose, token_hash, expires_at, created_at) VALUES (?, ?, ?, ?, ?)",
+		token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt.UTC(), time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConsumeEmailToken 使用令牌，条件更新保证并发提交时只有一个请求成功
+func (s *Store) ConsumeEmailToken(hash string, purpose models.TokenPurpose) (models.EmailToken, error) {
+	var t models.EmailToken
+	var usedAt sql.NullTime
+	err := s.db.QueryRow("SELECT id, user_id, purpose, token_hash, expires_at, used_at, created_at FROM email_tokens WHERE token_hash = ? AND purpose = ?", hash, purpose).
+		Scan(&t.ID, &t.UserID, &t.Purpose, &t.TokenHash, &t.ExpiresAt, &usedAt, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.EmailToken{}, store.ErrInvalidEmailToken
+	}
+	if err != nil {
+		return models.EmailToken{}, err
+	}
+
+	now := time.Now().UTC()
+	if usedAt.Valid || !now.Before(t.ExpiresAt) {
+		return models.EmailToken{}, store.ErrInvalidEmailToken
+	}
+
+	result, err := s.db.Exec("UPDATE email_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL", now, t.ID)
+	if err != nil {
+		return models.EmailToken{}, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return models.EmailToken{}, err
+	}
+	if n == 0 {
+		return models.EmailToken{}, store.ErrInvalidEmailToken
+	}
+
+	t.ExpiresAt = t.ExpiresAt.UTC()
+	t.UsedAt = &now
+	return t, nil
+}
@@ -0,0 +1,21 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlDSN 为连接串开启 parseTime，使时间列扫描到 time.Time
+func mysqlDSN(dsn string) (string, error) {
+	if dsn == "" {
+		dsn = DefaultDSN
+	}
+
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("invalid MySQL DSN: %w", err)
+	}
+	cfg.ParseTime = true
+	return cfg.FormatDSN(), nil
+}
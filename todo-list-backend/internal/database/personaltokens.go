@@ -0,0 +1,110 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 个人访问令牌相关操作。权限范围以空格分隔保存在一列中
+
+const personalTokenColumns = "id, user_id, workspace_id, name, prefix, token_hash, scopes, expires_at, last_used_at, created_at"
+
+func scanPersonalToken(row interface{ Scan(...interface{}) error }) (models.PersonalAccessToken, error) {
+	var t models.PersonalAccessToken
+	var scopes string
+	var expiresAt, lastUsedAt sql.NullTime
+	err := row.Scan(&t.ID, &t.UserID, &t.WorkspaceID, &t.Name, &t.Prefix, &t.TokenHash, &scopes, &expiresAt, &lastUsedAt, &t.CreatedAt)
+	if err != nil {
+		return models.PersonalAccessToken{}, err
+	}
+	t.Scopes = []models.Scope{}
+	for _, scope := range strings.Fields(scopes) {
+		t.Scopes = append(t.Scopes, models.Scope(scope))
+	}
+	t.ExpiresAt = timePtr(expiresAt)
+	t.LastUsedAt = timePtr(lastUsedAt)
+	return t, nil
+}
+
+// joinScopes 把权限范围拼接为保存到数据库的文本
+func joinScopes(scopes []models.Scope) string {
+	parts := make([]string, len(scopes))
+	for i, scope := range scopes {
+		parts[i] = string(scope)
+	}
+	return strings.Join(parts, " ")
+}
+
+// CreatePersonalToken 保存个人访问令牌
+func (s *Store) CreatePersonalToken(token models.PersonalAccessToken) (int64, error) {
+	result, err := s.db.Exec("INSERT INTO personal_access_tokens (user_id, workspace_id, name, prefix, token_hash, scopes, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		token.UserID, token.WorkspaceID, token.Name, token.Prefix, token.TokenHash, joinScopes(token.Scopes), nullTime(token.ExpiresAt), time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetPersonalTokens 获取用户的全部个人访问令牌
+func (s *Store) GetPersonalTokens(userID int) ([]models.PersonalAccessToken, error) {
+	rows, err := s.db.Query("SELECT "+personalTokenColumns+" FROM personal_access_tokens WHERE user_id = ? ORDER BY created_at DESC, id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []models.PersonalAccessToken{}
+	for rows.Next() {
+		t, err := scanPersonalToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, rows.Err()
+}
+
+// DeletePersonalToken 撤销个人访问令牌
+func (s *Store) DeletePersonalToken(id int, userID int) error {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM personal_access_tokens WHERE id = ? AND user_id = ?", id, userID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrPersonalTokenNotFound
+	}
+
+	_, err = s.db.Exec("DELETE FROM personal_access_tokens WHERE id = ? AND user_id = ?", id, userID)
+	return err
+}
+
+// UsePersonalToken 查找令牌并记录使用时间
+func (s *Store) UsePersonalToken(hash string) (models.PersonalAccessToken, error) {
+	t, err := scanPersonalToken(s.db.QueryRow("SELECT "+personalTokenColumns+" FROM personal_access_tokens WHERE token_hash = ?", hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.PersonalAccessToken{}, store.ErrInvalidPersonalToken
+	}
+	if err != nil {
+		return models.PersonalAccessToken{}, err
+	}
+
+	now := time.Now().UTC()
+	if t.ExpiresAt != nil && !now.Before(*t.ExpiresAt) {
+		return models.PersonalAccessToken{}, store.ErrInvalidPersonalToken
+	}
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) >= store.LastUsedInterval {
+		if _, err := s.db.Exec("UPDATE personal_access_tokens SET last_used_at = ? WHERE id = ?", now, t.ID); err != nil {
+			return models.PersonalAccessToken{}, err
+		}
+		t.LastUsedAt = &now
+	}
+
+	return t, nil
+}
@@ -0,0 +1,237 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 手动排序相关操作
+
+// siblingWhere 当前工作区中某组同级（同一项目和父项）的条件，NULL 也视为相等
+func (s *Store) siblingWhere(projectID, parentID *int) (string, []interface{}) {
+	return " WHERE workspace_id = ? AND (project_id = ? OR (project_id IS NULL AND ? IS NULL)) AND (parent_id = ? OR (parent_id IS NULL AND ? IS NULL))",
+		[]interface{}{s.workspaceID, nullInt(projectID), nullInt(projectID), nullInt(parentID), nullInt(parentID)}
+}
+
+// lockSiblings 在事务中锁定一组同级所属的行，使并发的位置读写串行执行，SQLite 只有一个写者不需要加锁
+func (s *Store) lockSiblings(tx *sql.Tx, projectID, parentID *int) error {
+	if s.driver != DriverMySQL {
+		return nil
+	}
+
+	query, arg := "SELECT id FROM workspaces WHERE id = ? FOR UPDATE", s.workspaceID
+	switch {
+	case parentID != nil:
+		query, arg = "SELECT id FROM todos WHERE id = ? FOR UPDATE", *parentID
+	case projectID != nil:
+		query, arg = "SELECT id FROM projects WHERE id = ? FOR UPDATE", *projectID
+	}
+	var id int
+	if err := tx.QueryRow(query, arg).Scan(&id); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	return nil
+}
+
+// endPosition 在事务中锁定同级并返回排在末尾的位置
+func (s *Store) endPosition(tx *sql.Tx, projectID, parentID *int) (float64, error) {
+	if err := s.lockSiblings(tx, projectID, parentID); err != nil {
+		return 0, err
+	}
+
+	where, args := s.siblingWhere(projectID, parentID)
+	var last sql.NullFloat64
+	if err := tx.QueryRow("SELECT MAX(position) FROM todos"+where, args...).Scan(&last); err != nil {
+		return 0, err
+	}
+	if !last.Valid {
+		position, _ := store.PositionBetween(nil, nil)
+		return position, nil
+	}
+	position, _ := store.PositionBetween(&last.Float64, nil)
+	return position, nil
+}
+
+// ReorderTodo 把待办事项放到同级锚点之前或之后
+func (s *Store) ReorderTodo(id int, userID int, anchorID int, before bool) error {
+	if err := s.requireTodo(id, userID, models.RoleEditor); err != nil {
+		return err
+	}
+	todo, err := s.GetTodo(id, userID)
+	if err != nil {
+		return err
+	}
+	anchor, err := s.GetTodo(anchorID, userID)
+	if errors.Is(err, store.ErrTodoNotFound) || errors.Is(err, store.ErrForbidden) {
+		return store.ErrInvalidAnchor
+	}
+	if err != nil {
+		return err
+	}
+	if anchor.ID == todo.ID || !sameInt(anchor.ProjectID, todo.ProjectID) || !sameInt(anchor.ParentID, todo.ParentID) {
+		return store.ErrInvalidAnchor
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.lockSiblings(tx, todo.ProjectID, todo.ParentID); err != nil {
+		return err
+	}
+	position, ok, err := s.positionNextTo(tx, todo, anchorID, before)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// 间隔已经过小，先把这组同级重新编号，再重新计算
+		if err := s.rebalance(tx, todo.ProjectID, todo.ParentID); err != nil {
+			return err
+		}
+		if position, _, err = s.positionNextTo(tx, todo, anchorID, before); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE todos SET position = ?, updated_at = ? WHERE id = ? AND workspace_id = ?", position, time.Now(), id, s.workspaceID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// positionNextTo 计算锚点与其另一侧相邻项（不包括被移动的待办事项）之间的位置
+func (s *Store) positionNextTo(tx *sql.Tx, todo models.Todo, anchorID int, before bool) (float64, bool, error) {
+	var anchor float64
+	if err := tx.QueryRow("SELECT position FROM todos WHERE id = ? AND workspace_id = ?", anchorID, s.workspaceID).Scan(&anchor); err != nil {
+		return 0, false, err
+	}
+
+	where, args := s.siblingWhere(todo.ProjectID, todo.ParentID)
+	args = append(args, todo.ID, anchor, anchor, anchorID)
+	query := "SELECT position FROM todos" + where + " AND id <> ? AND (position > ? OR (position = ? AND id < ?)) ORDER BY position, id DESC LIMIT 1"
+	if before {
+		query = "SELECT position FROM todos" + where + " AND id <> ? AND (position < ? OR (position = ? AND id > ?)) ORDER BY position DESC, id LIMIT 1"
+	}
+
+	var neighbor *float64
+	var value float64
+	err := tx.QueryRow(query, args...).Scan(&value)
+	if err == nil {
+		neighbor = &value
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, err
+	}
+
+	var position float64
+	var ok bool
+	if before {
+		position, ok = store.PositionBetween(neighbor, &anchor)
+	} else {
+		position, ok = store.PositionBetween(&anchor, neighbor)
+	}
+	return position, ok, nil
+}
+
+// rebalance 按当前顺序把一组同级重新编号为等间隔的位置
+func (s *Store) rebalance(tx *sql.Tx, projectID, parentID *int) error {
+	where, args := s.siblingWhere(projectID, parentID)
+	rows, err := tx.Query("SELECT id FROM todos"+where+" ORDER BY position, id DESC", args...)
+	if err != nil {
+		return err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i, id := range ids {
+		if _, err := tx.Exec("UPDATE todos SET position = ? WHERE id = ? AND workspace_id = ?", store.RebalancedPosition(i), id, s.workspaceID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// siblingGroup 一组同级所在的工作区、项目和父项
+type siblingGroup struct {
+	workspaceID int
+	projectID   *int
+	parentID    *int
+}
+
+// RebalancePositions 把存在过小间隔的同级组重新编号
+func (s *Store) RebalancePositions() (int, error) {
+	rows, err := s.db.Query("SELECT workspace_id, project_id, parent_id, position FROM todos ORDER BY workspace_id, project_id, parent_id, position, id DESC")
+	if err != nil {
+		return 0, err
+	}
+	var groups []siblingGroup
+	var current siblingGroup
+	var positions []float64
+	flush := func() {
+		if store.NeedsRebalance(positions) {
+			groups = append(groups, current)
+		}
+		positions = positions[:0]
+	}
+	for rows.Next() {
+		var g siblingGroup
+		var projectID, parentID sql.NullInt64
+		var position float64
+		if err := rows.Scan(&g.workspaceID, &projectID, &parentID, &position); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		g.projectID, g.parentID = intPtr(projectID), intPtr(parentID)
+		if g.workspaceID != current.workspaceID || !sameInt(g.projectID, current.projectID) || !sameInt(g.parentID, current.parentID) {
+			flush()
+			current = g
+		}
+		positions = append(positions, position)
+	}
+	flush()
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, g := range groups {
+		ws := &Store{db: s.db, driver: s.driver, workspaceID: g.workspaceID}
+		if err := ws.rebalanceGroup(g.projectID, g.parentID); err != nil {
+			return 0, err
+		}
+	}
+	return len(groups), nil
+}
+
+// rebalanceGroup 在单独的事务中锁定并重新编号一组同级
+func (s *Store) rebalanceGroup(projectID, parentID *int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.lockSiblings(tx, projectID, parentID); err != nil {
+		return err
+	}
+	if err := s.rebalance(tx, projectID, parentID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 项目相关操作
+
+// projectSelect 查询项目及当前用户角色的语句，需要两个 userID 参数
+const projectSelect = `SELECT p.id, p.workspace_id, p.user_id, p.name, p.color, p.archived, p.position, p.inbox, p.created_at, p.updated_at,
+	CASE WHEN p.user_id = ? THEN 'owner' ELSE m.role END
+	FROM projects p LEFT JOIN project_members m ON m.project_id = p.id AND m.user_id = ?`
+
+func scanProject(row interface{ Scan(...interface{}) error }) (models.Project, error) {
+	var p models.Project
+	err := row.Scan(&p.ID, &p.WorkspaceID, &p.UserID, &p.Name, &p.Color, &p.Archived, &p.Position, &p.Inbox, &p.CreatedAt, &p.UpdatedAt, &p.Role)
+	return p, err
+}
+
+// GetProjects 获取用户可访问的项目
+func (s *Store) GetProjects(userID int, includeArchived bool) ([]models.Project, error) {
+	query := projectSelect + " WHERE p.workspace_id = ? AND (p.user_id = ? OR m.user_id IS NOT NULL)"
+	args := []interface{}{userID, userID, s.workspaceID, userID}
+	if !includeArchived {
+		query += " AND p.archived = ?"
+		args = append(args, false)
+	}
+
+	rows, err := s.db.Query(query+" ORDER BY p.position, p.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	for rows.Next() {
+		p, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, p)
+	}
+
+	return projects, rows.Err()
+}
+
+// GetProject 获取单个项目
+func (s *Store) GetProject(id int, userID int) (models.Project, error) {
+	p, err := scanProject(s.db.QueryRow(projectSelect+" WHERE p.id = ? AND p.workspace_id = ? AND (p.user_id = ? OR m.user_id IS NOT NULL)", userID, userID, id, s.workspaceID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Project{}, store.ErrProjectNotFound
+	}
+	return p, err
+}
+
+// checkProject 验证用户可以向项目中放入待办事项：至少为 editor 且项目未归档
+func (s *Store) checkProject(id int, userID int) error {
+	p, err := s.GetProject(id, userID)
+	if errors.Is(err, store.ErrProjectNotFound) || (err == nil && p.Archived) {
+		return store.ErrInvalidProject
+	}
+	if err != nil {
+		return err
+	}
+	if !p.Role.Allows(models.RoleEditor) {
+		return store.ErrForbidden
+	}
+	return nil
+}
+
+// CreateProject 创建项目
+func (s *Store) CreateProject(project models.Project) (int64, error) {
+	return s.insertProject(project, false)
+}
+
+func (s *Store) insertProject(project models.Project, inbox bool) (int64, error) {
+	if project.Position == 0 && !inbox {
+		// 未指定位置时排在最后
+		err := s.db.QueryRow("SELECT COALESCE(MAX(position), 0) + 1 FROM projects WHERE user_id = ? AND workspace_id = ?", project.UserID, s.workspaceID).Scan(&project.Position)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := s.db.Exec("INSERT INTO projects (workspace_id, user_id, name, color, archived, position, inbox, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		s.workspaceID, project.UserID, project.Name, project.Color, project.Archived, project.Position, inbox, time.Now(), time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateProject 修改项目
+func (s *Store) UpdateProject(project models.Project) error {
+	existing, err := s.GetProject(project.ID, project.UserID)
+	if err != nil {
+		return err
+	}
+	if !existing.Role.Allows(models.RoleOwner) {
+		return store.ErrForbidden
+	}
+	if existing.Inbox && project.Archived {
+		return store.ErrInboxProject
+	}
+
+	_, err = s.db.Exec("UPDATE projects SET name = ?, color = ?, archived = ?, position = ?, updated_at = ? WHERE id = ? AND workspace_id = ?",
+		project.Name, project.Color, project.Archived, project.Position, time.Now(), project.ID, s.workspaceID)
+	return err
+}
+
+// DeleteProject 删除项目，其中的待办事项移到各自创建者的收件箱
+func (s *Store) DeleteProject(id int, userID int) error {
+	project, err := s.GetProject(id, userID)
+	if err != nil {
+		return err
+	}
+	if !project.Role.Allows(models.RoleOwner) {
+		return store.ErrForbidden
+	}
+	if project.Inbox {
+		return store.ErrInboxProject
+	}
+
+	// 共享项目中的待办事项可能由不同成员创建，先确保每个创建者在当前工作区都有收件箱
+	creators, err := s.queryIDs("SELECT DISTINCT user_id FROM todos WHERE project_id = ?", id)
+	if err != nil {
+		return err
+	}
+	for _, creator := range creators {
+		if _, err := s.EnsureInbox(creator); err != nil {
+			return err
+		}
+	}
+
+	// 父项由其他成员创建的子项会进入不同的收件箱，需要先变为顶层待办
+	detached, err := s.queryIDs("SELECT c.id FROM todos c JOIN todos p ON p.id = c.parent_id WHERE c.project_id = ? AND p.user_id <> c.user_id", id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if len(detached) > 0 {
+		placeholders := make([]string, len(detached))
+		args := []interface{}{time.Now()}
+		for i, todoID := range detached {
+			placeholders[i] = "?"
+			args = append(args, todoID)
+		}
+		if _, err := tx.Exec("UPDATE todos SET parent_id = NULL, updated_at = ? WHERE id IN ("+strings.Join(placeholders, ", ")+")", args...); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("UPDATE todos SET project_id = (SELECT MIN(i.id) FROM projects i WHERE i.user_id = todos.user_id AND i.workspace_id = todos.workspace_id AND i.inbox = ?), updated_at = ? WHERE project_id = ?",
+		true, time.Now(), id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM projects WHERE id = ?", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// queryIDs 执行只返回一列整数的查询
+func (s *Store) queryIDs(query string, args ...interface{}) ([]int, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// EnsureInbox 返回用户在当前工作区的收件箱，不存在时创建
+func (s *Store) EnsureInbox(userID int) (models.Project, error) {
+	p, err := scanProject(s.db.QueryRow(projectSelect+" WHERE p.user_id = ? AND p.workspace_id = ? AND p.inbox = ? ORDER BY p.id LIMIT 1", userID, userID, userID, s.workspaceID, true))
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return p, err
+	}
+
+	id, err := s.insertProject(models.Project{UserID: userID, Name: "Inbox", Color: "#808080"}, true)
+	if err != nil {
+		return models.Project{}, err
+	}
+	return s.GetProject(int(id), userID)
+}
@@ -0,0 +1,302 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 项目共享相关操作
+
+// accessibleProjects 用户可以访问的项目ID子查询（自己创建的和作为成员加入的），需要两个 userID 参数
+const accessibleProjects = "SELECT id FROM projects WHERE user_id = ? UNION SELECT project_id FROM project_members WHERE user_id = ?"
+
+// projectRole 返回用户在项目中的角色，项目创建者总是 owner；不在当前工作区或无法访问时返回 ErrProjectNotFound
+func (s *Store) projectRole(projectID int, userID int) (models.Role, error) {
+	var ownerID int
+	err := s.db.QueryRow("SELECT user_id FROM projects WHERE id = ? AND workspace_id = ?", projectID, s.workspaceID).Scan(&ownerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", store.ErrProjectNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if ownerID == userID {
+		return models.RoleOwner, nil
+	}
+
+	var role models.Role
+	err = s.db.QueryRow("SELECT role FROM project_members WHERE project_id = ? AND user_id = ?", projectID, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", store.ErrProjectNotFound
+	}
+	return role, err
+}
+
+// requireProject 检查用户在项目中至少具有 required 角色
+func (s *Store) requireProject(projectID int, userID int, required models.Role) error {
+	role, err := s.projectRole(projectID, userID)
+	if err != nil {
+		return err
+	}
+	if !role.Allows(required) {
+		return store.ErrForbidden
+	}
+	return nil
+}
+
+// requireTodo 检查用户对待办事项至少具有 required 角色，无法访问时返回 ErrTodoNotFound
+func (s *Store) requireTodo(id int, userID int, required models.Role) error {
+	var ownerID int
+	var projectID, assigneeID sql.NullInt64
+	err := s.db.QueryRow("SELECT user_id, project_id, assignee_id FROM todos WHERE id = ? AND workspace_id = ?", id, s.workspaceID).
+		Scan(&ownerID, &projectID, &assigneeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ErrTodoNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if !projectID.Valid {
+		if ownerID == userID {
+			return nil
+		}
+		err = store.ErrTodoNotFound
+	} else {
+		err = s.requireProject(int(projectID.Int64), userID, required)
+		if errors.Is(err, store.ErrProjectNotFound) {
+			err = store.ErrTodoNotFound
+		}
+	}
+
+	if err != nil && assigneeID.Valid && int(assigneeID.Int64) == userID {
+		if required == models.RoleViewer {
+			return nil
+		}
+		return store.ErrForbidden
+	}
+	return err
+}
+
+// isAssignee 判断待办事项是否指派给了 userID
+func (s *Store) isAssignee(id int, userID int) (bool, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM todos WHERE id = ? AND workspace_id = ? AND assignee_id = ?", id, s.workspaceID, userID).Scan(&count)
+	return count > 0, err
+}
+
+// checkAssignee 检查负责人是当前工作区的成员，assigneeID 为 nil 表示不指派
+func (s *Store) checkAssignee(assigneeID *int) error {
+	if assigneeID == nil {
+		return nil
+	}
+	_, err := s.workspaceRole(s.workspaceID, *assigneeID)
+	if errors.Is(err, store.ErrWorkspaceNotFound) {
+		return store.ErrInvalidAssignee
+	}
+	return err
+}
+
+// GetMembers 获取项目成员，创建者排在最前
+func (s *Store) GetMembers(projectID int, userID int) ([]models.Member, error) {
+	if err := s.requireProject(projectID, userID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	owner := models.Member{Role: models.RoleOwner}
+	err := s.db.QueryRow("SELECT u.id, u.username, p.created_at FROM projects p JOIN users u ON u.id = p.user_id WHERE p.id = ?", projectID).
+		Scan(&owner.UserID, &owner.Username, &owner.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query("SELECT u.id, u.username, m.role, m.created_at FROM project_members m JOIN users u ON u.id = m.user_id WHERE m.project_id = ? ORDER BY m.created_at, u.id", projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.Member{owner}
+	for rows.Next() {
+		var m models.Member
+		if err := rows.Scan(&m.UserID, &m.Username, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
+// isMember 判断用户是否为项目的成员（不包括创建者）
+func (s *Store) isMember(projectID int, userID int) (bool, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM project_members WHERE project_id = ? AND user_id = ?", projectID, userID).Scan(&count)
+	return count > 0, err
+}
+
+// UpdateMemberRole 修改成员的角色
+func (s *Store) UpdateMemberRole(projectID int, memberID int, role models.Role, userID int) error {
+	if err := s.requireProject(projectID, userID, models.RoleOwner); err != nil {
+		return err
+	}
+
+	member, err := s.isMember(projectID, memberID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return store.ErrMemberNotFound
+	}
+
+	_, err = s.db.Exec("UPDATE project_members SET role = ? WHERE project_id = ? AND user_id = ?", role, projectID, memberID)
+	return err
+}
+
+// RemoveMember 移除成员
+func (s *Store) RemoveMember(projectID int, memberID int, userID int) error {
+	required := models.RoleOwner
+	if memberID == userID {
+		// 成员可以自己退出项目
+		required = models.RoleViewer
+	}
+	if err := s.requireProject(projectID, userID, required); err != nil {
+		return err
+	}
+
+	member, err := s.isMember(projectID, memberID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return store.ErrMemberNotFound
+	}
+
+	_, err = s.db.Exec("DELETE FROM project_members WHERE project_id = ? AND user_id = ?", projectID, memberID)
+	return err
+}
+
+// CreateInvitation 邀请用户加入项目
+func (s *Store) CreateInvitation(projectID int, email string, role models.Role, userID int) (int64, error) {
+	project, err := s.GetProject(projectID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if !project.Role.Allows(models.RoleOwner) {
+		return 0, store.ErrForbidden
+	}
+	if project.Inbox {
+		return 0, store.ErrInboxProject
+	}
+
+	invitee, err := s.GetUserByEmail(email)
+	if err != nil {
+		return 0, err
+	}
+	// 只能邀请同一工作区的成员，否则项目会对工作区外的用户可见
+	if _, err := s.workspaceRole(s.workspaceID, invitee.ID); errors.Is(err, store.ErrWorkspaceNotFound) {
+		return 0, store.ErrUserNotFound
+	} else if err != nil {
+		return 0, err
+	}
+	member, err := s.isMember(projectID, invitee.ID)
+	if err != nil {
+		return 0, err
+	}
+	if member || invitee.ID == project.UserID {
+		return 0, store.ErrAlreadyMember
+	}
+
+	var count int
+	err = s.db.QueryRow("SELECT COUNT(*) FROM project_invitations WHERE project_id = ? AND invitee_id = ? AND status = ?",
+		projectID, invitee.ID, models.InvitationPending).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, store.ErrInvitationExists
+	}
+
+	result, err := s.db.Exec("INSERT INTO project_invitations (project_id, inviter_id, invitee_id, role, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		projectID, userID, invitee.ID, role, models.InvitationPending, time.Now(), time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+const invitationSelect = "SELECT i.id, i.project_id, p.name, i.inviter_id, i.invitee_id, i.role, i.status, i.created_at, i.updated_at FROM project_invitations i JOIN projects p ON p.id = i.project_id"
+
+func (s *Store) queryInvitations(query string, args ...interface{}) ([]models.Invitation, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []models.Invitation{}
+	for rows.Next() {
+		var inv models.Invitation
+		err := rows.Scan(&inv.ID, &inv.ProjectID, &inv.ProjectName, &inv.InviterID, &inv.InviteeID, &inv.Role, &inv.Status, &inv.CreatedAt, &inv.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, inv)
+	}
+
+	return invitations, rows.Err()
+}
+
+// GetProjectInvitations 获取项目中待处理的邀请
+func (s *Store) GetProjectInvitations(projectID int, userID int) ([]models.Invitation, error) {
+	if err := s.requireProject(projectID, userID, models.RoleOwner); err != nil {
+		return nil, err
+	}
+	return s.queryInvitations(invitationSelect+" WHERE i.project_id = ? AND i.status = ? ORDER BY i.created_at, i.id", projectID, models.InvitationPending)
+}
+
+// GetInvitations 获取用户在当前工作区收到的待处理邀请
+func (s *Store) GetInvitations(userID int) ([]models.Invitation, error) {
+	return s.queryInvitations(invitationSelect+" WHERE i.invitee_id = ? AND p.workspace_id = ? AND i.status = ? ORDER BY i.created_at, i.id", userID, s.workspaceID, models.InvitationPending)
+}
+
+// RespondInvitation 接受或拒绝邀请，接受时加入项目成员
+func (s *Store) RespondInvitation(id int, userID int, accept bool) error {
+	var projectID int
+	var role models.Role
+	err := s.db.QueryRow("SELECT i.project_id, i.role FROM project_invitations i JOIN projects p ON p.id = i.project_id WHERE i.id = ? AND i.invitee_id = ? AND p.workspace_id = ? AND i.status = ?",
+		id, userID, s.workspaceID, models.InvitationPending).Scan(&projectID, &role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ErrInvitationNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	status := models.InvitationDeclined
+	if accept {
+		status = models.InvitationAccepted
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE project_invitations SET status = ?, updated_at = ? WHERE id = ?", status, time.Now(), id); err != nil {
+		return err
+	}
+	if accept {
+		if _, err := tx.Exec("INSERT INTO project_members (project_id, user_id, role, created_at) VALUES (?, ?, ?, ?)",
+			projectID, userID, role, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 智能列表相关操作
+
+const smartListColumns = "id, workspace_id, user_id, name, query, created_at, updated_at"
+
+func scanSmartList(row interface{ Scan(...interface{}) error }) (models.SmartList, error) {
+	var l models.SmartList
+	err := row.Scan(&l.ID, &l.WorkspaceID, &l.UserID, &l.Name, &l.Query, &l.CreatedAt, &l.UpdatedAt)
+	return l, err
+}
+
+// GetSmartLists 获取用户在当前工作区的全部智能列表
+func (s *Store) GetSmartLists(userID int) ([]models.SmartList, error) {
+	rows, err := s.db.Query("SELECT "+smartListColumns+" FROM smart_lists WHERE user_id = ? AND workspace_id = ? ORDER BY name, id", userID, s.workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []models.SmartList{}
+	for rows.Next() {
+		l, err := scanSmartList(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, l)
+	}
+
+	return lists, rows.Err()
+}
+
+// GetSmartList 获取单个智能列表
+func (s *Store) GetSmartList(id int, userID int) (models.SmartList, error) {
+	l, err := scanSmartList(s.db.QueryRow("SELECT "+smartListColumns+" FROM smart_lists WHERE id = ? AND user_id = ? AND workspace_id = ?", id, userID, s.workspaceID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.SmartList{}, store.ErrSmartListNotFound
+	}
+	return l, err
+}
+
+// CreateSmartList 创建智能列表
+func (s *Store) CreateSmartList(list models.SmartList) (int64, error) {
+	result, err := s.db.Exec("INSERT INTO smart_lists (workspace_id, user_id, name, query, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		s.workspaceID, list.UserID, list.Name, list.Query, time.Now(), time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateSmartList 修改智能列表
+func (s *Store) UpdateSmartList(list models.SmartList) error {
+	if _, err := s.GetSmartList(list.ID, list.UserID); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec("UPDATE smart_lists SET name = ?, query = ?, updated_at = ? WHERE id = ? AND user_id = ? AND workspace_id = ?",
+		list.Name, list.Query, time.Now(), list.ID, list.UserID, s.workspaceID)
+	return err
+}
+
+// DeleteSmartList 删除智能列表
+func (s *Store) DeleteSmartList(id int, userID int) error {
+	if _, err := s.GetSmartList(id, userID); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec("DELETE FROM smart_lists WHERE id = ? AND user_id = ? AND workspace_id = ?", id, userID, s.workspaceID)
+	return err
+}
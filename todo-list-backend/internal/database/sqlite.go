@@ -0,0 +1,46 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteDriver 用 strings.ToLower 替换内置 LOWER 的 SQLite 驱动，使排序和搜索与内存存储一致
+const sqliteDriver = "sqlite3_unicode"
+
+func init() {
+	sql.Register(sqliteDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("lower", strings.ToLower, true)
+		},
+	})
+}
+
+// sqliteDSN 为连接串补上外键约束和忙等待参数
+func sqliteDSN(dsn string) string {
+	if dsn == "" {
+		dsn = "file:todo_list.db"
+	}
+
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	if !strings.Contains(dsn, "_foreign_keys") && !strings.Contains(dsn, "_fk") {
+		dsn += sep + "_foreign_keys=on"
+		sep = "&"
+	}
+	if !strings.Contains(dsn, "_busy_timeout") {
+		dsn += sep + "_busy_timeout=5000"
+	}
+	return dsn
+}
+
+// configureSQLite 限制为单个常驻连接，:memory: 数据库在每个连接上都是独立的
+func configureSQLite(db *sql.DB) {
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+}
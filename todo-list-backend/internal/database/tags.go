@@ -0,0 +1,193 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 标签相关操作
+
+const tagColumns = "id, workspace_id, user_id, name, color, created_at, updated_at"
+
+func scanTag(row interface{ Scan(...interface{}) error }) (models.Tag, error) {
+	var tag models.Tag
+	err := row.Scan(&tag.ID, &tag.WorkspaceID, &tag.UserID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.UpdatedAt)
+	return tag, err
+}
+
+// GetTags 获取用户在当前工作区的全部标签
+func (s *Store) GetTags(userID int) ([]models.Tag, error) {
+	rows, err := s.db.Query("SELECT "+tagColumns+" FROM tags WHERE user_id = ? AND workspace_id = ? ORDER BY name", userID, s.workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// GetTag 获取单个标签
+func (s *Store) GetTag(id int, userID int) (models.Tag, error) {
+	tag, err := scanTag(s.db.QueryRow("SELECT "+tagColumns+" FROM tags WHERE id = ? AND user_id = ? AND workspace_id = ?", id, userID, s.workspaceID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Tag{}, store.ErrTagNotFound
+	}
+	return tag, err
+}
+
+// tagNameTaken 检查同一用户在当前工作区是否已有同名标签，excludeID 用于修改时排除自身
+func (s *Store) tagNameTaken(userID int, name string, excludeID int) (bool, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM tags WHERE user_id = ? AND workspace_id = ? AND name = ? AND id <> ?", userID, s.workspaceID, name, excludeID).Scan(&count)
+	return count > 0, err
+}
+
+// CreateTag 创建标签
+func (s *Store) CreateTag(tag models.Tag) (int64, error) {
+	taken, err := s.tagNameTaken(tag.UserID, tag.Name, 0)
+	if err != nil {
+		return 0, err
+	}
+	if taken {
+		return 0, store.ErrTagExists
+	}
+
+	result, err := s.db.Exec("INSERT INTO tags (workspace_id, user_id, name, color, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		s.workspaceID, tag.UserID, tag.Name, tag.Color, time.Now(), time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateTag 修改标签
+func (s *Store) UpdateTag(tag models.Tag) error {
+	if _, err := s.GetTag(tag.ID, tag.UserID); err != nil {
+		return err
+	}
+
+	taken, err := s.tagNameTaken(tag.UserID, tag.Name, tag.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return store.ErrTagExists
+	}
+
+	_, err = s.db.Exec("UPDATE tags SET name = ?, color = ?, updated_at = ? WHERE id = ? AND user_id = ? AND workspace_id = ?",
+		tag.Name, tag.Color, time.Now(), tag.ID, tag.UserID, s.workspaceID)
+	return err
+}
+
+// DeleteTag 删除标签，todo_tags 中的关联由外键级联删除
+func (s *Store) DeleteTag(id int, userID int) error {
+	if _, err := s.GetTag(id, userID); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec("DELETE FROM tags WHERE id = ? AND user_id = ? AND workspace_id = ?", id, userID, s.workspaceID)
+	return err
+}
+
+// AttachTag 为待办事项添加标签
+func (s *Store) AttachTag(todoID int, tagID int, userID int) error {
+	if err := s.requireTodo(todoID, userID, models.RoleEditor); err != nil {
+		return err
+	}
+	if _, err := s.GetTag(tagID, userID); err != nil {
+		return err
+	}
+
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM todo_tags WHERE todo_id = ? AND tag_id = ?", todoID, tagID).Scan(&count)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	_, err = s.db.Exec("INSERT INTO todo_tags (todo_id, tag_id) VALUES (?, ?)", todoID, tagID)
+	return err
+}
+
+// DetachTag 从待办事项上移除标签
+func (s *Store) DetachTag(todoID int, tagID int, userID int) error {
+	if err := s.requireTodo(todoID, userID, models.RoleEditor); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec("DELETE FROM todo_tags WHERE todo_id = ? AND tag_id = ?", todoID, tagID)
+	return err
+}
+
+// loadTags 批量查询并填充待办事项的标签
+func (s *Store) loadTags(todos []models.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(todos))
+	args := []interface{}{s.workspaceID}
+	index := make(map[int]int, len(todos))
+	for i, t := range todos {
+		placeholders[i] = "?"
+		args = append(args, t.ID)
+		index[t.ID] = i
+		todos[i].Tags = []models.Tag{}
+	}
+
+	rows, err := s.db.Query(
+		"SELECT tt.todo_id, g.id, g.workspace_id, g.user_id, g.name, g.color, g.created_at, g.updated_at FROM todo_tags tt JOIN tags g ON g.id = tt.tag_id WHERE g.workspace_id = ? AND tt.todo_id IN ("+strings.Join(placeholders, ", ")+") ORDER BY g.name",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID int
+		var tag models.Tag
+		if err := rows.Scan(&todoID, &tag.ID, &tag.WorkspaceID, &tag.UserID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+			return err
+		}
+		i := index[todoID]
+		todos[i].Tags = append(todos[i].Tags, tag)
+	}
+
+	return rows.Err()
+}
+
+// tagCondition 返回按标签名过滤的 SQL 条件，使用 EXISTS 子查询以保证分页总数不会因连接而重复计数
+func tagCondition(filter store.TodoFilter) (string, []interface{}) {
+	const exists = "EXISTS (SELECT 1 FROM todo_tags tt JOIN tags g ON g.id = tt.tag_id WHERE tt.todo_id = todos.id AND g.name "
+
+	var args []interface{}
+	if filter.TagMode == store.TagModeAny {
+		placeholders := make([]string, len(filter.Tags))
+		for i, name := range filter.Tags {
+			placeholders[i] = "?"
+			args = append(args, name)
+		}
+		return exists + "IN (" + strings.Join(placeholders, ", ") + "))", args
+	}
+
+	conds := make([]string, len(filter.Tags))
+	for i, name := range filter.Tags {
+		conds[i] = exists + "= ?)"
+		args = append(args, name)
+	}
+	return strings.Join(conds, " AND "), args
+}
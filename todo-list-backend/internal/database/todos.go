@@ -0,0 +1,606 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// Todo相关操作
+
+const todoColumns = "id, workspace_id, title, completed, priority, user_id, assignee_id, parent_id, project_id, due_at, start_at, remind_at, recurrence, occurrence, position, created_at, updated_at"
+
+// scanTodo 按 todoColumns 的顺序读取一行待办事项
+func scanTodo(rows *sql.Rows) (models.Todo, error) {
+	var todo models.Todo
+	var assigneeID, parentID, projectID sql.NullInt64
+	var dueAt, startAt, remindAt sql.NullTime
+	err := rows.Scan(&todo.ID, &todo.WorkspaceID, &todo.Title, &todo.Completed, &todo.Priority, &todo.UserID, &assigneeID, &parentID, &projectID,
+		&dueAt, &startAt, &remindAt, &todo.Recurrence, &todo.Occurrence, &todo.Position, &todo.CreatedAt, &todo.UpdatedAt)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	todo.AssigneeID = intPtr(assigneeID)
+	todo.ParentID = intPtr(parentID)
+	todo.ProjectID = intPtr(projectID)
+	todo.DueAt = timePtr(dueAt)
+	todo.StartAt = timePtr(startAt)
+	todo.RemindAt = timePtr(remindAt)
+	return todo, nil
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time.UTC()
+	return &v
+}
+
+func intPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	i := int(v.Int64)
+	return &i
+}
+
+// sameInt 判断两个可空整数是否相等，都为 nil 也视为相等
+func sameInt(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func nullInt(v *int) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+// nullTime 将可选时间统一转换为 UTC 后写入，保证 SQLite 中按字符串比较的结果正确
+func nullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+// likePattern 把文本转换为子串匹配的 LIKE 模式，使用 ! 作为转义符
+func likePattern(text string) string {
+	return "%" + strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(text) + "%"
+}
+
+// localTime 把时间转换为服务器时区，SQLite 按字符串比较时间，参数必须与写入时使用同一时区
+func localTime(t time.Time) time.Time {
+	return t.In(time.Local)
+}
+
+// todoWhere 根据用户和过滤条件构造 WHERE 子句及参数，只包含当前工作区中用户可以访问的项目里的和指派给用户的待办事项
+func (s *Store) todoWhere(userID int, filter store.TodoFilter) (string, []interface{}) {
+	conds := []string{"workspace_id = ?", "(project_id IN (" + accessibleProjects + ") OR (project_id IS NULL AND user_id = ?) OR assignee_id = ?)"}
+	args := []interface{}{s.workspaceID, userID, userID, userID, userID}
+
+	if filter.Completed != nil {
+		conds = append(conds, "completed = ?")
+		args = append(args, *filter.Completed)
+	}
+	if len(filter.Priorities) > 0 {
+		placeholders := make([]string, len(filter.Priorities))
+		for i, p := range filter.Priorities {
+			placeholders[i] = "?"
+			args = append(args, p)
+		}
+		conds = append(conds, "priority IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if filter.TitleContains != "" {
+		conds = append(conds, "LOWER(title) LIKE ? ESCAPE '!'")
+		args = append(args, likePattern(strings.ToLower(filter.TitleContains)))
+	}
+	if filter.CreatedAfter != nil {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, localTime(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		conds = append(conds, "created_at < ?")
+		args = append(args, localTime(*filter.CreatedBefore))
+	}
+	if filter.UpdatedAfter != nil {
+		conds = append(conds, "updated_at >= ?")
+		args = append(args, localTime(*filter.UpdatedAfter))
+	}
+	if filter.UpdatedBefore != nil {
+		conds = append(conds, "updated_at < ?")
+		args = append(args, localTime(*filter.UpdatedBefore))
+	}
+	if filter.DueAfter != nil {
+		conds = append(conds, "due_at >= ?")
+		args = append(args, filter.DueAfter.UTC())
+	}
+	if filter.DueBefore != nil {
+		conds = append(conds, "due_at < ?")
+		args = append(args, filter.DueBefore.UTC())
+	}
+	if filter.Overdue {
+		conds = append(conds, "completed = ?", "due_at < ?")
+		args = append(args, false, time.Now().UTC())
+	}
+	if filter.ProjectID != nil {
+		conds = append(conds, "project_id = ?")
+		args = append(args, *filter.ProjectID)
+	}
+	if filter.AssigneeID != nil {
+		conds = append(conds, "assignee_id = ?")
+		args = append(args, *filter.AssigneeID)
+	}
+	if len(filter.Tags) > 0 {
+		cond, tagArgs := tagCondition(filter)
+		conds = append(conds, cond)
+		args = append(args, tagArgs...)
+	}
+
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// sortColumns 排序字段对应的 SQL 表达式，ORDER BY 中只会出现这里的固定文本
+var sortColumns = map[store.SortField]string{
+	store.SortCreatedAt: "created_at",
+	store.SortUpdatedAt: "updated_at",
+	store.SortDueAt:     "due_at",
+	store.SortPriority:  "CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 ELSE 0 END",
+	store.SortTitle:     "LOWER(title)",
+	store.SortCompleted: "completed",
+	store.SortPosition:  "position",
+}
+
+// sortTerm ORDER BY 中的一项。value 是游标待办事项在该项上的取值，param 是与之比较时使用的占位符表达式
+type sortTerm struct {
+	expr  string
+	desc  bool
+	value interface{}
+	param string
+}
+
+// sortTerms 把排序键展开为 ORDER BY 项，最后按 id 倒序，cursor 提供键集比较时使用的取值
+func (s *Store) sortTerms(keys []store.SortKey, cursor models.Todo) []sortTerm {
+	if len(keys) == 0 {
+		keys = store.DefaultSort
+	}
+
+	terms := make([]sortTerm, 0, len(keys)+2)
+	for _, k := range keys {
+		term := sortTerm{expr: sortColumns[k.Field], desc: k.Desc, param: "?"}
+		switch k.Field {
+		case store.SortCreatedAt:
+			term.value = localTime(cursor.CreatedAt)
+		case store.SortUpdatedAt:
+			term.value = localTime(cursor.UpdatedAt)
+		case store.SortDueAt:
+			terms = append(terms, sortTerm{expr: "due_at IS NULL", value: cursor.DueAt == nil, param: "?"})
+			term.value = nullTime(cursor.DueAt)
+		case store.SortPriority:
+			term.value = store.PriorityRank(cursor.Priority)
+		case store.SortTitle:
+			// 标题转为小写后按字节比较，MySQL 的默认排序规则按语言排序，需要转换为二进制串
+			term.value, term.param = cursor.Title, "LOWER(?)"
+			if s.driver == DriverMySQL {
+				term.expr, term.param = "CAST("+term.expr+" AS BINARY)", "CAST(LOWER(?) AS BINARY)"
+			}
+		case store.SortCompleted:
+			term.value = cursor.Completed
+		case store.SortPosition:
+			term.value = cursor.Position
+		}
+		terms = append(terms, term)
+	}
+	return append(terms, sortTerm{expr: "id", desc: true, value: cursor.ID, param: "?"})
+}
+
+// orderBy 根据排序键构造 ORDER BY 子句，reverse 为 true 时整体反转，用于向前翻页
+func (s *Store) orderBy(keys []store.SortKey, reverse bool) string {
+	terms := s.sortTerms(keys, models.Todo{})
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = t.expr
+		if t.desc != reverse {
+			parts[i] += " DESC"
+		}
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// keysetCondition 构造“排在 cursor 之后”（reverse 为 true 时为“之前”）的条件，取值为 NULL 的项只能相等
+func (s *Store) keysetCondition(keys []store.SortKey, cursor models.Todo, reverse bool) (string, []interface{}) {
+	terms := s.sortTerms(keys, cursor)
+
+	var branches []string
+	var args []interface{}
+	var eqConds []string
+	var eqArgs []interface{}
+	for _, t := range terms {
+		if t.value != nil {
+			op := " > "
+			if t.desc != reverse {
+				op = " < "
+			}
+			branch := append(append([]string{}, eqConds...), "("+t.expr+")"+op+t.param)
+			branches = append(branches, "("+strings.Join(branch, " AND ")+")")
+			args = append(append(args, eqArgs...), t.value)
+
+			eqConds = append(eqConds, "("+t.expr+") = "+t.param)
+			eqArgs = append(eqArgs, t.value)
+		} else {
+			eqConds = append(eqConds, t.expr+" IS NULL")
+		}
+	}
+	return "(" + strings.Join(branches, " OR ") + ")", args
+}
+
+func (s *Store) queryTodos(query string, args ...interface{}) ([]models.Todo, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []models.Todo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// 先关闭结果集再查询标签和评论数，SQLite 只有一个连接
+	rows.Close()
+
+	if err := s.loadTags(todos); err != nil {
+		return nil, err
+	}
+	if err := s.loadCommentCounts(todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// GetAllTodos 获取指定用户的所有待办事项
+func (s *Store) GetAllTodos(userID int, filter store.TodoFilter) ([]models.Todo, error) {
+	where, args := s.todoWhere(userID, filter)
+	return s.queryTodos("SELECT "+todoColumns+" FROM todos"+where+s.orderBy(filter.Sort, false), args...)
+}
+
+// GetTodosWithPagination 获取指定用户的待办事项，支持分页
+func (s *Store) GetTodosWithPagination(userID int, filter store.TodoFilter, page, pageSize int) ([]models.Todo, int, error) {
+	where, args := s.todoWhere(userID, filter)
+
+	// 获取总记录数
+	var total int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM todos"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 计算偏移量
+	offset := (page - 1) * pageSize
+
+	// 查询分页数据
+	todos, err := s.queryTodos(
+		"SELECT "+todoColumns+" FROM todos"+where+s.orderBy(filter.Sort, false)+" LIMIT ? OFFSET ?",
+		append(args, pageSize, offset)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return todos, total, nil
+}
+
+// GetTodosPage 多查询一条判断是否还有更多数据，向前翻页时按相反顺序查询再反转
+func (s *Store) GetTodosPage(userID int, filter store.TodoFilter, page store.Page) ([]models.Todo, bool, error) {
+	where, args := s.todoWhere(userID, filter)
+
+	cursor, reverse := page.After, false
+	if page.Before != nil {
+		cursor, reverse = page.Before, true
+	}
+	if cursor != nil {
+		cond, condArgs := s.keysetCondition(filter.Sort, *cursor, reverse)
+		where += " AND " + cond
+		args = append(args, condArgs...)
+	}
+
+	todos, err := s.queryTodos(
+		"SELECT "+todoColumns+" FROM todos"+where+s.orderBy(filter.Sort, reverse)+" LIMIT ?",
+		append(args, page.Limit+1)...,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	more := len(todos) > page.Limit
+	if more {
+		todos = todos[:page.Limit]
+	}
+	if reverse {
+		slices.Reverse(todos)
+	}
+	return todos, more, nil
+}
+
+// GetTodo 获取单个待办事项
+func (s *Store) GetTodo(id int, userID int) (models.Todo, error) {
+	if err := s.requireTodo(id, userID, models.RoleViewer); err != nil {
+		return models.Todo{}, err
+	}
+
+	todos, err := s.queryTodos("SELECT "+todoColumns+" FROM todos WHERE id = ? AND workspace_id = ?", id, s.workspaceID)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	if len(todos) == 0 {
+		return models.Todo{}, store.ErrTodoNotFound
+	}
+	return todos[0], nil
+}
+
+// subtreeIDs 是查询某个待办事项及其全部后代ID的递归 CTE，参数为根ID和工作区ID
+const subtreeIDs = `WITH RECURSIVE subtree (id) AS (
+	SELECT id FROM todos WHERE id = ? AND workspace_id = ?
+	UNION ALL
+	SELECT t.id FROM todos t JOIN subtree st ON t.parent_id = st.id
+)`
+
+// GetSubtree 获取待办事项及其全部后代，子项总是与根节点在同一项目，因此只需检查根节点的权限
+func (s *Store) GetSubtree(id int, userID int) ([]models.Todo, error) {
+	if err := s.requireTodo(id, userID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	todos, err := s.queryTodos(
+		subtreeIDs+" SELECT "+todoColumns+" FROM todos WHERE id IN (SELECT id FROM subtree) ORDER BY CASE WHEN id = ? THEN 0 ELSE 1 END, position, id DESC",
+		id, s.workspaceID, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(todos) == 0 {
+		return nil, store.ErrTodoNotFound
+	}
+	return todos, nil
+}
+
+// GetChildren 获取待办事项的直接子项，按手动排序
+func (s *Store) GetChildren(parentID int, userID int) ([]models.Todo, error) {
+	if err := s.requireTodo(parentID, userID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.queryTodos("SELECT "+todoColumns+" FROM todos WHERE parent_id = ? AND workspace_id = ? ORDER BY position, id DESC", parentID, s.workspaceID)
+}
+
+// parentProject 验证用户可以在父待办下添加子项（editor），并返回父待办所在项目
+func (s *Store) parentProject(parentID int, userID int) (*int, error) {
+	err := s.requireTodo(parentID, userID, models.RoleEditor)
+	if errors.Is(err, store.ErrTodoNotFound) {
+		return nil, store.ErrInvalidParent
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var projectID sql.NullInt64
+	if err := s.db.QueryRow("SELECT project_id FROM todos WHERE id = ? AND workspace_id = ?", parentID, s.workspaceID).Scan(&projectID); err != nil {
+		return nil, err
+	}
+	return intPtr(projectID), nil
+}
+
+// CreateTodo 创建待办事项
+func (s *Store) CreateTodo(todo models.Todo) (int64, error) {
+	if todo.ParentID != nil {
+		// 只能在有编辑权限的待办下创建子项，子项总是与父项在同一项目
+		projectID, err := s.parentProject(*todo.ParentID, todo.UserID)
+		if err != nil {
+			return 0, err
+		}
+		todo.ProjectID = projectID
+	} else if todo.ProjectID != nil {
+		if err := s.checkProject(*todo.ProjectID, todo.UserID); err != nil {
+			return 0, err
+		}
+	}
+	if err := s.checkAssignee(todo.AssigneeID); err != nil {
+		return 0, err
+	}
+
+	// 末尾位置的计算和插入在同一事务中完成，并发创建的同级不会得到相同的位置
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	position, err := s.endPosition(tx, todo.ProjectID, todo.ParentID)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec("INSERT INTO todos (workspace_id, title, completed, priority, user_id, assignee_id, parent_id, project_id, due_at, start_at, remind_at, recurrence, occurrence, position, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		s.workspaceID, todo.Title, todo.Completed, todo.Priority, todo.UserID, nullInt(todo.AssigneeID), nullInt(todo.ParentID), nullInt(todo.ProjectID),
+		nullTime(todo.DueAt), nullTime(todo.StartAt), nullTime(todo.RemindAt),
+		todo.Recurrence, todo.Occurrence, position, time.Now(), time.Now())
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdateTodo 更新待办事项
+func (s *Store) UpdateTodo(todo models.Todo) error {
+	if err := s.requireTodo(todo.ID, todo.UserID, models.RoleEditor); err != nil {
+		assigned, aerr := s.isAssignee(todo.ID, todo.UserID)
+		if aerr != nil {
+			return aerr
+		}
+		if !assigned {
+			return err
+		}
+		// 没有编辑权限的负责人只能修改完成状态
+		stored, err := s.GetTodo(todo.ID, todo.UserID)
+		if err != nil {
+			return err
+		}
+		if !store.OnlyCompletionChanged(stored, todo) {
+			return store.ErrForbidden
+		}
+		_, err = s.db.Exec("UPDATE todos SET completed = ?, updated_at = ? WHERE id = ? AND workspace_id = ?",
+			todo.Completed, time.Now(), todo.ID, s.workspaceID)
+		return err
+	}
+	if err := s.checkAssignee(todo.AssigneeID); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec("UPDATE todos SET title = ?, completed = ?, priority = ?, assignee_id = ?, due_at = ?, start_at = ?, remind_at = ?, recurrence = ?, updated_at = ? WHERE id = ? AND workspace_id = ?",
+		todo.Title, todo.Completed, todo.Priority, nullInt(todo.AssigneeID),
+		nullTime(todo.DueAt), nullTime(todo.StartAt), nullTime(todo.RemindAt),
+		todo.Recurrence, time.Now(), todo.ID, s.workspaceID)
+	return err
+}
+
+// MoveTodo 将待办事项连同子树移动到新的父项下
+func (s *Store) MoveTodo(id int, userID int, parentID *int) error {
+	if err := s.requireTodo(id, userID, models.RoleEditor); err != nil {
+		return err
+	}
+	subtree, err := s.GetSubtree(id, userID)
+	if err != nil {
+		return err
+	}
+
+	if parentID == nil {
+		return s.moveSubtree(subtree, nil, subtree[0].ProjectID)
+	}
+
+	// 不能移动到自己的子树内，否则会形成环
+	for _, t := range subtree {
+		if t.ID == *parentID {
+			return store.ErrInvalidParent
+		}
+	}
+	projectID, err := s.parentProject(*parentID, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.moveSubtree(subtree, parentID, projectID)
+}
+
+// MoveTodoToProject 将待办事项连同子树移动到另一个项目的顶层
+func (s *Store) MoveTodoToProject(id int, userID int, projectID int) error {
+	if err := s.requireTodo(id, userID, models.RoleEditor); err != nil {
+		return err
+	}
+	subtree, err := s.GetSubtree(id, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkProject(projectID, userID); err != nil {
+		return err
+	}
+
+	return s.moveSubtree(subtree, nil, &projectID)
+}
+
+// moveSubtree 在一个事务中修改子树根节点的父项并把它排在新同级的末尾，再把整棵子树放入 projectID
+func (s *Store) moveSubtree(subtree []models.Todo, parentID *int, projectID *int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 仍在原来的同级中时保持原位
+	position := subtree[0].Position
+	if !sameInt(subtree[0].ParentID, parentID) || !sameInt(subtree[0].ProjectID, projectID) {
+		if position, err = s.endPosition(tx, projectID, parentID); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec("UPDATE todos SET parent_id = ?, position = ?, updated_at = ? WHERE id = ? AND workspace_id = ?",
+		nullInt(parentID), position, now, subtree[0].ID, s.workspaceID); err != nil {
+		return err
+	}
+
+	placeholders := make([]string, len(subtree))
+	args := []interface{}{nullInt(projectID), now, s.workspaceID}
+	for i, t := range subtree {
+		placeholders[i] = "?"
+		args = append(args, t.ID)
+	}
+	if _, err := tx.Exec("UPDATE todos SET project_id = ?, updated_at = ? WHERE workspace_id = ? AND id IN ("+strings.Join(placeholders, ", ")+")", args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteTodo 删除待办事项，其评论、附件和标签关联由外键级联删除
+func (s *Store) DeleteTodo(id int, userID int) error {
+	if err := s.requireTodo(id, userID, models.RoleEditor); err != nil {
+		return err
+	}
+
+	// 有子项时拒绝删除，需要显式使用 DeleteTodoTree
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM todos WHERE parent_id = ? AND workspace_id = ?", id, s.workspaceID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return store.ErrHasSubtasks
+	}
+
+	_, err = s.db.Exec("DELETE FROM todos WHERE id = ? AND workspace_id = ?", id, s.workspaceID)
+	return err
+}
+
+// DeleteTodoTree 删除待办事项及其全部后代，评论、附件和标签关联由外键级联删除
+func (s *Store) DeleteTodoTree(id int, userID int) error {
+	if err := s.requireTodo(id, userID, models.RoleEditor); err != nil {
+		return err
+	}
+	subtree, err := s.GetSubtree(id, userID)
+	if err != nil {
+		return err
+	}
+
+	// 一条语句删除整棵子树
+	placeholders := make([]string, len(subtree))
+	args := []interface{}{s.workspaceID}
+	for i, t := range subtree {
+		placeholders[i] = "?"
+		args = append(args, t.ID)
+	}
+
+	_, err = s.db.Exec("DELETE FROM todos WHERE workspace_id = ? AND id IN ("+strings.Join(placeholders, ", ")+")", args...)
+	return err
+}
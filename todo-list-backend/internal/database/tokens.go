@@ -0,0 +1,153 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 刷新令牌和访问令牌撤销列表相关操作。过期时间以 UTC 写入，与 due_at 一致
+
+const refreshTokenColumns = "id, user_id, workspace_id, family_id, token_hash, expires_at, used_at, revoked_at, created_at"
+
+func scanRefreshToken(row interface{ Scan(...interface{}) error }) (models.RefreshToken, error) {
+	var t models.RefreshToken
+	var usedAt, revokedAt sql.NullTime
+	err := row.Scan(&t.ID, &t.UserID, &t.WorkspaceID, &t.FamilyID, &t.TokenHash, &t.ExpiresAt, &usedAt, &revokedAt, &t.CreatedAt)
+	if err != nil {
+		return models.RefreshToken{}, err
+	}
+	t.ExpiresAt = t.ExpiresAt.UTC()
+	t.UsedAt = timePtr(usedAt)
+	t.RevokedAt = timePtr(revokedAt)
+	return t, nil
+}
+
+// CreateRefreshToken 保存刷新令牌并清理该用户已过期的刷新令牌
+func (s *Store) CreateRefreshToken(token models.RefreshToken) error {
+	now := time.Now().UTC()
+	if _, err := s.db.Exec("DELETE FROM refresh_tokens WHERE user_id = ? AND expires_at < ?", token.UserID, now); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("INSERT INTO refresh_tokens (user_id, workspace_id, family_id, token_hash, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		token.UserID, token.WorkspaceID, token.FamilyID, token.TokenHash, token.ExpiresAt.UTC(), time.Now())
+	return err
+}
+
+// RotateRefreshToken 使用刷新令牌并在同一族中保存新令牌，重复使用时撤销整个族
+func (s *Store) RotateRefreshToken(hash string, next models.RefreshToken) (models.RefreshToken, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.RefreshToken{}, err
+	}
+	defer tx.Rollback()
+
+	current, err := scanRefreshToken(tx.QueryRow("SELECT "+refreshTokenColumns+" FROM refresh_tokens WHERE token_hash = ?", hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.RefreshToken{}, store.ErrInvalidToken
+	}
+	if err != nil {
+		return models.RefreshToken{}, err
+	}
+	now := time.Now().UTC()
+	if current.RevokedAt != nil || !now.Before(current.ExpiresAt) {
+		return models.RefreshToken{}, store.ErrInvalidToken
+	}
+
+	// 条件更新保证并发请求中只有一个能使用该令牌，其余的按重用处理
+	rotated := false
+	if current.UsedAt == nil {
+		result, err := tx.Exec("UPDATE refresh_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL", now, current.ID)
+		if err != nil {
+			return models.RefreshToken{}, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return models.RefreshToken{}, err
+		}
+		rotated = n == 1
+	}
+	if !rotated {
+		if _, err := tx.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL", now, current.FamilyID); err != nil {
+			return models.RefreshToken{}, err
+		}
+		if err := tx.Commit(); err != nil {
+			return models.RefreshToken{}, err
+		}
+		return models.RefreshToken{}, store.ErrTokenReused
+	}
+
+	next.UserID, next.WorkspaceID, next.FamilyID = current.UserID, current.WorkspaceID, current.FamilyID
+	next.ExpiresAt = next.ExpiresAt.UTC()
+	next.CreatedAt = time.Now()
+	result, err := tx.Exec("INSERT INTO refresh_tokens (user_id, workspace_id, family_id, token_hash, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		next.UserID, next.WorkspaceID, next.FamilyID, next.TokenHash, next.ExpiresAt, next.CreatedAt)
+	if err != nil {
+		return models.RefreshToken{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.RefreshToken{}, err
+	}
+	next.ID = int(id)
+
+	return next, tx.Commit()
+}
+
+// RevokeTokenFamily 撤销令牌族
+func (s *Store) RevokeTokenFamily(familyID string) error {
+	_, err := s.db.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL", time.Now().UTC(), familyID)
+	return err
+}
+
+// RevokeUserSessions 撤销用户的全部刷新令牌
+func (s *Store) RevokeUserSessions(userID int) error {
+	_, err := s.db.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", time.Now().UTC(), userID)
+	return err
+}
+
+// RevokeOtherSessions 撤销用户除 keepFamilyID 之外的全部刷新令牌
+func (s *Store) RevokeOtherSessions(userID int, keepFamilyID string) error {
+	_, err := s.db.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND family_id <> ? AND revoked_at IS NULL", time.Now().UTC(), userID, keepFamilyID)
+	return err
+}
+
+// SetFamilyWorkspace 修改令牌族刷新时进入的工作区
+func (s *Store) SetFamilyWorkspace(familyID string, workspaceID int) error {
+	_, err := s.db.Exec("UPDATE refresh_tokens SET workspace_id = ? WHERE family_id = ?", workspaceID, familyID)
+	return err
+}
+
+// RevokeAccessToken 把 jti 加入撤销列表
+func (s *Store) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	if _, err := s.db.Exec("DELETE FROM revoked_tokens WHERE expires_at < ?", time.Now().UTC()); err != nil {
+		return err
+	}
+
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM revoked_tokens WHERE jti = ?", jti).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err := s.db.Exec("INSERT INTO revoked_tokens (jti, expires_at) VALUES (?, ?)", jti, expiresAt.UTC())
+	return err
+}
+
+// IsAccessTokenRevoked 判断 jti 是否在撤销列表中
+func (s *Store) IsAccessTokenRevoked(jti string) (bool, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM revoked_tokens WHERE jti = ?", jti).Scan(&count)
+	return count > 0, err
+}
+
+// IsSessionRevoked 判断令牌族中是否有已撤销的刷新令牌，撤销总是作用于整个族
+func (s *Store) IsSessionRevoked(familyID string) (bool, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM refresh_tokens WHERE family_id = ? AND revoked_at IS NOT NULL", familyID).Scan(&count)
+	return count > 0, err
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 两步验证相关操作
+
+// GetTOTP 获取用户的两步验证密钥
+func (s *Store) GetTOTP(userID int) (models.TOTP, error) {
+	var t models.TOTP
+	var enabledAt sql.NullTime
+	err := s.db.QueryRow("SELECT user_id, secret, enabled_at, last_step, created_at FROM user_totp WHERE user_id = ?", userID).
+		Scan(&t.UserID, &t.Secret, &enabledAt, &t.LastStep, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.TOTP{}, store.ErrTwoFactorNotFound
+	}
+	if err != nil {
+		return models.TOTP{}, err
+	}
+	t.EnabledAt = timePtr(enabledAt)
+	return t, nil
+}
+
+// SetTOTPSecret 保存未确认的新密钥
+func (s *Store) SetTOTPSecret(userID int, secret string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var enabledAt sql.NullTime
+	err = tx.QueryRow("SELECT enabled_at FROM user_totp WHERE user_id = ?", userID).Scan(&enabledAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		_, err = tx.Exec("INSERT INTO user_totp (user_id, secret, last_step, created_at) VALUES (?, ?, 0, ?)", userID, secret, time.Now())
+	case err != nil:
+		return err
+	case enabledAt.Valid:
+		return store.ErrTwoFactorEnabled
+	default:
+		_, err = tx.Exec("UPDATE user_totp SET secret = ?, last_step = 0, created_at = ? WHERE user_id = ?", secret, time.Now(), userID)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// EnableTOTP 确认绑定并保存恢复码
+func (s *Store) EnableTOTP(userID int, step int64, recoveryHashes []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE user_totp SET enabled_at = ?, last_step = ? WHERE user_id = ? AND enabled_at IS NULL", time.Now().UTC(), step, userID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		var count int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM user_totp WHERE user_id = ?", userID).Scan(&count); err != nil {
+			return err
+		}
+		if count == 0 {
+			return store.ErrTwoFactorNotFound
+		}
+		return store.ErrTwoFactorEnabled
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, recoveryHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DisableTOTP 删除两步验证密钥和恢复码
+func (s *Store) DisableTOTP(userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM user_totp WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseTOTPStep 记录已使用的时间步
+func (s *Store) UseTOTPStep(userID int, step int64) error {
+	result, err := s.db.Exec("UPDATE user_totp SET last_step = ? WHERE user_id = ? AND last_step < ?", step, userID, step)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrCodeReused
+	}
+	return nil
+}
+
+// UseRecoveryCode 使用恢复码
+func (s *Store) UseRecoveryCode(userID int, hash string) error {
+	result, err := s.db.Exec("UPDATE recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL", time.Now().UTC(), userID, hash)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrInvalidRecoveryCode
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes 重新生成恢复码
+func (s *Store) ReplaceRecoveryCodes(userID int, hashes []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, hashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// replaceRecoveryCodes 在事务中删除旧恢复码并插入新恢复码
+func replaceRecoveryCodes(tx *sql.Tx, userID int, hashes []string) error {
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, hash := range hashes {
+		if _, err := tx.Exec("INSERT INTO recovery_codes (user_id, code_hash, created_at) VALUES (?, ?, ?)", userID, hash, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountRecoveryCodes 统计未使用的恢复码
+func (s *Store) CountRecoveryCodes(userID int) (int, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM recovery_codes WHERE user_id = ? AND used_at IS NULL", userID).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 用户相关操作
+
+const userColumns = "id, username, email, password, timezone, created_at, updated_at, email_verified_at"
+
+// CreateUser 创建新用户
+func (s *Store) CreateUser(user models.RegisterRequest) (int64, error) {
+	// 检查邮箱是否已存在
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM users WHERE email = ?", user.Email).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, store.ErrEmailExists
+	}
+
+	// 检查用户名是否已存在
+	err = s.db.QueryRow("SELECT COUNT(*) FROM users WHERE username = ?", user.Username).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, store.ErrUsernameExists
+	}
+
+	// 哈希密码
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+
+	timezone := user.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	// 插入用户
+	result, err := s.db.Exec(
+		"INSERT INTO users (username, email, password, timezone, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		user.Username, user.Email, hashedPassword, timezone, time.Now(), time.Now(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// GetUserByEmail 通过邮箱获取用户
+func (s *Store) GetUserByEmail(email string) (models.User, error) {
+	return s.getUser("SELECT "+userColumns+" FROM users WHERE email = ?", email)
+}
+
+// GetUserByID 通过ID获取用户
+func (s *Store) GetUserByID(id int) (models.User, error) {
+	return s.getUser("SELECT "+userColumns+" FROM users WHERE id = ?", id)
+}
+
+func (s *Store) getUser(query string, args ...interface{}) (models.User, error) {
+	var user models.User
+	var verifiedAt sql.NullTime
+	err := s.db.QueryRow(query, args...).Scan(
+		&user.ID, &user.Username, &user.Email, &user.Password, &user.Timezone, &user.CreatedAt, &user.UpdatedAt, &verifiedAt,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, store.ErrUserNotFound
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	user.EmailVerifiedAt = timePtr(verifiedAt)
+
+	return user, nil
+}
+
+// UpdateUserTimezone 修改用户的时区
+func (s *Store) UpdateUserTimezone(id int, timezone string) error {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM users WHERE id = ?", id).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrUserNotFound
+	}
+
+	_, err = s.db.Exec("UPDATE users SET timezone = ?, updated_at = ? WHERE id = ?", timezone, time.Now(), id)
+	return err
+}
+
+// UpdateUserPassword 修改用户的密码
+func (s *Store) UpdateUserPassword(id int, password string) error {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM users WHERE id = ?", id).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrUserNotFound
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec("UPDATE users SET password = ?, updated_at = ? WHERE id = ?", hashedPassword, time.Now(), id)
+	return err
+}
+
+// MarkEmailVerified 记录用户已确认邮箱
+func (s *Store) MarkEmailVerified(id int) error {
+	_, err := s.db.Exec("UPDATE users SET email_verified_at = ? WHERE id = ? AND email_verified_at IS NULL", time.Now().UTC(), id)
+	return err
+}
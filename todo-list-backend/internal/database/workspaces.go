@@ -0,0 +1,235 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 工作区相关操作
+
+// workspaceRole 返回用户在工作区中的角色，不是成员时返回 ErrWorkspaceNotFound
+func (s *Store) workspaceRole(id int, userID int) (models.WorkspaceRole, error) {
+	var role models.WorkspaceRole
+	err := s.db.QueryRow("SELECT role FROM workspace_members WHERE workspace_id = ? AND user_id = ?", id, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", store.ErrWorkspaceNotFound
+	}
+	return role, err
+}
+
+// requireAdmin 检查用户是工作区的管理员
+func (s *Store) requireAdmin(id int, userID int) error {
+	role, err := s.workspaceRole(id, userID)
+	if err != nil {
+		return err
+	}
+	if role != models.WorkspaceAdmin {
+		return store.ErrForbidden
+	}
+	return nil
+}
+
+// CreateWorkspace 创建工作区，创建者成为管理员
+func (s *Store) CreateWorkspace(name string, userID int) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO workspaces (name, created_at, updated_at) VALUES (?, ?, ?)", name, time.Now(), time.Now())
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec("INSERT INTO workspace_members (workspace_id, user_id, role, created_at) VALUES (?, ?, ?, ?)",
+		id, userID, models.WorkspaceAdmin, time.Now()); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+const workspaceSelect = "SELECT w.id, w.name, m.role, w.created_at, w.updated_at FROM workspaces w JOIN workspace_members m ON m.workspace_id = w.id"
+
+func scanWorkspace(row interface{ Scan(...interface{}) error }) (models.Workspace, error) {
+	var w models.Workspace
+	err := row.Scan(&w.ID, &w.Name, &w.Role, &w.CreatedAt, &w.UpdatedAt)
+	return w, err
+}
+
+// GetWorkspaces 获取用户加入的全部工作区
+func (s *Store) GetWorkspaces(userID int) ([]models.Workspace, error) {
+	rows, err := s.db.Query(workspaceSelect+" WHERE m.user_id = ? ORDER BY m.created_at, w.id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := []models.Workspace{}
+	for rows.Next() {
+		w, err := scanWorkspace(rows)
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, w)
+	}
+
+	return workspaces, rows.Err()
+}
+
+// GetWorkspace 获取单个工作区
+func (s *Store) GetWorkspace(id int, userID int) (models.Workspace, error) {
+	w, err := scanWorkspace(s.db.QueryRow(workspaceSelect+" WHERE w.id = ? AND m.user_id = ?", id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Workspace{}, store.ErrWorkspaceNotFound
+	}
+	return w, err
+}
+
+// RenameWorkspace 修改工作区名称
+func (s *Store) RenameWorkspace(id int, name string, userID int) error {
+	if err := s.requireAdmin(id, userID); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec("UPDATE workspaces SET name = ?, updated_at = ? WHERE id = ?", name, time.Now(), id)
+	return err
+}
+
+// GetWorkspaceMembers 获取工作区成员
+func (s *Store) GetWorkspaceMembers(id int, userID int) ([]models.WorkspaceMemberInfo, error) {
+	if _, err := s.workspaceRole(id, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query("SELECT u.id, u.username, u.email, m.role, m.created_at FROM workspace_members m JOIN users u ON u.id = m.user_id WHERE m.workspace_id = ? ORDER BY m.created_at, u.id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.WorkspaceMemberInfo{}
+	for rows.Next() {
+		var m models.WorkspaceMemberInfo
+		if err := rows.Scan(&m.UserID, &m.Username, &m.Email, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
+// AddWorkspaceMember 把已注册用户加入工作区
+func (s *Store) AddWorkspaceMember(id int, email string, role models.WorkspaceRole, userID int) error {
+	if err := s.requireAdmin(id, userID); err != nil {
+		return err
+	}
+
+	user, err := s.GetUserByEmail(email)
+	if err != nil {
+		return err
+	}
+	if _, err := s.workspaceRole(id, user.ID); err == nil {
+		return store.ErrAlreadyMember
+	} else if !errors.Is(err, store.ErrWorkspaceNotFound) {
+		return err
+	}
+
+	_, err = s.db.Exec("INSERT INTO workspace_members (workspace_id, user_id, role, created_at) VALUES (?, ?, ?, ?)",
+		id, user.ID, role, time.Now())
+	return err
+}
+
+// checkLastAdmin 在移除或降级管理员 memberID 之前检查工作区是否还有其他管理员
+func (s *Store) checkLastAdmin(id int, memberID int) error {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM workspace_members WHERE workspace_id = ? AND role = ? AND user_id <> ?",
+		id, models.WorkspaceAdmin, memberID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrLastAdmin
+	}
+	return nil
+}
+
+// UpdateWorkspaceMember 修改成员角色
+func (s *Store) UpdateWorkspaceMember(id int, memberID int, role models.WorkspaceRole, userID int) error {
+	if err := s.requireAdmin(id, userID); err != nil {
+		return err
+	}
+
+	current, err := s.workspaceRole(id, memberID)
+	if errors.Is(err, store.ErrWorkspaceNotFound) {
+		return store.ErrMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if current == models.WorkspaceAdmin && role != models.WorkspaceAdmin {
+		if err := s.checkLastAdmin(id, memberID); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.db.Exec("UPDATE workspace_members SET role = ? WHERE workspace_id = ? AND user_id = ?", role, id, memberID)
+	return err
+}
+
+// RemoveWorkspaceMember 移除成员，同时撤销其在工作区项目中的成员资格、待处理的邀请，并取消指派给该成员的待办事项
+func (s *Store) RemoveWorkspaceMember(id int, memberID int, userID int) error {
+	if memberID == userID {
+		// 成员可以自己退出工作区
+		if _, err := s.workspaceRole(id, userID); err != nil {
+			return err
+		}
+	} else if err := s.requireAdmin(id, userID); err != nil {
+		return err
+	}
+
+	current, err := s.workspaceRole(id, memberID)
+	if errors.Is(err, store.ErrWorkspaceNotFound) {
+		return store.ErrMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if current == models.WorkspaceAdmin {
+		if err := s.checkLastAdmin(id, memberID); err != nil {
+			return err
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM project_members WHERE user_id = ? AND project_id IN (SELECT id FROM projects WHERE workspace_id = ?)", memberID, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM project_invitations WHERE invitee_id = ? AND status = ? AND project_id IN (SELECT id FROM projects WHERE workspace_id = ?)",
+		memberID, models.InvitationPending, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE todos SET assignee_id = NULL, updated_at = ? WHERE workspace_id = ? AND assignee_id = ?", time.Now(), id, memberID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM workspace_members WHERE workspace_id = ? AND user_id = ?", id, memberID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,33 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// File 把每封邮件写入目录中的一个 .eml 文件，供本地开发查看，不会真正发出
+type File struct {
+	dir  string
+	from string
+	seq  atomic.Int64
+}
+
+// NewFile 创建写入 dir 的发送器，目录不存在时创建
+func NewFile(dir, from string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &File{dir: dir, from: from}, nil
+}
+
+// Send 把邮件写入以时间和序号命名的文件
+func (m *File) Send(msg Message) error {
+	if err := checkHeader(msg); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%d.eml", time.Now().Format("20060102-150405.000000"), m.seq.Add(1))
+	return os.WriteFile(filepath.Join(m.dir, name), format(m.from, msg), 0o600)
+}
@@ -0,0 +1,22 @@
+package mail
+
+import "log"
+
+// Log 把邮件内容写入日志，不会真正发出。只适用于本地运行，日志中会包含邮件里的一次性令牌
+type Log struct {
+	logger *log.Logger
+}
+
+// NewLog 创建写入 logger 的发送器
+func NewLog(logger *log.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+// Send 记录邮件
+func (m *Log) Send(msg Message) error {
+	if err := checkHeader(msg); err != nil {
+		return err
+	}
+	m.logger.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
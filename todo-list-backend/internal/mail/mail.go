@@ -0,0 +1,45 @@
+// Package mail 发送验证邮件和密码重置邮件，可以使用 SMTP、日志或目录中的 .eml 文件
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Message 一封纯文本邮件
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 邮件发送接口，实现必须可以并发使用
+type Mailer interface {
+	// Send 发送邮件，发件人由实现的配置决定
+	Send(msg Message) error
+}
+
+// format 把邮件编码为 RFC 5322 格式，主题按 RFC 2047 编码以支持非 ASCII 字符
+func format(from string, msg Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// checkHeader 拒绝包含换行的收件人和主题，防止注入额外的邮件头
+func checkHeader(msg Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("mail header contains a line break")
+	}
+	return nil
+}
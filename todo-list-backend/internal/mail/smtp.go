@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+)
+
+// SMTP 通过 SMTP 服务器发送邮件，支持 STARTTLS 和 PLAIN 认证
+type SMTP struct {
+	addr     string
+	from     string // 邮件头中的发件人，可以带显示名称
+	envelope string // SMTP 会话中的发件地址
+	auth     smtp.Auth
+}
+
+// NewSMTP 创建 SMTP 发送器，username 为空时不认证；from 不是有效的邮件地址时返回错误
+func NewSMTP(host string, port int, username, password, from string) (*SMTP, error) {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, err
+	}
+	m := &SMTP{addr: net.JoinHostPort(host, strconv.Itoa(port)), from: from, envelope: addr.Address}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m, nil
+}
+
+// Send 发送邮件
+func (m *SMTP) Send(msg Message) error {
+	if err := checkHeader(msg); err != nil {
+		return err
+	}
+	return smtp.SendMail(m.addr, m.auth, m.envelope, []string{msg.To}, format(m.from, msg))
+}
@@ -0,0 +1,136 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 附件相关操作
+
+// GetAttachments 获取待办事项的全部附件
+func (s *Store) GetAttachments(todoID int, userID int) ([]models.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, err := s.accessTodo(todoID, userID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	attachments := []models.Attachment{}
+	for _, a := range s.attachs[todoID] {
+		attachments = append(attachments, a)
+	}
+	sort.Slice(attachments, func(i, j int) bool {
+		if attachments[i].CreatedAt.Equal(attachments[j].CreatedAt) {
+			return attachments[i].ID < attachments[j].ID
+		}
+		return attachments[i].CreatedAt.Before(attachments[j].CreatedAt)
+	})
+	return attachments, nil
+}
+
+// GetAttachment 获取单个附件
+func (s *Store) GetAttachment(id int, todoID int, userID int) (models.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, err := s.accessTodo(todoID, userID, models.RoleViewer); err != nil {
+		return models.Attachment{}, err
+	}
+	a, ok := s.attachs[todoID][id]
+	if !ok {
+		return models.Attachment{}, store.ErrAttachmentNotFound
+	}
+	return a, nil
+}
+
+// CreateAttachment 保存附件元数据
+func (s *Store) CreateAttachment(attachment models.Attachment) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.accessTodo(attachment.TodoID, attachment.UserID, models.RoleEditor); err != nil {
+		return 0, err
+	}
+
+	attachment.ID = s.nextAttID
+	s.nextAttID++
+	attachment.CreatedAt = time.Now()
+	if s.attachs[attachment.TodoID] == nil {
+		s.attachs[attachment.TodoID] = make(map[int]models.Attachment)
+	}
+	s.attachs[attachment.TodoID][attachment.ID] = attachment
+
+	return int64(attachment.ID), nil
+}
+
+// DeleteAttachment 删除附件
+func (s *Store) DeleteAttachment(id int, todoID int, userID int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.accessTodo(todoID, userID, models.RoleViewer); err != nil {
+		return "", err
+	}
+	current, ok := s.attachs[todoID][id]
+	if !ok {
+		return "", store.ErrAttachmentNotFound
+	}
+	if current.UserID != userID {
+		// 不是上传者时需要能修改该待办事项
+		if _, err := s.accessTodo(todoID, userID, models.RoleEditor); err != nil {
+			return "", err
+		}
+	}
+
+	delete(s.attachs[todoID], id)
+	return current.Hash, nil
+}
+
+// GetTreeAttachmentHashes 获取子树中附件引用的内容摘要
+func (s *Store) GetTreeAttachmentHashes(todoID int, userID int) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subtree, err := s.subtree(todoID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := []string{}
+	seen := make(map[string]bool)
+	for _, t := range subtree {
+		for _, a := range s.attachs[t.ID] {
+			if !seen[a.Hash] {
+				seen[a.Hash] = true
+				hashes = append(hashes, a.Hash)
+			}
+		}
+	}
+	return hashes, nil
+}
+
+// UnreferencedHashes 筛选出已没有附件引用的内容摘要
+func (s *Store) UnreferencedHashes(hashes []string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	inUse := make(map[string]bool)
+	for _, attachments := range s.attachs {
+		for _, a := range attachments {
+			inUse[a.Hash] = true
+		}
+	}
+
+	var unreferenced []string
+	for _, h := range hashes {
+		if !inUse[h] {
+			unreferenced = append(unreferenced, h)
+			inUse[h] = true
+		}
+	}
+	return unreferenced, nil
+}
@@ -0,0 +1,193 @@
+package memstore
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 评论相关操作
+
+// comment 获取待办事项下的单条评论并填充作者用户名；调用方需持有读锁并先检查待办事项的权限
+func (s *Store) comment(id int, todoID int) (models.Comment, error) {
+	c, ok := s.comments[todoID][id]
+	if !ok {
+		return models.Comment{}, store.ErrCommentNotFound
+	}
+	c.Username = s.users[c.UserID].Username
+	return c, nil
+}
+
+// GetComments 获取待办事项的全部评论
+func (s *Store) GetComments(todoID int, userID int) ([]models.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, err := s.accessTodo(todoID, userID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	comments := []models.Comment{}
+	for id := range s.comments[todoID] {
+		c, _ := s.comment(id, todoID)
+		comments = append(comments, c)
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].ID < comments[j].ID
+		}
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	return comments, nil
+}
+
+// CreateComment 发表评论
+func (s *Store) CreateComment(comment models.Comment) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.accessTodo(comment.TodoID, comment.UserID, models.RoleViewer); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	comment.ID = s.nextCmtID
+	s.nextCmtID++
+	comment.Username = ""
+	comment.CreatedAt = now
+	comment.UpdatedAt = now
+	if s.comments[comment.TodoID] == nil {
+		s.comments[comment.TodoID] = make(map[int]models.Comment)
+	}
+	s.comments[comment.TodoID][comment.ID] = comment
+
+	return int64(comment.ID), nil
+}
+
+// UpdateComment 修改评论正文
+func (s *Store) UpdateComment(comment models.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.accessTodo(comment.TodoID, comment.UserID, models.RoleViewer); err != nil {
+		return err
+	}
+	current, ok := s.comments[comment.TodoID][comment.ID]
+	if !ok {
+		return store.ErrCommentNotFound
+	}
+	if current.UserID != comment.UserID {
+		return store.ErrForbidden
+	}
+
+	current.Body = comment.Body
+	current.UpdatedAt = time.Now()
+	s.comments[comment.TodoID][comment.ID] = current
+	return nil
+}
+
+// DeleteComment 删除评论
+func (s *Store) DeleteComment(id int, todoID int, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.accessTodo(todoID, userID, models.RoleViewer); err != nil {
+		return err
+	}
+	current, ok := s.comments[todoID][id]
+	if !ok {
+		return store.ErrCommentNotFound
+	}
+	if current.UserID != userID {
+		// 不是作者时需要能修改该待办事项
+		if _, err := s.accessTodo(todoID, userID, models.RoleEditor); err != nil {
+			return err
+		}
+	}
+
+	delete(s.comments[todoID], id)
+	return nil
+}
+
+// containsAny 判断小写后的 text 是否包含任一 term
+func containsAny(text string, terms []string) bool {
+	text = strings.ToLower(text)
+	for _, term := range terms {
+		if strings.Contains(text, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchTodos 按子串粗筛搜索候选
+func (s *Store) SearchTodos(userID int, terms []string, beforeID int, limit int) ([]models.Todo, map[int][]models.Comment, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	todos := []models.Todo{}
+	comments := make(map[int][]models.Comment)
+	for _, t := range s.userTodos(userID, store.TodoFilter{}) {
+		if beforeID > 0 && t.ID >= beforeID {
+			continue
+		}
+		var matched []models.Comment
+		for id, c := range s.comments[t.ID] {
+			if containsAny(c.Body, terms) {
+				c, _ = s.comment(id, t.ID)
+				matched = append(matched, c)
+			}
+		}
+
+		ok := true
+		for _, term := range terms {
+			if strings.Contains(strings.ToLower(t.Title), term) {
+				continue
+			}
+			found := false
+			for _, c := range matched {
+				if strings.Contains(strings.ToLower(c.Body), term) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		sort.Slice(matched, func(i, j int) bool {
+			if matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+				return matched[i].ID < matched[j].ID
+			}
+			return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+		})
+		todos = append(todos, t)
+		if len(matched) > 0 {
+			comments[t.ID] = matched
+		}
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		return todos[i].ID > todos[j].ID
+	})
+	more := len(todos) > limit
+	if more {
+		todos = todos[:limit]
+		kept := make(map[int][]models.Comment, len(todos))
+		for _, t := range todos {
+			if c, ok := comments[t.ID]; ok {
+				kept[t.ID] = c
+			}
+		}
+		comments = kept
+	}
+	return todos, comments, more, nil
+}
@@ -0,0 +1,46 @@
+package memstore
+
+import (
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 邮件一次性令牌相关操作
+
+// CreateEmailToken 保存令牌并作废同一用途的旧令牌
+func (s *Store) CreateEmailToken(token models.EmailToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for hash, t := range s.mailTokens {
+		if (t.UserID == token.UserID && t.Purpose == token.Purpose) || t.ExpiresAt.Before(now) {
+			delete(s.mailTokens, hash)
+		}
+	}
+
+	token.ID = s.nextMailID
+	s.nextMailID++
+	token.ExpiresAt = token.ExpiresAt.UTC()
+	token.UsedAt = nil
+	token.CreatedAt = now
+	s.mailTokens[token.TokenHash] = token
+	return nil
+}
+
+// ConsumeEmailToken 使用令牌
+func (s *Store) ConsumeEmailToken(hash string, purpose models.TokenPurpose) (models.EmailToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.mailTokens[hash]
+	now := time.Now().UTC()
+	if !ok || t.Purpose != purpose || t.UsedAt != nil || !now.Before(t.ExpiresAt) {
+		return models.EmailToken{}, store.ErrInvalidEmailToken
+	}
+	t.UsedAt = &now
+	s.mailTokens[hash] = t
+	return t, nil
+}
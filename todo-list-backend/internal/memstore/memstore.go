@@ -0,0 +1,546 @@
+package memstore
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// state 所有工作区视图共享的数据和锁
+type state struct {
+	mu         sync.RWMutex
+	users      map[int]models.User
+	todos      map[int]models.Todo
+	tags       map[int]models.Tag
+	todoTags   map[int]map[int]bool              // todoID -> tagID 集合
+	comments   map[int]map[int]models.Comment    // todoID -> commentID -> 评论
+	attachs    map[int]map[int]models.Attachment // todoID -> attachmentID -> 附件
+	projects   map[int]models.Project
+	members    map[int]map[int]models.Member // projectID -> userID -> 成员
+	invites    map[int]models.Invitation
+	workspaces map[int]models.Workspace
+	wsMembers  map[int]map[int]models.WorkspaceMemberInfo // workspaceID -> userID -> 成员
+	smartLists map[int]models.SmartList
+	refresh    map[string]models.RefreshToken // 摘要 -> 刷新令牌
+	revoked    map[string]time.Time           // jti -> 过期时间
+	patokens   map[int]models.PersonalAccessToken
+	mailTokens map[string]models.EmailToken // 摘要 -> 邮件令牌
+	totp       map[int]models.TOTP          // userID -> 两步验证密钥
+	recovery   map[int]map[string]bool      // userID -> 恢复码摘要 -> 是否已使用
+	nextUserID int
+	nextTodoID int
+	nextTagID  int
+	nextCmtID  int
+	nextAttID  int
+	nextProjID int
+	nextInvID  int
+	nextWsID   int
+	nextListID int
+	nextRefID  int
+	nextPATID  int
+	nextMailID int
+}
+
+// Store 进程内的 store.Store 实现，workspaceID 不为 0 时是 Workspace 返回的视图
+type Store struct {
+	*state
+	workspaceID int
+}
+
+// 编译期检查 Store 是否实现了 store.Store
+var _ store.Store = (*Store)(nil)
+
+// New 创建一个空的内存存储
+func New() *Store {
+	return &Store{state: &state{
+		users:      make(map[int]models.User),
+		todos:      make(map[int]models.Todo),
+		tags:       make(map[int]models.Tag),
+		todoTags:   make(map[int]map[int]bool),
+		comments:   make(map[int]map[int]models.Comment),
+		attachs:    make(map[int]map[int]models.Attachment),
+		projects:   make(map[int]models.Project),
+		members:    make(map[int]map[int]models.Member),
+		invites:    make(map[int]models.Invitation),
+		workspaces: make(map[int]models.Workspace),
+		wsMembers:  make(map[int]map[int]models.WorkspaceMemberInfo),
+		smartLists: make(map[int]models.SmartList),
+		refresh:    make(map[string]models.RefreshToken),
+		revoked:    make(map[string]time.Time),
+		patokens:   make(map[int]models.PersonalAccessToken),
+		mailTokens: make(map[string]models.EmailToken),
+		totp:       make(map[int]models.TOTP),
+		recovery:   make(map[int]map[string]bool),
+		nextUserID: 1,
+		nextTodoID: 1,
+		nextTagID:  1,
+		nextCmtID:  1,
+		nextAttID:  1,
+		nextProjID: 1,
+		nextInvID:  1,
+		nextWsID:   1,
+		nextListID: 1,
+		nextRefID:  1,
+		nextPATID:  1,
+		nextMailID: 1,
+	}}
+}
+
+// Workspace 返回限定在 workspaceID 内的视图，与 s 共享数据
+func (s *Store) Workspace(workspaceID int) store.TenantStore {
+	return &Store{state: s.state, workspaceID: workspaceID}
+}
+
+// 用户相关操作
+
+// CreateUser 创建新用户
+func (s *Store) CreateUser(user models.RegisterRequest) (int64, error) {
+	// 在加锁之前完成耗时的密码哈希
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Email == user.Email {
+			return 0, store.ErrEmailExists
+		}
+	}
+	for _, u := range s.users {
+		if u.Username == user.Username {
+			return 0, store.ErrUsernameExists
+		}
+	}
+
+	timezone := user.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	now := time.Now()
+	id := s.nextUserID
+	s.nextUserID++
+	s.users[id] = models.User{
+		ID:        id,
+		Username:  user.Username,
+		Email:     user.Email,
+		Password:  string(hashedPassword),
+		Timezone:  timezone,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	return int64(id), nil
+}
+
+// GetUserByEmail 通过邮箱获取用户
+func (s *Store) GetUserByEmail(email string) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return models.User{}, store.ErrUserNotFound
+}
+
+// GetUserByID 通过ID获取用户
+func (s *Store) GetUserByID(id int) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return models.User{}, store.ErrUserNotFound
+	}
+	return u, nil
+}
+
+// UpdateUserTimezone 修改用户的时区
+func (s *Store) UpdateUserTimezone(id int, timezone string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return store.ErrUserNotFound
+	}
+	u.Timezone = timezone
+	u.UpdatedAt = time.Now()
+	s.users[id] = u
+	return nil
+}
+
+// UpdateUserPassword 修改用户的密码
+func (s *Store) UpdateUserPassword(id int, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return store.ErrUserNotFound
+	}
+	u.Password = string(hashedPassword)
+	u.UpdatedAt = time.Now()
+	s.users[id] = u
+	return nil
+}
+
+// MarkEmailVerified 记录用户已确认邮箱
+func (s *Store) MarkEmailVerified(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok || u.EmailVerifiedAt != nil {
+		return nil
+	}
+	now := time.Now().UTC()
+	u.EmailVerifiedAt = &now
+	s.users[id] = u
+	return nil
+}
+
+// Todo相关操作
+
+// userTodos 返回当前工作区中指定用户可以访问且满足过滤条件的待办事项，按 filter.Sort 排序；调用方需持有读锁
+func (s *Store) userTodos(userID int, filter store.TodoFilter) []models.Todo {
+	now := time.Now()
+	todos := []models.Todo{}
+	for _, t := range s.todos {
+		if t.WorkspaceID != s.workspaceID {
+			continue
+		}
+		if _, ok := s.todoRole(t, userID); !ok && !assignedTo(t, userID) {
+			continue
+		}
+		if t = s.withDetails(t); filter.Match(t, now) {
+			todos = append(todos, t)
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool {
+		return store.LessTodo(todos[i], todos[j], filter.Sort)
+	})
+	return todos
+}
+
+// GetAllTodos 获取指定用户的所有待办事项
+func (s *Store) GetAllTodos(userID int, filter store.TodoFilter) ([]models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.userTodos(userID, filter), nil
+}
+
+// GetTodosWithPagination 获取指定用户的待办事项，支持分页
+func (s *Store) GetTodosWithPagination(userID int, filter store.TodoFilter, page, pageSize int) ([]models.Todo, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	todos := s.userTodos(userID, filter)
+	total := len(todos)
+
+	offset := (page - 1) * pageSize
+	if offset >= total {
+		return []models.Todo{}, total, nil
+	}
+	end := offset + pageSize
+	if end > total {
+		end = total
+	}
+
+	return todos[offset:end], total, nil
+}
+
+// GetTodosPage 键集分页获取待办事项：保留排在游标之后（或之前）的数据，再从靠近游标的一端截取
+func (s *Store) GetTodosPage(userID int, filter store.TodoFilter, page store.Page) ([]models.Todo, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	todos := []models.Todo{}
+	for _, t := range s.userTodos(userID, filter) {
+		switch {
+		case page.Before != nil:
+			if store.LessTodo(t, *page.Before, filter.Sort) {
+				todos = append(todos, t)
+			}
+		case page.After != nil:
+			if store.LessTodo(*page.After, t, filter.Sort) {
+				todos = append(todos, t)
+			}
+		default:
+			todos = append(todos, t)
+		}
+	}
+
+	if len(todos) <= page.Limit {
+		return todos, false, nil
+	}
+	if page.Before != nil {
+		return todos[len(todos)-page.Limit:], true, nil
+	}
+	return todos[:page.Limit], true, nil
+}
+
+// GetTodo 获取单个待办事项
+func (s *Store) GetTodo(id int, userID int) (models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	todo, err := s.accessTodo(id, userID, models.RoleViewer)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	return s.withDetails(todo), nil
+}
+
+// subtree 返回以 id 为根的子树（根节点在前），子项总是与根节点在同一项目，因此只检查根节点的权限；调用方需持有读锁
+func (s *Store) subtree(id int, userID int) ([]models.Todo, error) {
+	root, err := s.accessTodo(id, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []models.Todo{s.withDetails(root)}
+	for i := 0; i < len(result); i++ {
+		result = append(result, s.children(result[i].ID)...)
+	}
+	return result, nil
+}
+
+// children 返回直接子项，按手动排序；调用方需持有读锁
+func (s *Store) children(parentID int) []models.Todo {
+	children := []models.Todo{}
+	for _, t := range s.todos {
+		if t.ParentID != nil && *t.ParentID == parentID && t.WorkspaceID == s.workspaceID {
+			children = append(children, s.withDetails(t))
+		}
+	}
+	sortByPosition(children)
+	return children
+}
+
+// GetSubtree 获取待办事项及其全部后代
+func (s *Store) GetSubtree(id int, userID int) ([]models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.subtree(id, userID)
+}
+
+// GetChildren 获取待办事项的直接子项
+func (s *Store) GetChildren(parentID int, userID int) ([]models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, err := s.accessTodo(parentID, userID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.children(parentID), nil
+}
+
+// parent 验证用户可以在父待办下添加子项（editor）并返回父待办；调用方需持有锁
+func (s *Store) parent(parentID int, userID int) (models.Todo, error) {
+	parent, err := s.accessTodo(parentID, userID, models.RoleEditor)
+	if errors.Is(err, store.ErrTodoNotFound) {
+		return models.Todo{}, store.ErrInvalidParent
+	}
+	return parent, err
+}
+
+// CreateTodo 创建待办事项
+func (s *Store) CreateTodo(todo models.Todo) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if todo.ParentID != nil {
+		// 子项总是与父项在同一项目
+		parent, err := s.parent(*todo.ParentID, todo.UserID)
+		if err != nil {
+			return 0, err
+		}
+		todo.ProjectID = parent.ProjectID
+	} else if todo.ProjectID != nil {
+		if err := s.checkProject(*todo.ProjectID, todo.UserID); err != nil {
+			return 0, err
+		}
+	}
+	if err := s.checkAssignee(todo.AssigneeID); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	todo.Tags = nil
+	todo.ID = s.nextTodoID
+	todo.WorkspaceID = s.workspaceID
+	todo.Position = s.endPosition(todo.ProjectID, todo.ParentID)
+	todo.CreatedAt = now
+	todo.UpdatedAt = now
+	s.nextTodoID++
+	s.todos[todo.ID] = todo
+
+	return int64(todo.ID), nil
+}
+
+// UpdateTodo 更新待办事项
+func (s *Store) UpdateTodo(todo models.Todo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.accessTodo(todo.ID, todo.UserID, models.RoleEditor)
+	if err != nil {
+		if !assignedTo(s.todos[todo.ID], todo.UserID) || s.todos[todo.ID].WorkspaceID != s.workspaceID {
+			return err
+		}
+		// 没有编辑权限的负责人只能修改完成状态
+		existing = s.todos[todo.ID]
+		if !store.OnlyCompletionChanged(existing, todo) {
+			return store.ErrForbidden
+		}
+		existing.Completed = todo.Completed
+		existing.UpdatedAt = time.Now()
+		s.todos[todo.ID] = existing
+		return nil
+	}
+	if err := s.checkAssignee(todo.AssigneeID); err != nil {
+		return err
+	}
+
+	existing.Title = todo.Title
+	existing.Completed = todo.Completed
+	existing.Priority = todo.Priority
+	existing.AssigneeID = todo.AssigneeID
+	existing.DueAt = todo.DueAt
+	existing.StartAt = todo.StartAt
+	existing.RemindAt = todo.RemindAt
+	existing.Recurrence = todo.Recurrence
+	existing.UpdatedAt = time.Now()
+	s.todos[todo.ID] = existing
+
+	return nil
+}
+
+// MoveTodo 将待办事项连同子树移动到新的父项下
+func (s *Store) MoveTodo(id int, userID int, parentID *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.accessTodo(id, userID, models.RoleEditor); err != nil {
+		return err
+	}
+	subtree, err := s.subtree(id, userID)
+	if err != nil {
+		return err
+	}
+
+	projectID := s.todos[id].ProjectID
+	if parentID != nil {
+		// 不能移动到自己的子树内，否则会形成环
+		for _, t := range subtree {
+			if t.ID == *parentID {
+				return store.ErrInvalidParent
+			}
+		}
+		parent, err := s.parent(*parentID, userID)
+		if err != nil {
+			return err
+		}
+		projectID = parent.ProjectID
+	}
+
+	s.moveSubtree(subtree, parentID, projectID)
+	return nil
+}
+
+// MoveTodoToProject 将待办事项连同子树移动到另一个项目的顶层
+func (s *Store) MoveTodoToProject(id int, userID int, projectID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.accessTodo(id, userID, models.RoleEditor); err != nil {
+		return err
+	}
+	subtree, err := s.subtree(id, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkProject(projectID, userID); err != nil {
+		return err
+	}
+
+	s.moveSubtree(subtree, nil, &projectID)
+	return nil
+}
+
+// moveSubtree 修改子树根节点的父项并把它排在新同级的末尾，再把整棵子树放入 projectID；调用方需持有写锁
+func (s *Store) moveSubtree(subtree []models.Todo, parentID *int, projectID *int) {
+	now := time.Now()
+	for i, t := range subtree {
+		todo := s.todos[t.ID]
+		if i == 0 && (!sameInt(todo.ParentID, parentID) || !sameInt(todo.ProjectID, projectID)) {
+			// 仍在原来的同级中时保持原位
+			todo.Position = s.endPosition(projectID, parentID)
+		}
+		if i == 0 {
+			todo.ParentID = parentID
+		}
+		todo.ProjectID = projectID
+		todo.UpdatedAt = now
+		s.todos[t.ID] = todo
+	}
+}
+
+// DeleteTodo 删除待办事项
+func (s *Store) DeleteTodo(id int, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.accessTodo(id, userID, models.RoleEditor); err != nil {
+		return err
+	}
+	if len(s.children(id)) > 0 {
+		return store.ErrHasSubtasks
+	}
+
+	delete(s.todos, id)
+	delete(s.todoTags, id)
+	delete(s.comments, id)
+	delete(s.attachs, id)
+	return nil
+}
+
+// DeleteTodoTree 删除待办事项及其全部后代
+func (s *Store) DeleteTodoTree(id int, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.accessTodo(id, userID, models.RoleEditor); err != nil {
+		return err
+	}
+	subtree, err := s.subtree(id, userID)
+	if err != nil {
+		return err
+	}
+	for _, t := range subtree {
+		delete(s.todos, t.ID)
+		delete(s.todoTags, t.ID)
+		delete(s.comments, t.ID)
+		delete(s.attachs, t.ID)
+	}
+	return nil
+}
@@ -0,0 +1,389 @@
+package memstore_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+	"github.com/joy_project/todo-list-backend/internal/storetest"
+)
+
+// 以下用例在内存存储和 SQLite 上各运行一次，两者必须返回相同的错误
+
+func createUser(t *testing.T, st store.Store, username, email string) int {
+	t.Helper()
+	id, err := st.CreateUser(models.RegisterRequest{Username: username, Email: email, Password: "password123"})
+	if err != nil {
+		t.Fatalf("CreateUser(%q): %v", username, err)
+	}
+	return int(id)
+}
+
+func TestCreateUserDuplicates(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		createUser(t, st, "alice", "alice@example.com")
+
+		tests := []struct {
+			name     string
+			username string
+			email    string
+			want     error
+		}{
+			{"duplicate email", "alice2", "alice@example.com", store.ErrEmailExists},
+			{"duplicate username", "alice", "other@example.com", store.ErrUsernameExists},
+			{"both duplicated reports email first", "alice", "alice@example.com", store.ErrEmailExists},
+		}
+		for _, tt := range tests {
+			_, err := st.CreateUser(models.RegisterRequest{Username: tt.username, Email: tt.email, Password: "password123"})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("%s: CreateUser error = %v, want %v", tt.name, err, tt.want)
+			}
+		}
+
+		if _, err := st.GetUserByEmail("nobody@example.com"); !errors.Is(err, store.ErrUserNotFound) {
+			t.Errorf("GetUserByEmail(unknown) error = %v, want %v", err, store.ErrUserNotFound)
+		}
+	})
+}
+
+func TestUpdateDeleteTodoAccess(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		alice := createUser(t, st, "alice", "alice@example.com")
+		bob := createUser(t, st, "bob", "bob@example.com")
+
+		wsID, err := st.CreateWorkspace("Shared", alice)
+		if err != nil {
+			t.Fatalf("CreateWorkspace: %v", err)
+		}
+		if err := st.AddWorkspaceMember(int(wsID), "bob@example.com", models.WorkspaceMember, alice); err != nil {
+			t.Fatalf("AddWorkspaceMember: %v", err)
+		}
+		otherWS, err := st.CreateWorkspace("Bob's", bob)
+		if err != nil {
+			t.Fatalf("CreateWorkspace: %v", err)
+		}
+
+		ws := st.Workspace(int(wsID))
+		id, err := ws.CreateTodo(models.Todo{Title: "private", Priority: "medium", UserID: alice})
+		if err != nil {
+			t.Fatalf("CreateTodo: %v", err)
+		}
+		todoID := int(id)
+
+		tests := []struct {
+			name   string
+			tenant store.TenantStore
+			id     int
+			userID int
+		}{
+			{"missing todo", ws, 9999, alice},
+			{"todo of another member", ws, todoID, bob},
+			{"todo of another workspace", st.Workspace(int(otherWS)), todoID, alice},
+		}
+		for _, tt := range tests {
+			err := tt.tenant.UpdateTodo(models.Todo{ID: tt.id, UserID: tt.userID, Title: "changed", Priority: "medium"})
+			if !errors.Is(err, store.ErrTodoNotFound) {
+				t.Errorf("%s: UpdateTodo error = %v, want %v", tt.name, err, store.ErrTodoNotFound)
+			}
+			if err := tt.tenant.DeleteTodo(tt.id, tt.userID); !errors.Is(err, store.ErrTodoNotFound) {
+				t.Errorf("%s: DeleteTodo error = %v, want %v", tt.name, err, store.ErrTodoNotFound)
+			}
+		}
+
+		// 失败的请求不能改动数据，创建者自己仍然可以修改和删除
+		todo, err := ws.GetTodo(todoID, alice)
+		if err != nil {
+			t.Fatalf("GetTodo: %v", err)
+		}
+		if todo.Title != "private" {
+			t.Errorf("title = %q after rejected updates, want %q", todo.Title, "private")
+		}
+		if err := ws.UpdateTodo(models.Todo{ID: todoID, UserID: alice, Title: "renamed", Priority: "medium"}); err != nil {
+			t.Errorf("owner UpdateTodo: %v", err)
+		}
+		if err := ws.DeleteTodo(todoID, alice); err != nil {
+			t.Errorf("owner DeleteTodo: %v", err)
+		}
+		if _, err := ws.GetTodo(todoID, alice); !errors.Is(err, store.ErrTodoNotFound) {
+			t.Errorf("GetTodo after delete error = %v, want %v", err, store.ErrTodoNotFound)
+		}
+	})
+}
+
+func TestAssigneeUpdate(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		alice := createUser(t, st, "alice", "alice@example.com")
+		bob := createUser(t, st, "bob", "bob@example.com")
+
+		wsID, err := st.CreateWorkspace("Shared", alice)
+		if err != nil {
+			t.Fatalf("CreateWorkspace: %v", err)
+		}
+		if err := st.AddWorkspaceMember(int(wsID), "bob@example.com", models.WorkspaceMember, alice); err != nil {
+			t.Fatalf("AddWorkspaceMember: %v", err)
+		}
+		ws := st.Workspace(int(wsID))
+
+		// 待办事项在 alice 的收件箱中，bob 不是项目成员，只是负责人
+		due := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+		id, err := ws.CreateTodo(models.Todo{Title: "assigned", Priority: "medium", UserID: alice, AssigneeID: &bob, DueAt: &due})
+		if err != nil {
+			t.Fatalf("CreateTodo: %v", err)
+		}
+		stored, err := ws.GetTodo(int(id), bob)
+		if err != nil {
+			t.Fatalf("GetTodo as assignee: %v", err)
+		}
+
+		later := due.Add(24 * time.Hour)
+		tests := []struct {
+			name   string
+			modify func(*models.Todo)
+		}{
+			{"title", func(td *models.Todo) { td.Title = "renamed" }},
+			{"priority", func(td *models.Todo) { td.Priority = "high" }},
+			{"due date", func(td *models.Todo) { td.DueAt = &later }},
+			{"cleared due date", func(td *models.Todo) { td.DueAt = nil }},
+			{"assignee", func(td *models.Todo) { td.AssigneeID = &alice }},
+			{"recurrence", func(td *models.Todo) { td.Recurrence = "FREQ=DAILY" }},
+		}
+		for _, tt := range tests {
+			todo := stored
+			todo.UserID = bob
+			todo.Completed = true
+			tt.modify(&todo)
+			if err := ws.UpdateTodo(todo); !errors.Is(err, store.ErrForbidden) {
+				t.Errorf("%s: UpdateTodo error = %v, want %v", tt.name, err, store.ErrForbidden)
+			}
+		}
+
+		got, err := ws.GetTodo(int(id), bob)
+		if err != nil {
+			t.Fatalf("GetTodo: %v", err)
+		}
+		if got.Completed || got.Title != "assigned" || got.Priority != "medium" || got.DueAt == nil || !got.DueAt.Equal(due) {
+			t.Errorf("todo changed by rejected updates: %+v", got)
+		}
+
+		// 其余字段不变时负责人可以修改完成状态
+		todo := stored
+		todo.UserID = bob
+		todo.Completed = true
+		if err := ws.UpdateTodo(todo); err != nil {
+			t.Fatalf("assignee completing todo: %v", err)
+		}
+		if got, err := ws.GetTodo(int(id), bob); err != nil || !got.Completed {
+			t.Errorf("after completing: completed = %v, err = %v", got.Completed, err)
+		}
+	})
+}
+
+func TestTitleSortParity(t *testing.T) {
+	titles := []string{"banana", "Apple", "apple", "Éclair", "eclair", "zebra", "Zürich", "über", "Ångström", "中文", "Émile", "ébène"}
+	// 转为小写后按码点排序，小写相同的按 ID 倒序；只转换 ASCII 字母时 "Éclair" 会排在 "ébène" 之前
+	want := []string{"apple", "Apple", "banana", "eclair", "zebra", "Zürich", "Ångström", "ébène", "Éclair", "Émile", "über", "中文"}
+
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		alice := createUser(t, st, "alice", "alice@example.com")
+		wsID, err := st.CreateWorkspace("Personal", alice)
+		if err != nil {
+			t.Fatalf("CreateWorkspace: %v", err)
+		}
+		ws := st.Workspace(int(wsID))
+		for _, title := range titles {
+			if _, err := ws.CreateTodo(models.Todo{Title: title, Priority: "medium", UserID: alice}); err != nil {
+				t.Fatalf("CreateTodo(%q): %v", title, err)
+			}
+		}
+
+		for _, desc := range []bool{false, true} {
+			expected := append([]string(nil), want...)
+			if desc {
+				// 倒序时小写相同的仍按 ID 倒序
+				expected = []string{"中文", "über", "Émile", "Éclair", "ébène", "Ångström", "Zürich", "zebra", "eclair", "banana", "apple", "Apple"}
+			}
+			filter := store.TodoFilter{Sort: []store.SortKey{{Field: store.SortTitle, Desc: desc}}}
+
+			todos, err := ws.GetAllTodos(alice, filter)
+			if err != nil {
+				t.Fatalf("GetAllTodos: %v", err)
+			}
+			if got := todoTitles(todos); !equalStrings(got, expected) {
+				t.Errorf("desc=%v: GetAllTodos order = %q, want %q", desc, got, expected)
+			}
+
+			// 游标翻页的结果与一次性读取的顺序相同，不跳过也不重复
+			var paged []models.Todo
+			page := store.Page{Limit: 3}
+			for i := 0; i < len(titles); i++ {
+				batch, more, err := ws.GetTodosPage(alice, filter, page)
+				if err != nil {
+					t.Fatalf("GetTodosPage: %v", err)
+				}
+				paged = append(paged, batch...)
+				if !more || len(batch) == 0 {
+					break
+				}
+				page.After = &batch[len(batch)-1]
+			}
+			if got := todoTitles(paged); !equalStrings(got, expected) {
+				t.Errorf("desc=%v: paged order = %q, want %q", desc, got, expected)
+			}
+		}
+	})
+}
+
+func todoTitles(todos []models.Todo) []string {
+	titles := make([]string, len(todos))
+	for i, t := range todos {
+		titles[i] = t.Title
+	}
+	return titles
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestConcurrentCreatePositions(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		alice := createUser(t, st, "alice", "alice@example.com")
+		wsID, err := st.CreateWorkspace("Personal", alice)
+		if err != nil {
+			t.Fatalf("CreateWorkspace: %v", err)
+		}
+		ws := st.Workspace(int(wsID))
+		parent, err := ws.CreateTodo(models.Todo{Title: "parent", Priority: "medium", UserID: alice})
+		if err != nil {
+			t.Fatalf("CreateTodo: %v", err)
+		}
+		parentID := int(parent)
+
+		// 并发创建的同级必须得到互不相同的位置
+		const n = 20
+		var wg sync.WaitGroup
+		errs := make(chan error, n)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := ws.CreateTodo(models.Todo{Title: fmt.Sprintf("child %d", i), Priority: "medium", UserID: alice, ParentID: &parentID})
+				errs <- err
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("CreateTodo: %v", err)
+			}
+		}
+
+		children, err := ws.GetChildren(parentID, alice)
+		if err != nil {
+			t.Fatalf("GetChildren: %v", err)
+		}
+		if len(children) != n {
+			t.Fatalf("got %d children, want %d", len(children), n)
+		}
+		seen := make(map[float64]string)
+		for _, c := range children {
+			if other, ok := seen[c.Position]; ok {
+				t.Errorf("%q and %q share position %v", other, c.Title, c.Position)
+			}
+			seen[c.Position] = c.Title
+		}
+	})
+}
+
+func TestPositionRebalance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, st store.Store) {
+		alice := createUser(t, st, "alice", "alice@example.com")
+		wsID, err := st.CreateWorkspace("Personal", alice)
+		if err != nil {
+			t.Fatalf("CreateWorkspace: %v", err)
+		}
+		ws := st.Workspace(int(wsID))
+		parent, err := ws.CreateTodo(models.Todo{Title: "parent", Priority: "medium", UserID: alice})
+		if err != nil {
+			t.Fatalf("CreateTodo: %v", err)
+		}
+		parentID := int(parent)
+
+		var order []int
+		for _, title := range []string{"first", "a", "b", "last"} {
+			id, err := ws.CreateTodo(models.Todo{Title: title, Priority: "medium", UserID: alice, ParentID: &parentID})
+			if err != nil {
+				t.Fatalf("CreateTodo: %v", err)
+			}
+			order = append(order, int(id))
+		}
+		first := order[0]
+
+		children := func() []models.Todo {
+			t.Helper()
+			todos, err := ws.GetChildren(parentID, alice)
+			if err != nil {
+				t.Fatalf("GetChildren: %v", err)
+			}
+			return todos
+		}
+		checkOrder := func(step string) {
+			t.Helper()
+			todos := children()
+			for i, todo := range todos {
+				if i >= len(order) || todo.ID != order[i] {
+					t.Fatalf("%s: order %v, want %v", step, todoIDs(todos), order)
+				}
+			}
+		}
+
+		// 每次移动都使 first 与其后一项的间隔减半，小于 1e-6 后必须先重新编号才能保持顺序
+		for i := 0; i < 50; i++ {
+			id := order[2]
+			if err := ws.ReorderTodo(id, alice, first, false); err != nil {
+				t.Fatalf("move %d: ReorderTodo: %v", i, err)
+			}
+			order = append([]int{first, id}, append(order[1:2], order[3:]...)...)
+			checkOrder(fmt.Sprintf("move %d", i))
+		}
+
+		// 定期任务把剩下的过小间隔恢复为 PositionStep，顺序不变
+		n, err := st.RebalancePositions()
+		if err != nil {
+			t.Fatalf("RebalancePositions: %v", err)
+		}
+		if n != 1 {
+			t.Errorf("RebalancePositions rebalanced %d groups, want 1", n)
+		}
+		checkOrder("rebalance")
+		for i, todo := range children() {
+			if want := store.RebalancedPosition(i); todo.Position != want {
+				t.Errorf("%q position %v, want %v", todo.Title, todo.Position, want)
+			}
+		}
+		if n, err := st.RebalancePositions(); err != nil || n != 0 {
+			t.Errorf("second RebalancePositions = %d, %v; want 0, nil", n, err)
+		}
+	})
+}
+
+func todoIDs(todos []models.Todo) []int {
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+	return ids
+}
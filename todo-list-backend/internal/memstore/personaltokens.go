@@ -0,0 +1,85 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 个人访问令牌相关操作
+
+// CreatePersonalToken 保存个人访问令牌
+func (s *Store) CreatePersonalToken(token models.PersonalAccessToken) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token.ID = s.nextPATID
+	s.nextPATID++
+	token.Scopes = append([]models.Scope{}, token.Scopes...)
+	if token.ExpiresAt != nil {
+		expiresAt := token.ExpiresAt.UTC()
+		token.ExpiresAt = &expiresAt
+	}
+	token.LastUsedAt = nil
+	token.CreatedAt = time.Now()
+	s.patokens[token.ID] = token
+
+	return int64(token.ID), nil
+}
+
+// GetPersonalTokens 获取用户的全部个人访问令牌
+func (s *Store) GetPersonalTokens(userID int) ([]models.PersonalAccessToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := []models.PersonalAccessToken{}
+	for _, t := range s.patokens {
+		if t.UserID == userID {
+			tokens = append(tokens, t)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if !tokens[i].CreatedAt.Equal(tokens[j].CreatedAt) {
+			return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
+		}
+		return tokens[i].ID > tokens[j].ID
+	})
+	return tokens, nil
+}
+
+// DeletePersonalToken 撤销个人访问令牌
+func (s *Store) DeletePersonalToken(id int, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.patokens[id]
+	if !ok || t.UserID != userID {
+		return store.ErrPersonalTokenNotFound
+	}
+	delete(s.patokens, id)
+	return nil
+}
+
+// UsePersonalToken 查找令牌并记录使用时间
+func (s *Store) UsePersonalToken(hash string) (models.PersonalAccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for id, t := range s.patokens {
+		if t.TokenHash != hash {
+			continue
+		}
+		if t.ExpiresAt != nil && !now.Before(*t.ExpiresAt) {
+			return models.PersonalAccessToken{}, store.ErrInvalidPersonalToken
+		}
+		if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) >= store.LastUsedInterval {
+			t.LastUsedAt = &now
+			s.patokens[id] = t
+		}
+		return t, nil
+	}
+	return models.PersonalAccessToken{}, store.ErrInvalidPersonalToken
+}
@@ -0,0 +1,156 @@
+package memstore
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 手动排序相关操作
+
+// sameInt 判断两个可空整数是否相等，都为 nil 也视为相等
+func sameInt(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// sortByPosition 按手动排序排列待办事项，与 store.LessTodo 一致
+func sortByPosition(todos []models.Todo) {
+	keys := []store.SortKey{{Field: store.SortPosition}}
+	sort.Slice(todos, func(i, j int) bool {
+		return store.LessTodo(todos[i], todos[j], keys)
+	})
+}
+
+// siblings 返回当前工作区中一组同级（同一项目和父项），按手动排序；调用方需持有读锁
+func (s *Store) siblings(projectID, parentID *int) []models.Todo {
+	todos := []models.Todo{}
+	for _, t := range s.todos {
+		if t.WorkspaceID == s.workspaceID && sameInt(t.ProjectID, projectID) && sameInt(t.ParentID, parentID) {
+			todos = append(todos, t)
+		}
+	}
+	sortByPosition(todos)
+	return todos
+}
+
+// endPosition 返回排在同级末尾的位置；调用方需持有读锁
+func (s *Store) endPosition(projectID, parentID *int) float64 {
+	siblings := s.siblings(projectID, parentID)
+	if len(siblings) == 0 {
+		position, _ := store.PositionBetween(nil, nil)
+		return position
+	}
+	position, _ := store.PositionBetween(&siblings[len(siblings)-1].Position, nil)
+	return position
+}
+
+// ReorderTodo 把待办事项放到同级锚点之前或之后
+func (s *Store) ReorderTodo(id int, userID int, anchorID int, before bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	todo, err := s.accessTodo(id, userID, models.RoleEditor)
+	if err != nil {
+		return err
+	}
+	anchor, err := s.accessTodo(anchorID, userID, models.RoleViewer)
+	if errors.Is(err, store.ErrTodoNotFound) || errors.Is(err, store.ErrForbidden) {
+		return store.ErrInvalidAnchor
+	}
+	if err != nil {
+		return err
+	}
+	if anchor.ID == todo.ID || !sameInt(anchor.ProjectID, todo.ProjectID) || !sameInt(anchor.ParentID, todo.ParentID) {
+		return store.ErrInvalidAnchor
+	}
+
+	position, ok := s.positionNextTo(todo, anchorID, before)
+	if !ok {
+		// 间隔已经过小，先把这组同级重新编号，再重新计算
+		for i, t := range s.siblings(todo.ProjectID, todo.ParentID) {
+			t.Position = store.RebalancedPosition(i)
+			s.todos[t.ID] = t
+		}
+		position, _ = s.positionNextTo(todo, anchorID, before)
+	}
+
+	todo = s.todos[id]
+	todo.Position = position
+	todo.UpdatedAt = time.Now()
+	s.todos[id] = todo
+	return nil
+}
+
+// positionNextTo 计算锚点与其另一侧相邻项（不包括被移动的待办事项）之间的位置；调用方需持有读锁
+func (s *Store) positionNextTo(todo models.Todo, anchorID int, before bool) (float64, bool) {
+	var others []models.Todo
+	for _, t := range s.siblings(todo.ProjectID, todo.ParentID) {
+		if t.ID != todo.ID {
+			others = append(others, t)
+		}
+	}
+
+	for i, t := range others {
+		if t.ID != anchorID {
+			continue
+		}
+		if before {
+			var lower *float64
+			if i > 0 {
+				lower = &others[i-1].Position
+			}
+			return store.PositionBetween(lower, &t.Position)
+		}
+		var upper *float64
+		if i+1 < len(others) {
+			upper = &others[i+1].Position
+		}
+		return store.PositionBetween(&t.Position, upper)
+	}
+	return 0, false
+}
+
+// RebalancePositions 把存在过小间隔的同级组重新编号
+func (s *Store) RebalancePositions() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type groupKey struct {
+		workspaceID, projectID, parentID int
+	}
+	groups := make(map[groupKey][]models.Todo)
+	for _, t := range s.todos {
+		key := groupKey{workspaceID: t.WorkspaceID}
+		if t.ProjectID != nil {
+			key.projectID = *t.ProjectID
+		}
+		if t.ParentID != nil {
+			key.parentID = *t.ParentID
+		}
+		groups[key] = append(groups[key], t)
+	}
+
+	n := 0
+	for _, todos := range groups {
+		sortByPosition(todos)
+		positions := make([]float64, len(todos))
+		for i, t := range todos {
+			positions[i] = t.Position
+		}
+		if !store.NeedsRebalance(positions) {
+			continue
+		}
+		for i, t := range todos {
+			t.Position = store.RebalancedPosition(i)
+			s.todos[t.ID] = t
+		}
+		n++
+	}
+	return n, nil
+}
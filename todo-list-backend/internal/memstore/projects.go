@@ -0,0 +1,189 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 项目相关操作
+
+// withRole 返回填充了 userID 角色的项目副本，无法访问时返回 false；调用方需持有读锁
+func (s *Store) withRole(p models.Project, userID int) (models.Project, bool) {
+	role, ok := s.projectRole(p.ID, userID)
+	p.Role = role
+	return p, ok
+}
+
+// GetProjects 获取用户可访问的项目
+func (s *Store) GetProjects(userID int, includeArchived bool) ([]models.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	projects := []models.Project{}
+	for _, p := range s.projects {
+		p, ok := s.withRole(p, userID)
+		if ok && (includeArchived || !p.Archived) {
+			projects = append(projects, p)
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].Position == projects[j].Position {
+			return projects[i].ID < projects[j].ID
+		}
+		return projects[i].Position < projects[j].Position
+	})
+	return projects, nil
+}
+
+// GetProject 获取单个项目
+func (s *Store) GetProject(id int, userID int) (models.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.project(id, userID)
+}
+
+// project 返回填充了角色的项目；调用方需持有读锁
+func (s *Store) project(id int, userID int) (models.Project, error) {
+	p, ok := s.projects[id]
+	if !ok {
+		return models.Project{}, store.ErrProjectNotFound
+	}
+	p, ok = s.withRole(p, userID)
+	if !ok {
+		return models.Project{}, store.ErrProjectNotFound
+	}
+	return p, nil
+}
+
+// checkProject 验证用户可以向项目中放入待办事项：至少为 editor 且项目未归档；调用方需持有锁
+func (s *Store) checkProject(id int, userID int) error {
+	p, err := s.project(id, userID)
+	if err != nil || p.Archived {
+		return store.ErrInvalidProject
+	}
+	if !p.Role.Allows(models.RoleEditor) {
+		return store.ErrForbidden
+	}
+	return nil
+}
+
+// CreateProject 创建项目
+func (s *Store) CreateProject(project models.Project) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project.Inbox = false
+	return int64(s.insertProject(project).ID), nil
+}
+
+// insertProject 保存新项目，Position 为 0 的普通项目排在最后；调用方需持有写锁
+func (s *Store) insertProject(project models.Project) models.Project {
+	if project.Position == 0 && !project.Inbox {
+		for _, p := range s.projects {
+			if p.UserID == project.UserID && p.WorkspaceID == s.workspaceID && p.Position >= project.Position {
+				project.Position = p.Position
+			}
+		}
+		project.Position++
+	}
+
+	now := time.Now()
+	project.ID = s.nextProjID
+	project.WorkspaceID = s.workspaceID
+	project.Role = ""
+	project.CreatedAt = now
+	project.UpdatedAt = now
+	s.nextProjID++
+	s.projects[project.ID] = project
+
+	project.Role = models.RoleOwner
+	return project
+}
+
+// UpdateProject 修改项目
+func (s *Store) UpdateProject(project models.Project) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.project(project.ID, project.UserID)
+	if err != nil {
+		return err
+	}
+	if !existing.Role.Allows(models.RoleOwner) {
+		return store.ErrForbidden
+	}
+	if existing.Inbox && project.Archived {
+		return store.ErrInboxProject
+	}
+
+	existing = s.projects[project.ID]
+	existing.Name = project.Name
+	existing.Color = project.Color
+	existing.Archived = project.Archived
+	existing.Position = project.Position
+	existing.UpdatedAt = time.Now()
+	s.projects[project.ID] = existing
+	return nil
+}
+
+// DeleteProject 删除项目，其中的待办事项移到各自创建者的收件箱
+func (s *Store) DeleteProject(id int, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, err := s.project(id, userID)
+	if err != nil {
+		return err
+	}
+	if !project.Role.Allows(models.RoleOwner) {
+		return store.ErrForbidden
+	}
+	if project.Inbox {
+		return store.ErrInboxProject
+	}
+
+	now := time.Now()
+	for todoID, t := range s.todos {
+		if t.ProjectID != nil && *t.ProjectID == id {
+			inbox := s.inbox(t.UserID)
+			if t.ParentID != nil && s.todos[*t.ParentID].UserID != t.UserID {
+				// 父项由其他成员创建时会进入不同的收件箱，变为顶层待办
+				t.ParentID = nil
+			}
+			t.ProjectID = &inbox.ID
+			t.UpdatedAt = now
+			s.todos[todoID] = t
+		}
+	}
+	delete(s.projects, id)
+	delete(s.members, id)
+	for invID, inv := range s.invites {
+		if inv.ProjectID == id {
+			delete(s.invites, invID)
+		}
+	}
+	return nil
+}
+
+// EnsureInbox 返回用户在当前工作区的收件箱，不存在时创建
+func (s *Store) EnsureInbox(userID int) (models.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.inbox(userID), nil
+}
+
+// inbox 返回用户在当前工作区的收件箱，不存在时创建；调用方需持有写锁
+func (s *Store) inbox(userID int) models.Project {
+	for _, p := range s.projects {
+		if p.UserID == userID && p.WorkspaceID == s.workspaceID && p.Inbox {
+			p.Role = models.RoleOwner
+			return p
+		}
+	}
+	return s.insertProject(models.Project{UserID: userID, Name: "Inbox", Color: "#808080", Inbox: true})
+}
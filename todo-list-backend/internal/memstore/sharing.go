@@ -0,0 +1,258 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 项目共享相关操作
+
+// projectRole 返回用户在项目中的角色，项目创建者总是 owner；项目不在当前工作区时返回 false。调用方需持有读锁
+func (s *Store) projectRole(projectID int, userID int) (models.Role, bool) {
+	p, ok := s.projects[projectID]
+	if !ok || p.WorkspaceID != s.workspaceID {
+		return "", false
+	}
+	if p.UserID == userID {
+		return models.RoleOwner, true
+	}
+	m, ok := s.members[projectID][userID]
+	return m.Role, ok
+}
+
+// todoRole 返回用户对待办事项的角色，由所在项目决定；没有项目的待办事项只有创建者可以访问。调用方需持有读锁
+func (s *Store) todoRole(todo models.Todo, userID int) (models.Role, bool) {
+	if todo.ProjectID == nil {
+		return models.RoleOwner, todo.UserID == userID
+	}
+	return s.projectRole(*todo.ProjectID, userID)
+}
+
+// assignedTo 判断待办事项是否指派给了 userID
+func assignedTo(todo models.Todo, userID int) bool {
+	return todo.AssigneeID != nil && *todo.AssigneeID == userID
+}
+
+// accessTodo 检查用户对待办事项至少具有 required 角色并返回该待办事项，负责人只能查看；调用方需持有读锁
+func (s *Store) accessTodo(id int, userID int, required models.Role) (models.Todo, error) {
+	todo, ok := s.todos[id]
+	if !ok || todo.WorkspaceID != s.workspaceID {
+		return models.Todo{}, store.ErrTodoNotFound
+	}
+	role, ok := s.todoRole(todo, userID)
+	if !ok || !role.Allows(required) {
+		if assignedTo(todo, userID) {
+			if required == models.RoleViewer {
+				return todo, nil
+			}
+			return models.Todo{}, store.ErrForbidden
+		}
+		if !ok {
+			return models.Todo{}, store.ErrTodoNotFound
+		}
+		return models.Todo{}, store.ErrForbidden
+	}
+	return todo, nil
+}
+
+// checkAssignee 检查负责人是当前工作区的成员，assigneeID 为 nil 表示不指派；调用方需持有读锁
+func (s *Store) checkAssignee(assigneeID *int) error {
+	if assigneeID == nil {
+		return nil
+	}
+	if _, ok := s.wsMembers[s.workspaceID][*assigneeID]; !ok {
+		return store.ErrInvalidAssignee
+	}
+	return nil
+}
+
+// requireProject 检查用户在项目中至少具有 required 角色；调用方需持有读锁
+func (s *Store) requireProject(projectID int, userID int, required models.Role) (models.Project, error) {
+	p, err := s.project(projectID, userID)
+	if err != nil {
+		return models.Project{}, err
+	}
+	if !p.Role.Allows(required) {
+		return models.Project{}, store.ErrForbidden
+	}
+	return p, nil
+}
+
+// GetMembers 获取项目成员，创建者排在最前
+func (s *Store) GetMembers(projectID int, userID int) ([]models.Member, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, err := s.requireProject(projectID, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	members := []models.Member{}
+	for _, m := range s.members[projectID] {
+		m.Username = s.users[m.UserID].Username
+		members = append(members, m)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].CreatedAt.Equal(members[j].CreatedAt) {
+			return members[i].UserID < members[j].UserID
+		}
+		return members[i].CreatedAt.Before(members[j].CreatedAt)
+	})
+
+	owner := models.Member{UserID: p.UserID, Username: s.users[p.UserID].Username, Role: models.RoleOwner, CreatedAt: p.CreatedAt}
+	return append([]models.Member{owner}, members...), nil
+}
+
+// UpdateMemberRole 修改成员的角色
+func (s *Store) UpdateMemberRole(projectID int, memberID int, role models.Role, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.requireProject(projectID, userID, models.RoleOwner); err != nil {
+		return err
+	}
+	m, ok := s.members[projectID][memberID]
+	if !ok {
+		return store.ErrMemberNotFound
+	}
+
+	m.Role = role
+	s.members[projectID][memberID] = m
+	return nil
+}
+
+// RemoveMember 移除成员
+func (s *Store) RemoveMember(projectID int, memberID int, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	required := models.RoleOwner
+	if memberID == userID {
+		// 成员可以自己退出项目
+		required = models.RoleViewer
+	}
+	if _, err := s.requireProject(projectID, userID, required); err != nil {
+		return err
+	}
+	if _, ok := s.members[projectID][memberID]; !ok {
+		return store.ErrMemberNotFound
+	}
+
+	delete(s.members[projectID], memberID)
+	return nil
+}
+
+// CreateInvitation 邀请用户加入项目
+func (s *Store) CreateInvitation(projectID int, email string, role models.Role, userID int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.requireProject(projectID, userID, models.RoleOwner)
+	if err != nil {
+		return 0, err
+	}
+	if p.Inbox {
+		return 0, store.ErrInboxProject
+	}
+
+	inviteeID := 0
+	for _, u := range s.users {
+		if u.Email == email {
+			inviteeID = u.ID
+		}
+	}
+	// 只能邀请同一工作区的成员，否则项目会对工作区外的用户可见
+	if _, ok := s.wsMembers[s.workspaceID][inviteeID]; !ok {
+		return 0, store.ErrUserNotFound
+	}
+	if _, ok := s.projectRole(projectID, inviteeID); ok {
+		return 0, store.ErrAlreadyMember
+	}
+	for _, inv := range s.invites {
+		if inv.ProjectID == projectID && inv.InviteeID == inviteeID && inv.Status == models.InvitationPending {
+			return 0, store.ErrInvitationExists
+		}
+	}
+
+	now := time.Now()
+	inv := models.Invitation{
+		ID:        s.nextInvID,
+		ProjectID: projectID,
+		InviterID: userID,
+		InviteeID: inviteeID,
+		Role:      role,
+		Status:    models.InvitationPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.nextInvID++
+	s.invites[inv.ID] = inv
+
+	return int64(inv.ID), nil
+}
+
+// pendingInvitations 返回满足条件的待处理邀请，按创建时间排序；调用方需持有读锁
+func (s *Store) pendingInvitations(match func(models.Invitation) bool) []models.Invitation {
+	invitations := []models.Invitation{}
+	for _, inv := range s.invites {
+		if inv.Status == models.InvitationPending && match(inv) {
+			inv.ProjectName = s.projects[inv.ProjectID].Name
+			invitations = append(invitations, inv)
+		}
+	}
+	sort.Slice(invitations, func(i, j int) bool {
+		return invitations[i].ID < invitations[j].ID
+	})
+	return invitations
+}
+
+// GetProjectInvitations 获取项目中待处理的邀请
+func (s *Store) GetProjectInvitations(projectID int, userID int) ([]models.Invitation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, err := s.requireProject(projectID, userID, models.RoleOwner); err != nil {
+		return nil, err
+	}
+	return s.pendingInvitations(func(inv models.Invitation) bool {
+		return inv.ProjectID == projectID
+	}), nil
+}
+
+// GetInvitations 获取用户在当前工作区收到的待处理邀请
+func (s *Store) GetInvitations(userID int) ([]models.Invitation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.pendingInvitations(func(inv models.Invitation) bool {
+		return inv.InviteeID == userID && s.projects[inv.ProjectID].WorkspaceID == s.workspaceID
+	}), nil
+}
+
+// RespondInvitation 接受或拒绝邀请，接受时加入项目成员
+func (s *Store) RespondInvitation(id int, userID int, accept bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inv, ok := s.invites[id]
+	if !ok || inv.InviteeID != userID || inv.Status != models.InvitationPending || s.projects[inv.ProjectID].WorkspaceID != s.workspaceID {
+		return store.ErrInvitationNotFound
+	}
+
+	now := time.Now()
+	inv.Status = models.InvitationDeclined
+	if accept {
+		inv.Status = models.InvitationAccepted
+		if s.members[inv.ProjectID] == nil {
+			s.members[inv.ProjectID] = make(map[int]models.Member)
+		}
+		s.members[inv.ProjectID][userID] = models.Member{UserID: userID, Role: inv.Role, CreatedAt: now}
+	}
+	inv.UpdatedAt = now
+	s.invites[id] = inv
+	return nil
+}
@@ -0,0 +1,90 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 智能列表相关操作
+
+// GetSmartLists 获取用户在当前工作区的全部智能列表
+func (s *Store) GetSmartLists(userID int) ([]models.SmartList, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	lists := []models.SmartList{}
+	for _, l := range s.smartLists {
+		if l.UserID == userID && l.WorkspaceID == s.workspaceID {
+			lists = append(lists, l)
+		}
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		if lists[i].Name == lists[j].Name {
+			return lists[i].ID < lists[j].ID
+		}
+		return lists[i].Name < lists[j].Name
+	})
+	return lists, nil
+}
+
+// GetSmartList 获取单个智能列表
+func (s *Store) GetSmartList(id int, userID int) (models.SmartList, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.smartList(id, userID)
+}
+
+// smartList 返回当前工作区中属于 userID 的智能列表；调用方需持有读锁
+func (s *Store) smartList(id int, userID int) (models.SmartList, error) {
+	l, ok := s.smartLists[id]
+	if !ok || l.UserID != userID || l.WorkspaceID != s.workspaceID {
+		return models.SmartList{}, store.ErrSmartListNotFound
+	}
+	return l, nil
+}
+
+// CreateSmartList 创建智能列表
+func (s *Store) CreateSmartList(list models.SmartList) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	list.ID = s.nextListID
+	s.nextListID++
+	list.WorkspaceID = s.workspaceID
+	list.CreatedAt, list.UpdatedAt = now, now
+	s.smartLists[list.ID] = list
+	return int64(list.ID), nil
+}
+
+// UpdateSmartList 修改智能列表
+func (s *Store) UpdateSmartList(list models.SmartList) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.smartList(list.ID, list.UserID)
+	if err != nil {
+		return err
+	}
+	current.Name = list.Name
+	current.Query = list.Query
+	current.UpdatedAt = time.Now()
+	s.smartLists[list.ID] = current
+	return nil
+}
+
+// DeleteSmartList 删除智能列表
+func (s *Store) DeleteSmartList(id int, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.smartList(id, userID); err != nil {
+		return err
+	}
+	delete(s.smartLists, id)
+	return nil
+}
@@ -0,0 +1,158 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 标签相关操作
+
+// withDetails 返回填充了标签和评论数的待办事项副本；调用方需持有读锁
+func (s *Store) withDetails(todo models.Todo) models.Todo {
+	todo.CommentCount = len(s.comments[todo.ID])
+	todo.Tags = []models.Tag{}
+	for tagID := range s.todoTags[todo.ID] {
+		if tag := s.tags[tagID]; tag.WorkspaceID == s.workspaceID {
+			todo.Tags = append(todo.Tags, tag)
+		}
+	}
+	sort.Slice(todo.Tags, func(i, j int) bool {
+		return todo.Tags[i].Name < todo.Tags[j].Name
+	})
+	return todo
+}
+
+// GetTags 获取用户在当前工作区的全部标签
+func (s *Store) GetTags(userID int) ([]models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tags := []models.Tag{}
+	for _, t := range s.tags {
+		if t.UserID == userID && t.WorkspaceID == s.workspaceID {
+			tags = append(tags, t)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+// GetTag 获取单个标签
+func (s *Store) GetTag(id int, userID int) (models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.tag(id, userID)
+}
+
+// tag 返回当前工作区中属于 userID 的标签；调用方需持有读锁
+func (s *Store) tag(id int, userID int) (models.Tag, error) {
+	tag, ok := s.tags[id]
+	if !ok || tag.UserID != userID || tag.WorkspaceID != s.workspaceID {
+		return models.Tag{}, store.ErrTagNotFound
+	}
+	return tag, nil
+}
+
+// tagNameTaken 检查同一用户在当前工作区是否已有同名标签；调用方需持有锁
+func (s *Store) tagNameTaken(userID int, name string, excludeID int) bool {
+	for _, t := range s.tags {
+		if t.UserID == userID && t.WorkspaceID == s.workspaceID && t.Name == name && t.ID != excludeID {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateTag 创建标签
+func (s *Store) CreateTag(tag models.Tag) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tagNameTaken(tag.UserID, tag.Name, 0) {
+		return 0, store.ErrTagExists
+	}
+
+	now := time.Now()
+	tag.ID = s.nextTagID
+	tag.WorkspaceID = s.workspaceID
+	tag.CreatedAt = now
+	tag.UpdatedAt = now
+	s.nextTagID++
+	s.tags[tag.ID] = tag
+
+	return int64(tag.ID), nil
+}
+
+// UpdateTag 修改标签
+func (s *Store) UpdateTag(tag models.Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.tag(tag.ID, tag.UserID)
+	if err != nil {
+		return err
+	}
+	if s.tagNameTaken(tag.UserID, tag.Name, tag.ID) {
+		return store.ErrTagExists
+	}
+
+	existing.Name = tag.Name
+	existing.Color = tag.Color
+	existing.UpdatedAt = time.Now()
+	s.tags[tag.ID] = existing
+	return nil
+}
+
+// DeleteTag 删除标签并从所有待办事项上移除
+func (s *Store) DeleteTag(id int, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.tag(id, userID); err != nil {
+		return err
+	}
+
+	delete(s.tags, id)
+	for _, tagIDs := range s.todoTags {
+		delete(tagIDs, id)
+	}
+	return nil
+}
+
+// AttachTag 为待办事项添加标签
+func (s *Store) AttachTag(todoID int, tagID int, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.accessTodo(todoID, userID, models.RoleEditor); err != nil {
+		return err
+	}
+	if _, err := s.tag(tagID, userID); err != nil {
+		return err
+	}
+
+	if s.todoTags[todoID] == nil {
+		s.todoTags[todoID] = make(map[int]bool)
+	}
+	s.todoTags[todoID][tagID] = true
+	return nil
+}
+
+// DetachTag 从待办事项上移除标签
+func (s *Store) DetachTag(todoID int, tagID int, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.accessTodo(todoID, userID, models.RoleEditor); err != nil {
+		return err
+	}
+
+	delete(s.todoTags[todoID], tagID)
+	return nil
+}
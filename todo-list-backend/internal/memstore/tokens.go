@@ -0,0 +1,157 @@
+package memstore
+
+import (
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 刷新令牌和访问令牌撤销列表相关操作
+
+// CreateRefreshToken 保存刷新令牌并清理该用户已过期的刷新令牌
+func (s *Store) CreateRefreshToken(token models.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for hash, t := range s.refresh {
+		if t.UserID == token.UserID && t.ExpiresAt.Before(now) {
+			delete(s.refresh, hash)
+		}
+	}
+	s.addRefreshToken(&token)
+	return nil
+}
+
+// addRefreshToken 为刷新令牌分配ID并保存，调用方需持有写锁
+func (s *Store) addRefreshToken(token *models.RefreshToken) {
+	token.ID = s.nextRefID
+	s.nextRefID++
+	token.ExpiresAt = token.ExpiresAt.UTC()
+	token.CreatedAt = time.Now()
+	s.refresh[token.TokenHash] = *token
+}
+
+// RotateRefreshToken 使用刷新令牌并在同一族中保存新令牌，重复使用时撤销整个族
+func (s *Store) RotateRefreshToken(hash string, next models.RefreshToken) (models.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.refresh[hash]
+	now := time.Now().UTC()
+	if !ok || current.RevokedAt != nil || !now.Before(current.ExpiresAt) {
+		return models.RefreshToken{}, store.ErrInvalidToken
+	}
+	if current.UsedAt != nil {
+		s.revokeFamily(current.FamilyID, now)
+		return models.RefreshToken{}, store.ErrTokenReused
+	}
+
+	current.UsedAt = &now
+	s.refresh[hash] = current
+
+	next.UserID, next.WorkspaceID, next.FamilyID = current.UserID, current.WorkspaceID, current.FamilyID
+	s.addRefreshToken(&next)
+	return next, nil
+}
+
+// RevokeTokenFamily 撤销令牌族
+func (s *Store) RevokeTokenFamily(familyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revokeFamily(familyID, time.Now().UTC())
+	return nil
+}
+
+// revokeFamily 撤销令牌族中未撤销的刷新令牌，调用方需持有写锁
+func (s *Store) revokeFamily(familyID string, now time.Time) {
+	for hash, t := range s.refresh {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+			s.refresh[hash] = t
+		}
+	}
+}
+
+// RevokeUserSessions 撤销用户的全部刷新令牌
+func (s *Store) RevokeUserSessions(userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for hash, t := range s.refresh {
+		if t.UserID == userID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+			s.refresh[hash] = t
+		}
+	}
+	return nil
+}
+
+// RevokeOtherSessions 撤销用户除 keepFamilyID 之外的全部刷新令牌
+func (s *Store) RevokeOtherSessions(userID int, keepFamilyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for hash, t := range s.refresh {
+		if t.UserID == userID && t.FamilyID != keepFamilyID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+			s.refresh[hash] = t
+		}
+	}
+	return nil
+}
+
+// SetFamilyWorkspace 修改令牌族刷新时进入的工作区
+func (s *Store) SetFamilyWorkspace(familyID string, workspaceID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for hash, t := range s.refresh {
+		if t.FamilyID == familyID {
+			t.WorkspaceID = workspaceID
+			s.refresh[hash] = t
+		}
+	}
+	return nil
+}
+
+// RevokeAccessToken 把 jti 加入撤销列表
+func (s *Store) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, exp := range s.revoked {
+		if exp.Before(now) {
+			delete(s.revoked, id)
+		}
+	}
+	s.revoked[jti] = expiresAt.UTC()
+	return nil
+}
+
+// IsAccessTokenRevoked 判断 jti 是否在撤销列表中
+func (s *Store) IsAccessTokenRevoked(jti string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.revoked[jti]
+	return ok, nil
+}
+
+// IsSessionRevoked 判断令牌族中是否有已撤销的刷新令牌，撤销总是作用于整个族
+func (s *Store) IsSessionRevoked(familyID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, t := range s.refresh {
+		if t.FamilyID == familyID && t.RevokedAt != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
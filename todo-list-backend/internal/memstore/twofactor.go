@@ -0,0 +1,123 @@
+package memstore
+
+import (
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 两步验证相关操作
+
+// GetTOTP 获取用户的两步验证密钥
+func (s *Store) GetTOTP(userID int) (models.TOTP, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.totp[userID]
+	if !ok {
+		return models.TOTP{}, store.ErrTwoFactorNotFound
+	}
+	return t, nil
+}
+
+// SetTOTPSecret 保存未确认的新密钥
+func (s *Store) SetTOTPSecret(userID int, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, ok := s.totp[userID]; ok && t.EnabledAt != nil {
+		return store.ErrTwoFactorEnabled
+	}
+	s.totp[userID] = models.TOTP{UserID: userID, Secret: secret, CreatedAt: time.Now()}
+	return nil
+}
+
+// EnableTOTP 确认绑定并保存恢复码
+func (s *Store) EnableTOTP(userID int, step int64, recoveryHashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.totp[userID]
+	if !ok {
+		return store.ErrTwoFactorNotFound
+	}
+	if t.EnabledAt != nil {
+		return store.ErrTwoFactorEnabled
+	}
+	now := time.Now().UTC()
+	t.EnabledAt = &now
+	t.LastStep = step
+	s.totp[userID] = t
+	s.replaceRecoveryCodes(userID, recoveryHashes)
+	return nil
+}
+
+// DisableTOTP 删除两步验证密钥和恢复码
+func (s *Store) DisableTOTP(userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.totp, userID)
+	delete(s.recovery, userID)
+	return nil
+}
+
+// UseTOTPStep 记录已使用的时间步
+func (s *Store) UseTOTPStep(userID int, step int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.totp[userID]
+	if !ok || t.LastStep >= step {
+		return store.ErrCodeReused
+	}
+	t.LastStep = step
+	s.totp[userID] = t
+	return nil
+}
+
+// UseRecoveryCode 使用恢复码
+func (s *Store) UseRecoveryCode(userID int, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	used, ok := s.recovery[userID][hash]
+	if !ok || used {
+		return store.ErrInvalidRecoveryCode
+	}
+	s.recovery[userID][hash] = true
+	return nil
+}
+
+// ReplaceRecoveryCodes 重新生成恢复码
+func (s *Store) ReplaceRecoveryCodes(userID int, hashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.replaceRecoveryCodes(userID, hashes)
+	return nil
+}
+
+// replaceRecoveryCodes 替换用户的全部恢复码，调用方需持有写锁
+func (s *Store) replaceRecoveryCodes(userID int, hashes []string) {
+	codes := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		codes[hash] = false
+	}
+	s.recovery[userID] = codes
+}
+
+// CountRecoveryCodes 统计未使用的恢复码
+func (s *Store) CountRecoveryCodes(userID int) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, used := range s.recovery[userID] {
+		if !used {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,231 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// 工作区相关操作
+
+// workspaceRole 返回用户在工作区中的角色，不是成员时返回 ErrWorkspaceNotFound；调用方需持有读锁
+func (s *Store) workspaceRole(id int, userID int) (models.WorkspaceRole, error) {
+	m, ok := s.wsMembers[id][userID]
+	if !ok {
+		return "", store.ErrWorkspaceNotFound
+	}
+	return m.Role, nil
+}
+
+// requireAdmin 检查用户是工作区的管理员；调用方需持有读锁
+func (s *Store) requireAdmin(id int, userID int) error {
+	role, err := s.workspaceRole(id, userID)
+	if err != nil {
+		return err
+	}
+	if role != models.WorkspaceAdmin {
+		return store.ErrForbidden
+	}
+	return nil
+}
+
+// CreateWorkspace 创建工作区，创建者成为管理员
+func (s *Store) CreateWorkspace(name string, userID int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	w := models.Workspace{ID: s.nextWsID, Name: name, CreatedAt: now, UpdatedAt: now}
+	s.nextWsID++
+	s.workspaces[w.ID] = w
+	s.wsMembers[w.ID] = map[int]models.WorkspaceMemberInfo{
+		userID: {UserID: userID, Role: models.WorkspaceAdmin, CreatedAt: now},
+	}
+
+	return int64(w.ID), nil
+}
+
+// GetWorkspaces 获取用户加入的全部工作区
+func (s *Store) GetWorkspaces(userID int) ([]models.Workspace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	workspaces := []models.Workspace{}
+	joined := make(map[int]time.Time)
+	for id, w := range s.workspaces {
+		if m, ok := s.wsMembers[id][userID]; ok {
+			w.Role = m.Role
+			workspaces = append(workspaces, w)
+			joined[id] = m.CreatedAt
+		}
+	}
+	sort.Slice(workspaces, func(i, j int) bool {
+		a, b := joined[workspaces[i].ID], joined[workspaces[j].ID]
+		if a.Equal(b) {
+			return workspaces[i].ID < workspaces[j].ID
+		}
+		return a.Before(b)
+	})
+	return workspaces, nil
+}
+
+// GetWorkspace 获取单个工作区
+func (s *Store) GetWorkspace(id int, userID int) (models.Workspace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	role, err := s.workspaceRole(id, userID)
+	if err != nil {
+		return models.Workspace{}, err
+	}
+	w := s.workspaces[id]
+	w.Role = role
+	return w, nil
+}
+
+// RenameWorkspace 修改工作区名称
+func (s *Store) RenameWorkspace(id int, name string, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.requireAdmin(id, userID); err != nil {
+		return err
+	}
+
+	w := s.workspaces[id]
+	w.Name = name
+	w.UpdatedAt = time.Now()
+	s.workspaces[id] = w
+	return nil
+}
+
+// GetWorkspaceMembers 获取工作区成员
+func (s *Store) GetWorkspaceMembers(id int, userID int) ([]models.WorkspaceMemberInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, err := s.workspaceRole(id, userID); err != nil {
+		return nil, err
+	}
+
+	members := []models.WorkspaceMemberInfo{}
+	for _, m := range s.wsMembers[id] {
+		m.Username = s.users[m.UserID].Username
+		m.Email = s.users[m.UserID].Email
+		members = append(members, m)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].CreatedAt.Equal(members[j].CreatedAt) {
+			return members[i].UserID < members[j].UserID
+		}
+		return members[i].CreatedAt.Before(members[j].CreatedAt)
+	})
+	return members, nil
+}
+
+// AddWorkspaceMember 把已注册用户加入工作区
+func (s *Store) AddWorkspaceMember(id int, email string, role models.WorkspaceRole, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.requireAdmin(id, userID); err != nil {
+		return err
+	}
+
+	memberID := 0
+	for _, u := range s.users {
+		if u.Email == email {
+			memberID = u.ID
+		}
+	}
+	if memberID == 0 {
+		return store.ErrUserNotFound
+	}
+	if _, ok := s.wsMembers[id][memberID]; ok {
+		return store.ErrAlreadyMember
+	}
+
+	s.wsMembers[id][memberID] = models.WorkspaceMemberInfo{UserID: memberID, Role: role, CreatedAt: time.Now()}
+	return nil
+}
+
+// checkLastAdmin 在移除或降级管理员 memberID 之前检查工作区是否还有其他管理员；调用方需持有读锁
+func (s *Store) checkLastAdmin(id int, memberID int) error {
+	for uid, m := range s.wsMembers[id] {
+		if uid != memberID && m.Role == models.WorkspaceAdmin {
+			return nil
+		}
+	}
+	return store.ErrLastAdmin
+}
+
+// UpdateWorkspaceMember 修改成员角色
+func (s *Store) UpdateWorkspaceMember(id int, memberID int, role models.WorkspaceRole, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.requireAdmin(id, userID); err != nil {
+		return err
+	}
+	m, ok := s.wsMembers[id][memberID]
+	if !ok {
+		return store.ErrMemberNotFound
+	}
+	if m.Role == models.WorkspaceAdmin && role != models.WorkspaceAdmin {
+		if err := s.checkLastAdmin(id, memberID); err != nil {
+			return err
+		}
+	}
+
+	m.Role = role
+	s.wsMembers[id][memberID] = m
+	return nil
+}
+
+// RemoveWorkspaceMember 移除成员，同时撤销其在工作区项目中的成员资格、待处理的邀请，并取消指派给该成员的待办事项
+func (s *Store) RemoveWorkspaceMember(id int, memberID int, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if memberID == userID {
+		// 成员可以自己退出工作区
+		if _, err := s.workspaceRole(id, userID); err != nil {
+			return err
+		}
+	} else if err := s.requireAdmin(id, userID); err != nil {
+		return err
+	}
+
+	m, ok := s.wsMembers[id][memberID]
+	if !ok {
+		return store.ErrMemberNotFound
+	}
+	if m.Role == models.WorkspaceAdmin {
+		if err := s.checkLastAdmin(id, memberID); err != nil {
+			return err
+		}
+	}
+
+	for projectID, p := range s.projects {
+		if p.WorkspaceID == id {
+			delete(s.members[projectID], memberID)
+		}
+	}
+	for invID, inv := range s.invites {
+		if inv.InviteeID == memberID && inv.Status == models.InvitationPending && s.projects[inv.ProjectID].WorkspaceID == id {
+			delete(s.invites, invID)
+		}
+	}
+	now := time.Now()
+	for todoID, t := range s.todos {
+		if t.WorkspaceID == id && assignedTo(t, memberID) {
+			t.AssigneeID = nil
+			t.UpdatedAt = now
+			s.todos[todoID] = t
+		}
+	}
+	delete(s.wsMembers[id], memberID)
+	return nil
+}
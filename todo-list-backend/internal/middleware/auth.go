@@ -2,18 +2,43 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/joy_project/todo-list-backend/internal/auth"
+	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/store"
 )
 
 type contextKey string
 
-const UserIDKey contextKey = "userID"
+const (
+	UserIDKey        contextKey = "userID"
+	WorkspaceIDKey   contextKey = "workspaceID"
+	ClaimsKey        contextKey = "claims"
+	PersonalTokenKey contextKey = "personalToken"
+)
+
+// TokenValidator 验证令牌并返回其中的声明
+type TokenValidator interface {
+	ValidateToken(token string) (*auth.Claims, error)
+}
+
+// Denylist 查询访问令牌或签发它的会话是否已在过期前被撤销
+type Denylist interface {
+	IsAccessTokenRevoked(jti string) (bool, error)
+	IsSessionRevoked(familyID string) (bool, error)
+}
 
-// Auth 中间件验证JWT令牌并将用户ID添加到请求上下文中
-func Auth(next http.HandlerFunc) http.HandlerFunc {
+// PersonalTokens 通过摘要查找个人访问令牌
+type PersonalTokens interface {
+	UsePersonalToken(hash string) (models.PersonalAccessToken, error)
+}
+
+// Auth 中间件验证JWT令牌或个人访问令牌，并将用户ID和工作区ID添加到请求上下文中
+func Auth(tokens TokenValidator, denylist Denylist, personal PersonalTokens, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// 从Authorization头获取令牌
 		authHeader := r.Header.Get("Authorization")
@@ -29,15 +54,53 @@ func Auth(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		if strings.HasPrefix(parts[1], auth.PersonalTokenPrefix) {
+			token, err := personal.UsePersonalToken(auth.HashToken(parts[1]))
+			if errors.Is(err, store.ErrInvalidPersonalToken) {
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), UserIDKey, token.UserID)
+			ctx = context.WithValue(ctx, WorkspaceIDKey, token.WorkspaceID)
+			ctx = context.WithValue(ctx, PersonalTokenKey, token)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		// 验证令牌
-		userID, err := auth.ValidateToken(parts[1])
+		claims, err := tokens.ValidateToken(parts[1])
 		if err != nil {
 			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
 			return
 		}
 
-		// 将用户ID添加到请求上下文
-		ctx := context.WithValue(r.Context(), UserIDKey, userID)
+		// 现在签发的令牌都带有 jti 和会话ID，缺少时是无法撤销的旧版令牌，要求重新登录
+		if claims.ID == "" || claims.SessionID == "" {
+			http.Error(w, "Token cannot be revoked, please log in again", http.StatusUnauthorized)
+			return
+		}
+		revoked, err := denylist.IsAccessTokenRevoked(claims.ID)
+		if err == nil && !revoked {
+			revoked, err = denylist.IsSessionRevoked(claims.SessionID)
+		}
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if revoked {
+			http.Error(w, "Token has been revoked", http.StatusUnauthorized)
+			return
+		}
+
+		// 将用户ID、工作区ID和声明添加到请求上下文
+		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, WorkspaceIDKey, claims.WorkspaceID)
+		ctx = context.WithValue(ctx, ClaimsKey, claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
@@ -47,3 +110,46 @@ func GetUserID(r *http.Request) (int, bool) {
 	userID, ok := r.Context().Value(UserIDKey).(int)
 	return userID, ok
 }
+
+// GetWorkspaceID 从请求上下文中获取令牌对应的工作区ID，旧版令牌中没有工作区时返回 false
+func GetWorkspaceID(r *http.Request) (int, bool) {
+	workspaceID, ok := r.Context().Value(WorkspaceIDKey).(int)
+	return workspaceID, ok && workspaceID > 0
+}
+
+// GetClaims 从请求上下文中获取令牌的完整声明
+func GetClaims(r *http.Request) (*auth.Claims, bool) {
+	claims, ok := r.Context().Value(ClaimsKey).(*auth.Claims)
+	return claims, ok
+}
+
+// GetPersonalToken 从请求上下文中获取个人访问令牌，请求使用 JWT 认证时返回 false
+func GetPersonalToken(r *http.Request) (models.PersonalAccessToken, bool) {
+	token, ok := r.Context().Value(PersonalTokenKey).(models.PersonalAccessToken)
+	return token, ok
+}
+
+// RequireScope 限制个人访问令牌可以调用的接口，GET 请求需要 read，其余需要 write
+func RequireScope(read, write models.Scope, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := GetPersonalToken(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scope := write
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			scope = read
+		}
+		if scope == "" {
+			http.Error(w, "This endpoint cannot be used with a personal access token", http.StatusForbidden)
+			return
+		}
+		if !token.HasScope(scope) {
+			http.Error(w, fmt.Sprintf("Personal access token lacks the %s scope", scope), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,406 @@
+package migrate
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// 迁移文件按驱动分目录存放，命名格式为 <版本号>_<名称>.up.sql / .down.sql
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration 一个版本的迁移脚本
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status 某个迁移的应用状态，Failed 表示最近一次应用失败且尚未成功
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	Failed    bool
+	FailedAt  time.Time
+}
+
+// Migrator 在指定数据库上执行版本化迁移
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+	logger     *log.Logger
+}
+
+// New 加载驱动对应的内嵌迁移脚本，logger 记录重新执行失败版本时跳过的语句
+func New(db *sql.DB, driver string, logger *log.Logger) (*Migrator, error) {
+	migrations, err := load(driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, driver: driver, migrations: migrations, logger: logger}, nil
+}
+
+// load 读取并校验 migrations/<driver> 目录下的迁移脚本
+func load(driver string) ([]Migration, error) {
+	dir := path.Join("migrations", driver)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %q: %w", driver, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		versionStr, title, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", name)
+		}
+
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		} else if m.Name != title {
+			return nil, fmt.Errorf("conflicting names for migration %d: %q and %q", version, m.Name, title)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// ensureTable 创建记录已应用版本的 schema_migrations 表和记录失败版本的 schema_migration_failures 表
+func (m *Migrator) ensureTable() error {
+	if _, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`); err != nil {
+		return err
+	}
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migration_failures (
+		version INT PRIMARY KEY,
+		failed_at DATETIME NOT NULL
+	)`)
+	return err
+}
+
+// applied 返回已应用的版本及其应用时间
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	return m.versions("SELECT version, applied_at FROM schema_migrations")
+}
+
+// failed 返回应用失败且尚未成功的版本及其失败时间
+func (m *Migrator) failed() (map[int]time.Time, error) {
+	return m.versions("SELECT version, failed_at FROM schema_migration_failures")
+}
+
+// versions 执行返回 (版本, 时间) 的查询
+func (m *Migrator) versions(query string) (map[int]time.Time, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		versions[version] = appliedAt
+	}
+	return versions, rows.Err()
+}
+
+// Status 返回全部迁移及其应用状态，按版本升序
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	failed, err := m.failed()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		st := Status{Migration: mig}
+		st.AppliedAt, st.Applied = applied[mig.Version]
+		st.FailedAt, st.Failed = failed[mig.Version]
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
+
+// Pending 返回尚未应用的迁移
+func (m *Migrator) Pending() ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, st := range statuses {
+		if !st.Applied {
+			pending = append(pending, st.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Current 返回已应用的最高版本，未应用任何迁移时为 0
+func (m *Migrator) Current() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	current := 0
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	return current, nil
+}
+
+// Latest 返回内嵌迁移中的最高版本
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up 应用全部待执行的迁移，返回应用的数量
+func (m *Migrator) Up() (int, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, mig := range pending {
+		if err := m.apply(mig, true); err != nil {
+			return i, err
+		}
+	}
+	return len(pending), nil
+}
+
+// Down 回滚最近应用的一个迁移
+func (m *Migrator) Down() error {
+	current, err := m.Current()
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		return nil
+	}
+	return m.To(current - 1)
+}
+
+// To 将数据库迁移到指定版本，高于该版本的已应用迁移会被回滚
+func (m *Migrator) To(version int) error {
+	if version < 0 || version > m.Latest() {
+		return fmt.Errorf("unknown migration version %d (latest is %d)", version, m.Latest())
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	// 先按降序回滚高于目标版本的迁移
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if mig.Version <= version {
+			break
+		}
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if err := m.apply(mig, false); err != nil {
+			return err
+		}
+	}
+
+	// 再按升序应用不高于目标版本的迁移
+	for _, mig := range m.migrations {
+		if mig.Version > version {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if err := m.apply(mig, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply 在一个事务中执行迁移脚本并更新 schema_migrations，应用失败时记录到 schema_migration_failures
+func (m *Migrator) apply(mig Migration, up bool) error {
+	if !up {
+		if mig.Down == "" {
+			return fmt.Errorf("migration %d_%s cannot be rolled back: no down script", mig.Version, mig.Name)
+		}
+		return m.exec(mig, mig.Down, false, false)
+	}
+
+	failed, err := m.failed()
+	if err != nil {
+		return err
+	}
+	_, resuming := failed[mig.Version]
+	if err := m.exec(mig, mig.Up, true, resuming); err != nil {
+		if ferr := m.recordFailure(mig.Version); ferr != nil {
+			m.logger.Printf("Error recording failed migration %d_%s: %v", mig.Version, mig.Name, ferr)
+		}
+		return err
+	}
+	return nil
+}
+
+// recordFailure 记录版本应用失败的时间
+func (m *Migrator) recordFailure(version int) error {
+	if _, err := m.db.Exec("DELETE FROM schema_migration_failures WHERE version = ?", version); err != nil {
+		return err
+	}
+	_, err := m.db.Exec("INSERT INTO schema_migration_failures (version, failed_at) VALUES (?, ?)", version, time.Now().UTC())
+	return err
+}
+
+// exec 执行脚本的全部语句。MySQL 的 DDL 会隐式提交，resuming 时跳过上次失败前已生效的 DDL
+func (m *Migrator) exec(mig Migration, script string, up, resuming bool) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range splitStatements(script) {
+		if _, err := tx.Exec(stmt); err != nil {
+			if resuming && m.driver == "mysql" && alreadyApplied(err) {
+				m.logger.Printf("Migration %d_%s: skipping statement applied by the failed run: %v", mig.Version, mig.Name, err)
+				continue
+			}
+			return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+	}
+
+	if up {
+		_, err = tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+			mig.Version, mig.Name, time.Now().UTC())
+		if err == nil {
+			_, err = tx.Exec("DELETE FROM schema_migration_failures WHERE version = ?", mig.Version)
+		}
+	} else {
+		_, err = tx.Exec("DELETE FROM schema_migrations WHERE version = ?", mig.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// MySQL 重复执行 DDL 时的错误码
+const (
+	errTableExists   = 1050 // ER_TABLE_EXISTS_ERROR
+	errDupFieldName  = 1060 // ER_DUP_FIELDNAME
+	errDupKeyName    = 1061 // ER_DUP_KEYNAME
+	errCantDropField = 1091 // ER_CANT_DROP_FIELD_OR_KEY
+	errFKDupName     = 1826 // ER_FK_DUP_NAME
+)
+
+// alreadyApplied 判断 MySQL 错误是否表示该 DDL 语句已经生效
+func alreadyApplied(err error) bool {
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) {
+		return false
+	}
+	switch myErr.Number {
+	case errTableExists, errDupFieldName, errDupKeyName, errCantDropField, errFKDupName:
+		return true
+	}
+	return false
+}
+
+// splitStatements 按分号拆分脚本并去掉 -- 注释行，字符串字面量里不应使用分号
+func splitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	var stmts []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
@@ -0,0 +1,144 @@
+package migrate_test
+
+import (
+	"io"
+	"log"
+	"testing"
+
+	"github.com/joy_project/todo-list-backend/internal/database"
+	"github.com/joy_project/todo-list-backend/internal/migrate"
+	"github.com/joy_project/todo-list-backend/internal/storetest"
+)
+
+// newMigrator 在新的 SQLite 数据库上创建迁移器
+func newMigrator(t *testing.T) (*migrate.Migrator, *database.Store) {
+	t.Helper()
+
+	db := storetest.OpenSQLite(t)
+	m, err := migrate.New(db.DB(), database.DriverSQLite, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	return m, db
+}
+
+// wantVersion 要求数据库处于 version，且状态中恰好是不高于 version 的迁移已应用
+func wantVersion(t *testing.T, m *migrate.Migrator, version int) {
+	t.Helper()
+
+	current, err := m.Current()
+	if err != nil {
+		t.Fatalf("current: %v", err)
+	}
+	if current != version {
+		t.Fatalf("current version %d, want %d", current, version)
+	}
+
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if len(statuses) != m.Latest() {
+		t.Fatalf("status lists %d migrations, want %d", len(statuses), m.Latest())
+	}
+	for _, st := range statuses {
+		if want := st.Version <= version; st.Applied != want {
+			t.Errorf("migration %d_%s applied = %v, want %v", st.Version, st.Name, st.Applied, want)
+		}
+	}
+
+	pending, err := m.Pending()
+	if err != nil {
+		t.Fatalf("pending: %v", err)
+	}
+	if len(pending) != m.Latest()-version {
+		t.Errorf("%d pending migrations, want %d", len(pending), m.Latest()-version)
+	}
+}
+
+func TestUpDownTo(t *testing.T) {
+	m, _ := newMigrator(t)
+	latest := m.Latest()
+	wantVersion(t, m, 0)
+
+	n, err := m.Up()
+	if err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if n != latest {
+		t.Errorf("up applied %d migrations, want %d", n, latest)
+	}
+	wantVersion(t, m, latest)
+
+	if n, err := m.Up(); err != nil || n != 0 {
+		t.Errorf("second up = %d, %v; want 0, nil", n, err)
+	}
+
+	if err := m.Down(); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	wantVersion(t, m, latest-1)
+
+	if err := m.To(5); err != nil {
+		t.Fatalf("to 5: %v", err)
+	}
+	wantVersion(t, m, 5)
+
+	// 全部回滚后再次应用，检查每个 down 脚本都能把库恢复到可重新迁移的状态
+	if err := m.To(0); err != nil {
+		t.Fatalf("to 0: %v", err)
+	}
+	wantVersion(t, m, 0)
+	if err := m.To(latest); err != nil {
+		t.Fatalf("to %d: %v", latest, err)
+	}
+	wantVersion(t, m, latest)
+
+	if err := m.To(latest + 1); err == nil {
+		t.Errorf("to %d: want error for unknown version", latest+1)
+	}
+}
+
+func TestUpRerunAfterFailure(t *testing.T) {
+	m, db := newMigrator(t)
+	if err := m.To(4); err != nil {
+		t.Fatalf("to 4: %v", err)
+	}
+
+	// 与 0005_create_tags 冲突的表使该版本失败，之前的版本保持已应用
+	if _, err := db.DB().Exec("CREATE TABLE tags (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("create conflicting table: %v", err)
+	}
+	if n, err := m.Up(); err == nil || n != 0 {
+		t.Fatalf("up = %d, %v; want 0 and an error", n, err)
+	}
+	wantVersion(t, m, 4)
+
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	for _, st := range statuses {
+		if want := st.Version == 5; st.Failed != want {
+			t.Errorf("migration %d_%s failed = %v, want %v", st.Version, st.Name, st.Failed, want)
+		}
+	}
+
+	if _, err := db.DB().Exec("DROP TABLE tags"); err != nil {
+		t.Fatalf("drop conflicting table: %v", err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("rerun up: %v", err)
+	}
+	wantVersion(t, m, m.Latest())
+
+	statuses, err = m.Status()
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	for _, st := range statuses {
+		if st.Failed {
+			t.Errorf("migration %d_%s still marked failed after it was applied", st.Version, st.Name)
+		}
+	}
+}
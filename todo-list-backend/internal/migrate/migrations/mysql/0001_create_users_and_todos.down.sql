@@ -0,0 +1,3 @@
+-- 先删除有外键约束的表
+DROP TABLE IF EXISTS todos;
+DROP TABLE IF EXISTS users;
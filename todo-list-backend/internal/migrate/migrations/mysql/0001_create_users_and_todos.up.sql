@@ -1,6 +1,4 @@
-CREATE DATABASE IF NOT EXISTS todo_list;
-USE todo_list;
-
+-- 使用 IF NOT EXISTS，使通过旧版 setup_db.go 建好的库可以直接纳入迁移管理
 CREATE TABLE IF NOT EXISTS users (
     id INT AUTO_INCREMENT PRIMARY KEY,
     username VARCHAR(50) NOT NULL UNIQUE,
@@ -20,4 +18,3 @@ CREATE TABLE IF NOT EXISTS todos (
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
-
@@ -0,0 +1,10 @@
+-- 新索引可能已替代了外键 user_id 的隐式索引，删除前先补一个单列索引
+CREATE INDEX idx_todos_user ON todos (user_id);
+DROP INDEX idx_todos_user_due ON todos;
+
+ALTER TABLE todos
+    DROP COLUMN due_at,
+    DROP COLUMN start_at,
+    DROP COLUMN remind_at;
+
+ALTER TABLE users DROP COLUMN timezone;
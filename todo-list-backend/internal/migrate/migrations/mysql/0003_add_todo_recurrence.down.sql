@@ -0,0 +1,3 @@
+ALTER TABLE todos
+    DROP COLUMN recurrence,
+    DROP COLUMN occurrence;
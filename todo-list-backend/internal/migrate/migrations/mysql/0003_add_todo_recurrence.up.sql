@@ -0,0 +1,3 @@
+ALTER TABLE todos
+    ADD COLUMN recurrence VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN occurrence INT NOT NULL DEFAULT 0;
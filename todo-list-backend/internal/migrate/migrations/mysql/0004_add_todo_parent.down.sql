@@ -0,0 +1,2 @@
+ALTER TABLE todos DROP FOREIGN KEY fk_todos_parent;
+ALTER TABLE todos DROP COLUMN parent_id;
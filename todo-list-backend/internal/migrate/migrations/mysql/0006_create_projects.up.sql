@@ -0,0 +1,23 @@
+CREATE TABLE projects (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#808080',
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    position INT NOT NULL DEFAULT 0,
+    inbox BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_projects_user (user_id, position),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+ALTER TABLE todos
+    ADD COLUMN project_id INT NULL,
+    ADD CONSTRAINT fk_todos_project FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE SET NULL;
+
+-- 为已有用户创建收件箱，并把原有待办事项归入其中；已有收件箱的用户跳过
+INSERT INTO projects (user_id, name, inbox) SELECT u.id, 'Inbox', TRUE FROM users u
+WHERE NOT EXISTS (SELECT 1 FROM projects p WHERE p.user_id = u.id AND p.inbox = TRUE);
+
+UPDATE todos SET project_id = (SELECT p.id FROM projects p WHERE p.user_id = todos.user_id AND p.inbox = TRUE);
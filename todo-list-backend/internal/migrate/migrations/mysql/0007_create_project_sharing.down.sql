@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS project_invitations;
+DROP TABLE IF EXISTS project_members;
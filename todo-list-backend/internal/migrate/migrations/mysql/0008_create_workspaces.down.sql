@@ -0,0 +1,15 @@
+-- 回滚后标签名恢复为按用户唯一，不同工作区中的同名标签会导致回滚失败
+ALTER TABLE tags ADD UNIQUE KEY uq_tags_user_name (user_id, name);
+ALTER TABLE tags DROP INDEX uq_tags_user_workspace_name;
+ALTER TABLE tags DROP FOREIGN KEY fk_tags_workspace;
+ALTER TABLE tags DROP COLUMN workspace_id;
+
+DROP INDEX idx_todos_workspace ON todos;
+ALTER TABLE todos DROP FOREIGN KEY fk_todos_workspace;
+ALTER TABLE todos DROP COLUMN workspace_id;
+
+ALTER TABLE projects DROP FOREIGN KEY fk_projects_workspace;
+ALTER TABLE projects DROP COLUMN workspace_id;
+
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,51 @@
+CREATE TABLE workspaces (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+CREATE TABLE workspace_members (
+    workspace_id INT NOT NULL,
+    user_id INT NOT NULL,
+    role VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, user_id),
+    INDEX idx_workspace_members_user (user_id),
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 为每个已有用户创建一个个人工作区（ID 与用户ID相同），原有数据全部归入其中；插入按主键去重
+INSERT IGNORE INTO workspaces (id, name) SELECT id, CONCAT(username, '''s workspace') FROM users;
+
+INSERT IGNORE INTO workspace_members (workspace_id, user_id, role) SELECT id, id, 'admin' FROM users;
+
+ALTER TABLE projects
+    ADD COLUMN workspace_id INT NULL,
+    ADD CONSTRAINT fk_projects_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+UPDATE projects SET workspace_id = user_id;
+
+-- 共享项目的协作者加入项目所在的工作区，保证升级后仍能访问
+INSERT IGNORE INTO workspace_members (workspace_id, user_id, role)
+SELECT DISTINCT p.workspace_id, m.user_id, 'member' FROM project_members m JOIN projects p ON p.id = m.project_id;
+
+ALTER TABLE todos
+    ADD COLUMN workspace_id INT NULL,
+    ADD CONSTRAINT fk_todos_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+-- 待办事项属于所在项目的工作区，共享项目中其他成员创建的待办事项也随项目归入该工作区
+UPDATE todos SET workspace_id = COALESCE((SELECT p.workspace_id FROM projects p WHERE p.id = todos.project_id), user_id);
+
+CREATE INDEX idx_todos_workspace ON todos (workspace_id, created_at);
+
+-- 标签名在同一工作区内按用户唯一；先建新索引，保证 user_id 外键始终有可用的索引
+ALTER TABLE tags
+    ADD COLUMN workspace_id INT NULL,
+    ADD CONSTRAINT fk_tags_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+UPDATE tags SET workspace_id = user_id;
+
+ALTER TABLE tags ADD UNIQUE KEY uq_tags_user_workspace_name (user_id, workspace_id, name);
+ALTER TABLE tags DROP INDEX uq_tags_user_name;
@@ -0,0 +1,2 @@
+ALTER TABLE todos DROP FOREIGN KEY fk_todos_assignee;
+ALTER TABLE todos DROP COLUMN assignee_id;
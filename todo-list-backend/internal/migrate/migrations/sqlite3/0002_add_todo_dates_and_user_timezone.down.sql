@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_todos_user_due;
+
+ALTER TABLE todos DROP COLUMN due_at;
+ALTER TABLE todos DROP COLUMN start_at;
+ALTER TABLE todos DROP COLUMN remind_at;
+
+ALTER TABLE users DROP COLUMN timezone;
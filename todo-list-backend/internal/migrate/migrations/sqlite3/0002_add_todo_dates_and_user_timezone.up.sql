@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE todos ADD COLUMN due_at DATETIME NULL;
+ALTER TABLE todos ADD COLUMN start_at DATETIME NULL;
+ALTER TABLE todos ADD COLUMN remind_at DATETIME NULL;
+
+CREATE INDEX idx_todos_user_due ON todos (user_id, due_at);
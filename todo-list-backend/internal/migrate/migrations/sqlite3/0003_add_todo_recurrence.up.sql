@@ -0,0 +1,2 @@
+ALTER TABLE todos ADD COLUMN recurrence VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE todos ADD COLUMN occurrence INTEGER NOT NULL DEFAULT 0;
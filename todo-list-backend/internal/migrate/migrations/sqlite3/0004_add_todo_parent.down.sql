@@ -0,0 +1,25 @@
+-- SQLite 不能删除带外键约束的列，只能重建表
+DROP INDEX IF EXISTS idx_todos_parent;
+
+CREATE TABLE todos_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title VARCHAR(255) NOT NULL,
+    completed BOOLEAN DEFAULT FALSE,
+    priority TEXT CHECK (priority IN ('low', 'medium', 'high')) DEFAULT 'medium',
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    due_at DATETIME NULL,
+    start_at DATETIME NULL,
+    remind_at DATETIME NULL,
+    recurrence VARCHAR(255) NOT NULL DEFAULT '',
+    occurrence INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO todos_old (id, title, completed, priority, user_id, created_at, updated_at, due_at, start_at, remind_at, recurrence, occurrence)
+SELECT id, title, completed, priority, user_id, created_at, updated_at, due_at, start_at, remind_at, recurrence, occurrence FROM todos;
+
+DROP TABLE todos;
+ALTER TABLE todos_old RENAME TO todos;
+
+CREATE INDEX idx_todos_user_due ON todos (user_id, due_at);
@@ -0,0 +1,3 @@
+ALTER TABLE todos ADD COLUMN parent_id INTEGER NULL REFERENCES todos(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_todos_parent ON todos (parent_id);
@@ -0,0 +1,23 @@
+CREATE TABLE projects (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#808080',
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL DEFAULT 0,
+    inbox BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_projects_user ON projects (user_id, position);
+
+-- 不加外键约束：SQLite 不能删除带外键的列，删除项目时由应用把待办事项移到收件箱
+ALTER TABLE todos ADD COLUMN project_id INTEGER NULL;
+
+CREATE INDEX idx_todos_project ON todos (project_id);
+
+-- 为已有用户创建收件箱，并把原有待办事项归入其中
+INSERT INTO projects (user_id, name, inbox) SELECT id, 'Inbox', TRUE FROM users;
+
+UPDATE todos SET project_id = (SELECT p.id FROM projects p WHERE p.user_id = todos.user_id AND p.inbox = TRUE);
@@ -0,0 +1,30 @@
+-- 回滚后标签名恢复为按用户唯一，不同工作区中的同名标签会导致回滚失败
+CREATE TABLE todo_tags_backup AS SELECT todo_id, tag_id FROM todo_tags;
+
+CREATE TABLE tags_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#808080',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+INSERT INTO tags_old (id, user_id, name, color, created_at, updated_at)
+SELECT id, user_id, name, color, created_at, updated_at FROM tags;
+
+DROP TABLE tags;
+ALTER TABLE tags_old RENAME TO tags;
+
+INSERT INTO todo_tags (todo_id, tag_id) SELECT todo_id, tag_id FROM todo_tags_backup;
+DROP TABLE todo_tags_backup;
+
+DROP INDEX IF EXISTS idx_todos_workspace;
+ALTER TABLE todos DROP COLUMN workspace_id;
+
+DROP INDEX IF EXISTS idx_projects_workspace;
+ALTER TABLE projects DROP COLUMN workspace_id;
+
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
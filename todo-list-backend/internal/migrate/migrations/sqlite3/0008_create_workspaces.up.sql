@@ -0,0 +1,62 @@
+CREATE TABLE workspaces (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE workspace_members (
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX idx_workspace_members_user ON workspace_members (user_id);
+
+-- 为每个已有用户创建一个个人工作区（ID 与用户ID相同），原有数据全部归入其中
+INSERT INTO workspaces (id, name) SELECT id, username || '''s workspace' FROM users;
+
+INSERT INTO workspace_members (workspace_id, user_id, role) SELECT id, id, 'admin' FROM users;
+
+-- 与 project_id 相同，不加外键约束以便回滚时可以直接删除列
+ALTER TABLE projects ADD COLUMN workspace_id INTEGER NULL;
+
+UPDATE projects SET workspace_id = user_id;
+
+-- 共享项目的协作者加入项目所在的工作区，保证升级后仍能访问
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT DISTINCT p.workspace_id, m.user_id, 'member' FROM project_members m JOIN projects p ON p.id = m.project_id;
+
+CREATE INDEX idx_projects_workspace ON projects (workspace_id);
+
+ALTER TABLE todos ADD COLUMN workspace_id INTEGER NULL;
+
+-- 待办事项属于所在项目的工作区，共享项目中其他成员创建的待办事项也随项目归入该工作区
+UPDATE todos SET workspace_id = COALESCE((SELECT p.workspace_id FROM projects p WHERE p.id = todos.project_id), user_id);
+
+CREATE INDEX idx_todos_workspace ON todos (workspace_id, created_at);
+
+-- SQLite 不能删除 UNIQUE 约束，重建 tags 表前先备份 todo_tags
+CREATE TABLE todo_tags_backup AS SELECT todo_id, tag_id FROM todo_tags;
+
+CREATE TABLE tags_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id INTEGER NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#808080',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, workspace_id, name)
+);
+
+INSERT INTO tags_new (id, user_id, workspace_id, name, color, created_at, updated_at)
+SELECT id, user_id, user_id, name, color, created_at, updated_at FROM tags;
+
+DROP TABLE tags;
+ALTER TABLE tags_new RENAME TO tags;
+
+INSERT INTO todo_tags (todo_id, tag_id) SELECT todo_id, tag_id FROM todo_tags_backup;
+DROP TABLE todo_tags_backup;
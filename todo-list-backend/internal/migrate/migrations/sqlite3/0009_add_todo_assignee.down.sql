@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_todos_assignee;
+ALTER TABLE todos DROP COLUMN assignee_id;
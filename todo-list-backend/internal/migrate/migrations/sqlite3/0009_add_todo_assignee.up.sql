@@ -0,0 +1,4 @@
+-- 与 project_id 相同，不加外键约束以便回滚时可以直接删除列
+ALTER TABLE todos ADD COLUMN assignee_id INTEGER NULL;
+
+CREATE INDEX idx_todos_assignee ON todos (assignee_id);
@@ -0,0 +1,6 @@
+-- 已有的待办事项按创建顺序排列：id 递增，间隔与 store.PositionStep 相同
+ALTER TABLE todos ADD COLUMN position REAL NOT NULL DEFAULT 0;
+
+UPDATE todos SET position = id * 1024;
+
+CREATE INDEX idx_todos_position ON todos (project_id, parent_id, position);
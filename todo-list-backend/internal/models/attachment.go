@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Attachment 待办事项的附件，内容按 SHA-256 去重保存在 blob 存储中
+type Attachment struct {
+	ID          int       `json:"id"`
+	TodoID      int       `json:"todo_id"`
+	UserID      int       `json:"user_id"` // 上传者
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"` // 由服务端根据内容检测
+	Size        int64     `json:"size"`
+	Hash        string    `json:"sha256"` // 内容的 SHA-256 摘要
+	CreatedAt   time.Time `json:"created_at"`
+}
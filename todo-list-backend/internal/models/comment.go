@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Comment 待办事项下的评论，正文为 Markdown 文本，服务端原样保存，由客户端负责渲染
+type Comment struct {
+	ID        int       `json:"id"`
+	TodoID    int       `json:"todo_id"`
+	UserID    int       `json:"user_id"`  // 只读，作者
+	Username  string    `json:"username"` // 只读，作者的用户名
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Project 待办事项所在的清单，每个用户注册时会自动创建一个收件箱
+type Project struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	WorkspaceID int       `json:"workspace_id"` // 只读，所属工作区
+	Name        string    `json:"name"`
+	Color       string    `json:"color"`
+	Archived    bool      `json:"archived"`
+	Position    int       `json:"position"` // 列表中的排序位置，从小到大
+	Inbox       bool      `json:"inbox"`    // 只读，收件箱不能删除、归档或共享
+	Role        Role      `json:"role"`     // 只读，当前用户在该项目中的角色
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
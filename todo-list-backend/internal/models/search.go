@@ -0,0 +1,15 @@
+package models
+
+// SearchResult 全文搜索的一条结果，结果按 Score 从高到低排列
+type SearchResult struct {
+	Todo       Todo              `json:"todo"`
+	Score      float64           `json:"score"`
+	Highlights []SearchHighlight `json:"highlights"`
+}
+
+// SearchHighlight 命中字段的高亮文本，已做 HTML 转义，命中部分用 <mark> 包围
+type SearchHighlight struct {
+	Field     string `json:"field"` // title 或 comment
+	CommentID int    `json:"comment_id,omitempty"`
+	Snippet   string `json:"snippet"`
+}
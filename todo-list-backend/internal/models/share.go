@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// Role 协作者在共享项目中的角色
+type Role string
+
+const (
+	// RoleViewer 只能查看项目及其中的待办事项
+	RoleViewer Role = "viewer"
+	// RoleEditor 可以创建、修改、移动和删除项目中的待办事项
+	RoleEditor Role = "editor"
+	// RoleOwner 还可以修改、删除项目以及管理成员和邀请
+	RoleOwner Role = "owner"
+)
+
+var roleRank = map[Role]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+// Valid 判断是否为已知角色
+func (r Role) Valid() bool {
+	return roleRank[r] > 0
+}
+
+// Allows 判断该角色是否具有 required 角色的全部权限
+func (r Role) Allows(required Role) bool {
+	return roleRank[r] >= roleRank[required]
+}
+
+// Member 项目成员，项目创建者总是以 owner 角色出现在成员列表中
+type Member struct {
+	UserID    int       `json:"user_id"`
+	Username  string    `json:"username"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InvitationStatus 邀请的状态
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationDeclined InvitationStatus = "declined"
+)
+
+// Invitation 邀请已注册用户加入项目
+type Invitation struct {
+	ID          int              `json:"id"`
+	ProjectID   int              `json:"project_id"`
+	ProjectName string           `json:"project_name"`
+	InviterID   int              `json:"inviter_id"`
+	InviteeID   int              `json:"invitee_id"`
+	Role        Role             `json:"role"`
+	Status      InvitationStatus `json:"status"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// InviteRequest 通过邮箱邀请用户的请求
+type InviteRequest struct {
+	Email string `json:"email"`
+	Role  Role   `json:"role"`
+}
+
+// UpdateMemberRequest 修改成员角色的请求
+type UpdateMemberRequest struct {
+	Role Role `json:"role"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SmartList 智能列表：用户保存的具名过滤条件，Query 使用与 GET /todos 相同的参数
+type SmartList struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`      // 只读，创建者，智能列表只对创建者可见
+	WorkspaceID int       `json:"workspace_id"` // 只读，所属工作区
+	Name        string    `json:"name"`
+	Query       string    `json:"query"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
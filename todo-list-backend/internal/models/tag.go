@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+type Tag struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	WorkspaceID int       `json:"workspace_id"` // 只读，所属工作区
+	Name        string    `json:"name"`
+	Color       string    `json:"color"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// AttachTagRequest 为待办事项添加标签的请求
+type AttachTagRequest struct {
+	TagID int `json:"tag_id"`
+}
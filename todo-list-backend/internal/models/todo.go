@@ -3,11 +3,37 @@ package models
 import "time"
 
 type Todo struct {
-	ID        int       `json:"id"`
-	Title     string    `json:"title"`
-	Completed bool      `json:"completed"`
-	Priority  string    `json:"priority"`
-	UserID    int       `json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           int        `json:"id"`
+	Title        string     `json:"title"`
+	Completed    bool       `json:"completed"`
+	Priority     string     `json:"priority"`
+	UserID       int        `json:"user_id"`       // 创建者；访问权限由所在项目决定
+	AssigneeID   *int       `json:"assignee_id"`   // 负责人，必须是同一工作区的成员；负责人即使不在项目中也可以查看和完成该待办
+	WorkspaceID  int        `json:"workspace_id"`  // 只读，所属工作区
+	ParentID     *int       `json:"parent_id"`     // 父待办ID，顶层待办为 null
+	ProjectID    *int       `json:"project_id"`    // 所属项目，未指定时创建在收件箱中；子项总是与父项在同一项目
+	DueAt        *time.Time `json:"due_at"`        // 截止时间，未设置时为 null
+	StartAt      *time.Time `json:"start_at"`      // 计划开始时间
+	RemindAt     *time.Time `json:"remind_at"`     // 提醒时间
+	Recurrence   string     `json:"recurrence"`    // RRULE 格式的重复规则，如 "FREQ=WEEKLY;BYDAY=MO"，为空表示不重复
+	Occurrence   int        `json:"occurrence"`    // 当前实例是重复序列中的第几次，由服务端维护
+	Position     float64    `json:"position"`      // 只读，同级（同一项目和父项）之间的手动排序位置，通过 POST /todos/{id}/move 调整
+	Tags         []Tag      `json:"tags"`          // 只读，通过 /todos/{id}/tags 添加或移除
+	CommentCount int        `json:"comment_count"` // 只读，评论数
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TodoNode 子树中的一个节点，用于返回层级结构
+type TodoNode struct {
+	Todo
+	Children []TodoNode `json:"children"`
+}
+
+// MoveTodoRequest 移动子树的请求，依次按 BeforeID、AfterID、ProjectID、ParentID 确定目标位置
+type MoveTodoRequest struct {
+	ParentID  *int `json:"parent_id"`
+	ProjectID *int `json:"project_id"`
+	BeforeID  *int `json:"before_id"`
+	AfterID   *int `json:"after_id"`
 }
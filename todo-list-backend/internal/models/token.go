@@ -0,0 +1,117 @@
+package models
+
+import "time"
+
+// RefreshToken 刷新令牌的存储记录，只保存摘要，FamilyID 也是访问令牌中的会话ID
+type RefreshToken struct {
+	ID          int
+	UserID      int
+	WorkspaceID int // 刷新时签发的访问令牌进入的工作区，切换工作区时随之更新
+	FamilyID    string
+	TokenHash   string
+	ExpiresAt   time.Time
+	UsedAt      *time.Time // 已轮换的时间，再次使用视为令牌泄露
+	RevokedAt   *time.Time // 注销或检测到重用时撤销
+	CreatedAt   time.Time
+}
+
+// RefreshRequest 使用刷新令牌换取新令牌的请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse 刷新后返回的新访问令牌和新刷新令牌，旧的刷新令牌随即失效
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // 访问令牌的有效秒数
+	WorkspaceID  int    `json:"workspace_id"`
+}
+
+// Scope 个人访问令牌的权限范围，格式为 资源:操作。读取类请求（GET）需要 :read，其余请求需要 :write
+type Scope string
+
+const (
+	ScopeTodosRead       Scope = "todos:read" // 待办事项、子任务、评论、附件、搜索和智能列表
+	ScopeTodosWrite      Scope = "todos:write"
+	ScopeTagsRead        Scope = "tags:read"
+	ScopeTagsWrite       Scope = "tags:write"
+	ScopeProjectsRead    Scope = "projects:read" // 项目、成员和邀请
+	ScopeProjectsWrite   Scope = "projects:write"
+	ScopeWorkspacesRead  Scope = "workspaces:read"
+	ScopeWorkspacesWrite Scope = "workspaces:write"
+	ScopeProfileRead     Scope = "profile:read"
+	ScopeProfileWrite    Scope = "profile:write"
+)
+
+// Scopes 全部可授予的权限范围
+var Scopes = []Scope{
+	ScopeTodosRead, ScopeTodosWrite, ScopeTagsRead, ScopeTagsWrite, ScopeProjectsRead, ScopeProjectsWrite,
+	ScopeWorkspacesRead, ScopeWorkspacesWrite, ScopeProfileRead, ScopeProfileWrite,
+}
+
+// Valid 判断是否为已知的权限范围
+func (s Scope) Valid() bool {
+	for _, scope := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// PersonalAccessToken 用户为脚本和集成创建的长期令牌，限定在创建时所在的工作区和 Scopes 中
+type PersonalAccessToken struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	WorkspaceID int        `json:"workspace_id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	TokenHash   string     `json:"-"`
+	Scopes      []Scope    `json:"scopes"`
+	ExpiresAt   *time.Time `json:"expires_at"` // 为空时永不过期
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// HasScope 判断令牌是否拥有权限范围 scope
+func (t PersonalAccessToken) HasScope(scope Scope) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatePersonalTokenRequest 创建个人访问令牌的请求
+type CreatePersonalTokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []Scope    `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreatePersonalTokenResponse 创建成功后返回的令牌记录和令牌本身，令牌之后无法再次查看
+type CreatePersonalTokenResponse struct {
+	PersonalAccessToken
+	Token string `json:"token"`
+}
+
+// TokenPurpose 通过邮件发送的一次性令牌的用途
+type TokenPurpose string
+
+const (
+	PurposeVerifyEmail   TokenPurpose = "verify_email"
+	PurposeResetPassword TokenPurpose = "reset_password"
+)
+
+// EmailToken 通过邮件发送的一次性令牌，只保存摘要。使用后或同一用途签发新令牌后即失效
+type EmailToken struct {
+	ID        int
+	UserID    int
+	Purpose   TokenPurpose
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
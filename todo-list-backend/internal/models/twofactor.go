@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// TOTP 用户的两步验证密钥。EnabledAt 为空表示已开始绑定但还没有用验证码确认，登录时不要求验证码
+type TOTP struct {
+	UserID    int
+	Secret    string
+	EnabledAt *time.Time
+	// LastStep 最近一次验证通过的时间步，只接受更晚的时间步，防止验证码被重放
+	LastStep  int64
+	CreatedAt time.Time
+}
+
+// TwoFactorStatus GET /2fa 的响应
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// EnrollTwoFactorResponse 开始绑定两步验证时返回的密钥，ProvisioningURI 可以生成二维码供验证器应用扫描
+type EnrollTwoFactorResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TwoFactorCodeRequest 提交验证器应用中的验证码，部分操作也接受恢复码
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// DisableTwoFactorRequest 关闭两步验证需要同时提供密码和验证码（或恢复码）
+type DisableTwoFactorRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// RecoveryCodesResponse 新生成的恢复码，只在生成时返回一次
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorChallengeResponse 开启了两步验证的用户密码验证通过后的登录响应
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"`
+}
+
+// TwoFactorLoginRequest 登录第二步，Code 可以是验证码或恢复码
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
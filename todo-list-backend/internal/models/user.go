@@ -7,26 +7,61 @@ type User struct {
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
 	Password  string    `json:"-"` // 不在JSON响应中返回密码
+	Timezone  string    `json:"timezone"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// EmailVerifiedAt 通过验证邮件确认邮箱的时间，未验证时为空
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
 }
 
 type UserResponse struct {
 	ID        int       `json:"id"`
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
+	Timezone  string    `json:"timezone"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Token     string    `json:"token,omitempty"`
+	// EmailVerified 邮箱是否已通过验证邮件确认
+	EmailVerified bool `json:"email_verified"`
+	// RefreshToken 登录和注册时签发的刷新令牌，用于 POST /token/refresh
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// ExpiresIn 访问令牌的有效秒数
+	ExpiresIn int `json:"expires_in,omitempty"`
+	// WorkspaceID 令牌对应的当前工作区
+	WorkspaceID int `json:"workspace_id,omitempty"`
 }
 
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// WorkspaceID 登录后进入的工作区，为 0 时使用最早加入的工作区
+	WorkspaceID int `json:"workspace_id,omitempty"`
 }
 
 type RegisterRequest struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Timezone string `json:"timezone"` // IANA 时区名，为空时使用 UTC
+}
+
+type UpdateProfileRequest struct {
+	Timezone string `json:"timezone"`
+}
+
+// VerifyEmailRequest 提交验证邮件中的令牌
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// ForgotPasswordRequest 请求发送密码重置邮件
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest 使用重置邮件中的令牌设置新密码
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
 }
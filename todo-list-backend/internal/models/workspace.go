@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// WorkspaceRole 用户在工作区中的角色
+type WorkspaceRole string
+
+const (
+	// WorkspaceAdmin 可以修改工作区并管理成员
+	WorkspaceAdmin WorkspaceRole = "admin"
+	// WorkspaceMember 只能使用工作区中的数据
+	WorkspaceMember WorkspaceRole = "member"
+)
+
+// Valid 判断是否为已知角色
+func (r WorkspaceRole) Valid() bool {
+	return r == WorkspaceAdmin || r == WorkspaceMember
+}
+
+// Workspace 团队工作区，项目、待办事项和标签都属于某个工作区，不同工作区之间的数据完全隔离
+type Workspace struct {
+	ID        int           `json:"id"`
+	Name      string        `json:"name"`
+	Role      WorkspaceRole `json:"role"` // 只读，当前用户在该工作区中的角色
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// WorkspaceMemberInfo 工作区成员
+type WorkspaceMemberInfo struct {
+	UserID    int           `json:"user_id"`
+	Username  string        `json:"username"`
+	Email     string        `json:"email"`
+	Role      WorkspaceRole `json:"role"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// AddWorkspaceMemberRequest 通过邮箱把已注册用户加入工作区的请求
+type AddWorkspaceMemberRequest struct {
+	Email string        `json:"email"`
+	Role  WorkspaceRole `json:"role"`
+}
+
+// UpdateWorkspaceMemberRequest 修改工作区成员角色的请求
+type UpdateWorkspaceMemberRequest struct {
+	Role WorkspaceRole `json:"role"`
+}
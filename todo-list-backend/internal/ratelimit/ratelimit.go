@@ -0,0 +1,59 @@
+// Package ratelimit 进程内按键计数的固定窗口限流器，多实例部署时每个实例分别计数
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter 在每个 window 内最多允许同一个键通过 limit 次，可以并发使用
+type Limiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	windows   map[string]*window
+	lastSweep time.Time
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+// New 创建限流器
+func New(limit int, period time.Duration) *Limiter {
+	return &Limiter{limit: limit, window: period, windows: make(map[string]*window), lastSweep: time.Now()}
+}
+
+// Allow 记录键 key 的一次请求。超过限制时不计数，返回 false 和距离当前窗口结束的时间
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// sweep 每个窗口周期清理一次已结束的窗口，避免键无限增长
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.window {
+			delete(l.windows, key)
+		}
+	}
+	l.lastSweep = now
+}
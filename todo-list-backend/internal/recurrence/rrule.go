@@ -0,0 +1,287 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency 重复频率
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxIterations 查找下一次发生时间时的最大迭代次数，防止规则永远无法命中时死循环
+const maxIterations = 1000
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Rule RFC 5545 RRULE 的子集：FREQ、INTERVAL、BYDAY、BYMONTHDAY、UNTIL 和 COUNT
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	Until      *time.Time
+	Count      int
+}
+
+// Parse 解析形如 "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR" 的规则，可带 "RRULE:" 前缀，不区分大小写
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+	if s == "" {
+		return nil, errors.New("empty rule")
+	}
+
+	rule := &Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate %s", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			switch f := Frequency(value); f {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = f
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("INTERVAL must be a positive integer")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT must be a positive integer")
+			}
+			rule.Count = n
+		case "UNTIL":
+			t, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &t
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				wd, ok := weekdays[d]
+				if !ok {
+					return nil, fmt.Errorf("unsupported BYDAY value %q", d)
+				}
+				rule.ByDay = append(rule.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(d)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY value %q", d)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, errors.New("COUNT and UNTIL cannot both be set")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != Weekly {
+		return nil, errors.New("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq != Monthly {
+		return nil, errors.New("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+
+	return rule, nil
+}
+
+// parseUntil 支持 20261231T235959Z 和 20261231 两种格式，只有日期时表示当天结束（UTC）
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ")
+}
+
+// String 返回规范化的规则字符串
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		names := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			names[i] = strings.ToUpper(wd.String()[:2])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(names, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next 返回第 occurrence 次发生（时间为 prev）之后的下一次发生时间，在 loc 时区按墙上时间计算
+func (r *Rule) Next(prev time.Time, occurrence int, loc *time.Location) (time.Time, bool) {
+	if r.Count > 0 && occurrence >= r.Count {
+		return time.Time{}, false
+	}
+
+	local := prev.In(loc)
+	var next time.Time
+	var ok bool
+	switch r.Freq {
+	case Daily:
+		next, ok = addDays(local, r.Interval, loc), true
+	case Weekly:
+		next, ok = r.nextWeekly(local, loc)
+	case Monthly:
+		next, ok = r.nextMonthly(local, loc)
+	case Yearly:
+		next, ok = r.nextYearly(local, loc)
+	}
+	if !ok {
+		return time.Time{}, false
+	}
+
+	if r.Until != nil && next.After(*r.Until) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// addDays 按日历天数前进，保持本地时分秒不变
+func addDays(t time.Time, days int, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+days, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+func (r *Rule) nextWeekly(prev time.Time, loc *time.Location) (time.Time, bool) {
+	if len(r.ByDay) == 0 {
+		return addDays(prev, 7*r.Interval, loc), true
+	}
+
+	allowed := make(map[time.Weekday]bool, len(r.ByDay))
+	for _, wd := range r.ByDay {
+		allowed[wd] = true
+	}
+
+	// 以 prev 所在周为基准（周一为一周的开始），只在相隔 INTERVAL 整数倍的周内取值
+	anchor := weekStart(prev, loc)
+	for i := 1; i <= 7*r.Interval+7; i++ {
+		candidate := addDays(prev, i, loc)
+		if !allowed[candidate.Weekday()] {
+			continue
+		}
+		weeks := daysBetween(anchor, weekStart(candidate, loc)) / 7
+		if weeks%r.Interval == 0 {
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r *Rule) nextMonthly(prev time.Time, loc *time.Location) (time.Time, bool) {
+	days := r.ByMonthDay
+	if len(days) == 0 {
+		days = []int{prev.Day()}
+	}
+
+	for i := 0; i < maxIterations; i++ {
+		// 第 0 次迭代检查 prev 当月剩余的日期，之后每次前进 INTERVAL 个月
+		year, month := prev.Year(), prev.Month()+time.Month(i*r.Interval)
+		first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+		last := daysIn(first.Year(), first.Month())
+
+		var candidates []int
+		for _, d := range days {
+			if d < 0 {
+				d = last + d + 1
+			}
+			if d >= 1 && d <= last {
+				candidates = append(candidates, d)
+			}
+		}
+		sort.Ints(candidates)
+
+		for _, d := range candidates {
+			candidate := time.Date(first.Year(), first.Month(), d, prev.Hour(), prev.Minute(), prev.Second(), prev.Nanosecond(), loc)
+			if candidate.After(prev) {
+				return candidate, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r *Rule) nextYearly(prev time.Time, loc *time.Location) (time.Time, bool) {
+	for i := 1; i < maxIterations; i++ {
+		year := prev.Year() + i*r.Interval
+		if prev.Day() > daysIn(year, prev.Month()) {
+			// 2 月 29 日只在闰年出现
+			continue
+		}
+		return time.Date(year, prev.Month(), prev.Day(), prev.Hour(), prev.Minute(), prev.Second(), prev.Nanosecond(), loc), true
+	}
+	return time.Time{}, false
+}
+
+// daysIn 返回某年某月的天数
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// weekStart 返回 t 所在周周一的零点
+func weekStart(t time.Time, loc *time.Location) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, loc)
+}
+
+// daysBetween 按日历日期计算天数差，不受夏令时导致的 23/25 小时天影响
+func daysBetween(a, b time.Time) int {
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
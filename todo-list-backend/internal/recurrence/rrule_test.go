@@ -0,0 +1,216 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestNext(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	utc := time.UTC
+	at := func(loc *time.Location, y int, m time.Month, d, h, min int) time.Time {
+		return time.Date(y, m, d, h, min, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name  string
+		rule  string
+		loc   *time.Location
+		start time.Time
+		want  []time.Time
+		// done 为 true 时 want 之后规则必须结束
+		done bool
+	}{
+		{
+			// 2026-03-08 美东进入夏令时，本地时刻保持 9:00，UTC 时刻提前一小时
+			name:  "daily across spring forward",
+			rule:  "FREQ=DAILY",
+			loc:   ny,
+			start: at(ny, 2026, 3, 7, 9, 0),
+			want:  []time.Time{at(ny, 2026, 3, 8, 9, 0), at(ny, 2026, 3, 9, 9, 0)},
+		},
+		{
+			name:  "daily across fall back",
+			rule:  "FREQ=DAILY",
+			loc:   ny,
+			start: at(ny, 2026, 10, 31, 9, 0),
+			want:  []time.Time{at(ny, 2026, 11, 1, 9, 0), at(ny, 2026, 11, 2, 9, 0)},
+		},
+		{
+			name:  "weekly across spring forward",
+			rule:  "FREQ=WEEKLY",
+			loc:   ny,
+			start: at(ny, 2026, 3, 1, 9, 0),
+			want:  []time.Time{at(ny, 2026, 3, 8, 9, 0), at(ny, 2026, 3, 15, 9, 0)},
+		},
+		{
+			name:  "weekly across fall back",
+			rule:  "FREQ=WEEKLY",
+			loc:   ny,
+			start: at(ny, 2026, 10, 25, 23, 30),
+			want:  []time.Time{at(ny, 2026, 11, 1, 23, 30), at(ny, 2026, 11, 8, 23, 30)},
+		},
+		{
+			// 没有 31 日的月份被跳过，而不是顺延到下月初或退到月末
+			name:  "monthly from Jan 31 skips short months",
+			rule:  "FREQ=MONTHLY",
+			loc:   utc,
+			start: at(utc, 2026, 1, 31, 10, 0),
+			want: []time.Time{
+				at(utc, 2026, 3, 31, 10, 0),
+				at(utc, 2026, 5, 31, 10, 0),
+				at(utc, 2026, 7, 31, 10, 0),
+				at(utc, 2026, 8, 31, 10, 0),
+				at(utc, 2026, 10, 31, 10, 0),
+			},
+		},
+		{
+			name:  "monthly on the 30th skips February",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=30",
+			loc:   utc,
+			start: at(utc, 2026, 1, 30, 10, 0),
+			want:  []time.Time{at(utc, 2026, 3, 30, 10, 0), at(utc, 2026, 4, 30, 10, 0)},
+		},
+		{
+			name:  "last day of month",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			loc:   utc,
+			start: at(utc, 2028, 1, 15, 8, 0),
+			want: []time.Time{
+				at(utc, 2028, 1, 31, 8, 0),
+				at(utc, 2028, 2, 29, 8, 0),
+				at(utc, 2028, 3, 31, 8, 0),
+				at(utc, 2028, 4, 30, 8, 0),
+			},
+		},
+		{
+			name:  "yearly from Feb 29 only in leap years",
+			rule:  "FREQ=YEARLY",
+			loc:   utc,
+			start: at(utc, 2024, 2, 29, 12, 0),
+			want:  []time.Time{at(utc, 2028, 2, 29, 12, 0), at(utc, 2032, 2, 29, 12, 0)},
+		},
+		{
+			name:  "count includes the first occurrence",
+			rule:  "FREQ=DAILY;COUNT=3",
+			loc:   utc,
+			start: at(utc, 2026, 6, 1, 9, 0),
+			want:  []time.Time{at(utc, 2026, 6, 2, 9, 0), at(utc, 2026, 6, 3, 9, 0)},
+			done:  true,
+		},
+		{
+			// 19:00 EDT 为 23:00Z，12 日这一次仍在 UNTIL 当天结束（UTC）之前
+			name:  "date-only until is inclusive end of day in UTC",
+			rule:  "FREQ=DAILY;UNTIL=20260312",
+			loc:   ny,
+			start: at(ny, 2026, 3, 10, 19, 0),
+			want:  []time.Time{at(ny, 2026, 3, 11, 19, 0), at(ny, 2026, 3, 12, 19, 0)},
+			done:  true,
+		},
+		{
+			// 20:30 EDT 已是次日 00:30Z，本地 12 日的一次超出了 UTC 的 12 日
+			name:  "date-only until is not local end of day",
+			rule:  "FREQ=DAILY;UNTIL=20260312",
+			loc:   ny,
+			start: at(ny, 2026, 3, 10, 20, 30),
+			want:  []time.Time{at(ny, 2026, 3, 11, 20, 30)},
+			done:  true,
+		},
+		{
+			name:  "weekly byday every other week",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+			loc:   utc,
+			start: at(utc, 2026, 1, 5, 9, 0),
+			want: []time.Time{
+				at(utc, 2026, 1, 9, 9, 0),
+				at(utc, 2026, 1, 19, 9, 0),
+				at(utc, 2026, 1, 23, 9, 0),
+				at(utc, 2026, 2, 2, 9, 0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+
+			prev := tt.start
+			for i, want := range tt.want {
+				got, ok := rule.Next(prev, i+1, tt.loc)
+				if !ok {
+					t.Fatalf("occurrence %d: rule ended, want %v", i+2, want)
+				}
+				if !got.Equal(want) {
+					t.Fatalf("occurrence %d: got %v, want %v", i+2, got.In(tt.loc), want)
+				}
+				prev = got
+			}
+			if tt.done {
+				if got, ok := rule.Next(prev, len(tt.want)+1, tt.loc); ok {
+					t.Errorf("rule should have ended, got %v", got.In(tt.loc))
+				}
+			}
+		})
+	}
+}
+
+func TestParseRejectsUnsupported(t *testing.T) {
+	tests := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;BYHOUR=9",
+		"FREQ=MONTHLY;BYSETPOS=-1",
+		"FREQ=WEEKLY;WKST=SU",
+		"FREQ=MONTHLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20261231",
+		"FREQ=DAILY;UNTIL=2026-12-31",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL",
+	}
+	for _, s := range tests {
+		if rule, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) = %v, want error", s, rule)
+		}
+	}
+}
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"rrule:freq=weekly;byday=mo,fr;interval=2", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"},
+		{"FREQ=DAILY;INTERVAL=1;UNTIL=20261231", "FREQ=DAILY;UNTIL=20261231T235959Z"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=12", "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=12"},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got := rule.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+// Package search 不依赖数据库全文索引的待办事项搜索：分词、前缀匹配、打分和高亮
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTerms 单次查询最多使用的词数，多余的词被忽略
+const MaxTerms = 10
+
+// 各字段的权重，标题命中比评论命中更相关
+const (
+	TitleWeight   = 3.0
+	CommentWeight = 1.0
+)
+
+// prefixFactor 前缀命中相对于整词命中的得分比例
+const prefixFactor = 0.6
+
+// snippetRunes 评论片段在第一个命中位置前后保留的字符数
+const snippetRunes = 40
+
+// Term 查询中的一个词，Text 已转为小写
+type Term struct {
+	Text string
+	CJK  bool
+}
+
+// Field 参与搜索的一段文本，ID 用于区分同名字段（如评论ID）
+type Field struct {
+	Name   string
+	ID     int
+	Text   string
+	Weight float64
+	// Snippet 为 true 时高亮结果只保留命中位置附近的片段，否则返回全文
+	Snippet bool
+}
+
+// Highlight 某个字段中命中部分的高亮结果，Snippet 已做 HTML 转义，命中部分用 <mark> 包围
+type Highlight struct {
+	Field   string
+	ID      int
+	Snippet string
+}
+
+// token 文本中的一个词，start 和 end 是在原文中的字节偏移
+type token struct {
+	text       string
+	start, end int
+	cjk        bool
+}
+
+// span 原文中命中的字节区间
+type span struct {
+	start, end int
+}
+
+// isCJK 判断字符是否属于中日韩文字
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// tokenize 把文本切分为单词和中日韩字符段，其余字符作为分隔符
+func tokenize(s string) []token {
+	var tokens []token
+	start := -1
+	cjk := false
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, token{text: strings.ToLower(s[start:end]), start: start, end: end, cjk: cjk})
+			start = -1
+		}
+	}
+
+	for i, r := range s {
+		switch {
+		case isCJK(r):
+			if start >= 0 && !cjk {
+				flush(i)
+			}
+			if start < 0 {
+				start, cjk = i, true
+			}
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			if start >= 0 && cjk {
+				flush(i)
+			}
+			if start < 0 {
+				start, cjk = i, false
+			}
+		default:
+			flush(i)
+		}
+	}
+	flush(len(s))
+	return tokens
+}
+
+// ParseQuery 把查询字符串切分为去重后的查询词，最多 MaxTerms 个
+func ParseQuery(q string) []Term {
+	var terms []Term
+	seen := make(map[string]bool)
+	for _, t := range tokenize(q) {
+		if seen[t.text] {
+			continue
+		}
+		seen[t.text] = true
+		terms = append(terms, Term{Text: t.text, CJK: t.cjk})
+		if len(terms) == MaxTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// Texts 返回查询词的文本，供存储层粗筛使用
+func Texts(terms []Term) []string {
+	texts := make([]string, len(terms))
+	for i, t := range terms {
+		texts[i] = t.Text
+	}
+	return texts
+}
+
+// match 在已分词的文本中查找查询词，返回命中区间和命中质量（整词为 1，前缀为 prefixFactor）
+func match(term Term, tokens []token, text string) ([]span, float64) {
+	var spans []span
+	quality := 0.0
+	for _, tok := range tokens {
+		if tok.cjk != term.CJK {
+			continue
+		}
+		if term.CJK {
+			// 中日韩文字大小写不变，小写后的偏移与原文一致
+			for offset := 0; ; {
+				i := strings.Index(tok.text[offset:], term.Text)
+				if i < 0 {
+					break
+				}
+				start := tok.start + offset + i
+				spans = append(spans, span{start, start + len(term.Text)})
+				offset += i + len(term.Text)
+				quality = 1
+			}
+			continue
+		}
+		if !strings.HasPrefix(tok.text, term.Text) {
+			continue
+		}
+		// 小写可能改变字节长度，按字符数换算回原文中的前缀长度
+		end := tok.start
+		for n := utf8.RuneCountInString(term.Text); n > 0 && end < tok.end; n-- {
+			_, size := utf8.DecodeRuneInString(text[end:])
+			end += size
+		}
+		spans = append(spans, span{tok.start, end})
+		if tok.text == term.Text {
+			quality = 1
+		} else if quality == 0 {
+			quality = prefixFactor
+		}
+	}
+	return spans, quality
+}
+
+// Rank 计算文本对查询的得分和高亮，每个查询词都必须在至少一个字段中命中，否则 ok 为 false
+func Rank(terms []Term, fields []Field) (score float64, highlights []Highlight, ok bool) {
+	if len(terms) == 0 {
+		return 0, nil, false
+	}
+
+	fieldSpans := make([][]span, len(fields))
+	fieldTokens := make([][]token, len(fields))
+	for i, f := range fields {
+		fieldTokens[i] = tokenize(f.Text)
+	}
+
+	allInFirst := true
+	for _, term := range terms {
+		termScore := 0.0
+		for i, f := range fields {
+			spans, quality := match(term, fieldTokens[i], f.Text)
+			if len(spans) == 0 {
+				if i == 0 {
+					allInFirst = false
+				}
+				continue
+			}
+			termScore += f.Weight * quality * (1 + math.Log(float64(len(spans))))
+			fieldSpans[i] = append(fieldSpans[i], spans...)
+		}
+		if termScore == 0 {
+			return 0, nil, false
+		}
+		score += termScore
+	}
+	if allInFirst && len(fields) > 0 {
+		score *= 1.5
+	}
+
+	for i, f := range fields {
+		if len(fieldSpans[i]) == 0 {
+			continue
+		}
+		highlights = append(highlights, Highlight{Field: f.Name, ID: f.ID, Snippet: mark(f.Text, merge(fieldSpans[i]), f.Snippet)})
+	}
+	return math.Round(score*1000) / 1000, highlights, true
+}
+
+// merge 排序并合并重叠或相邻的区间
+func merge(spans []span) []span {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	merged := []span{spans[0]}
+	for _, sp := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if sp.start <= last.end {
+			if sp.end > last.end {
+				last.end = sp.end
+			}
+			continue
+		}
+		merged = append(merged, sp)
+	}
+	return merged
+}
+
+// mark 生成 HTML 转义后的高亮文本；snippet 为 true 时只保留第一个命中位置前后 snippetRunes 个字符
+func mark(text string, spans []span, snippet bool) string {
+	from, to := 0, len(text)
+	if snippet {
+		from = backRunes(text, spans[0].start, snippetRunes)
+		to = forwardRunes(text, spans[0].end, snippetRunes)
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, sp := range spans {
+		if sp.start >= to {
+			break
+		}
+		end := sp.end
+		if end > to {
+			end = to
+		}
+		b.WriteString(html.EscapeString(text[pos:sp.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[sp.start:end]))
+		b.WriteString("</mark>")
+		pos = end
+	}
+	b.WriteString(html.EscapeString(text[pos:to]))
+	if to < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// backRunes 返回从字节偏移 i 向前 n 个字符的位置
+func backRunes(s string, i int, n int) int {
+	for ; n > 0 && i > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+	return i
+}
+
+// forwardRunes 返回从字节偏移 i 向后 n 个字符的位置
+func forwardRunes(s string, i int, n int) int {
+	for ; n > 0 && i < len(s); n-- {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return i
+}
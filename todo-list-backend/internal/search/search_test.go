@@ -0,0 +1,137 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want []Term
+	}{
+		{"Buy MILK, milk!", []Term{{Text: "buy"}, {Text: "milk"}}},
+		{"买牛奶 milk", []Term{{Text: "买牛奶", CJK: true}, {Text: "milk"}}},
+		{"abc中文def", []Term{{Text: "abc"}, {Text: "中文", CJK: true}, {Text: "def"}}},
+		{"café naïve", []Term{{Text: "café"}, {Text: "naïve"}}},
+		{"ミルクを買う", []Term{{Text: "ミルクを買う", CJK: true}}},
+		{"-- !! ??", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := ParseQuery(tt.q); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseQuery(%q) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+
+	if got := ParseQuery("a b c d e f g h i j k l"); len(got) != MaxTerms || got[MaxTerms-1].Text != "j" {
+		t.Errorf("ParseQuery kept %v, want the first %d terms", got, MaxTerms)
+	}
+}
+
+func TestRank(t *testing.T) {
+	title := func(text string) []Field {
+		return []Field{{Name: "title", Text: text, Weight: TitleWeight}}
+	}
+	withComment := func(titleText, comment string) []Field {
+		return append(title(titleText), Field{Name: "comment", ID: 7, Text: comment, Weight: CommentWeight, Snippet: true})
+	}
+
+	tests := []struct {
+		name   string
+		q      string
+		fields []Field
+		score  float64
+		want   []Highlight
+	}{
+		{"exact word", "milk", title("Buy milk"), 4.5,
+			[]Highlight{{Field: "title", Snippet: "Buy <mark>milk</mark>"}}},
+		{"prefix scores lower", "mil", title("Buy milk"), 2.7,
+			[]Highlight{{Field: "title", Snippet: "Buy <mark>mil</mark>k"}}},
+		{"case-insensitive", "milk", title("BUY MILK"), 4.5,
+			[]Highlight{{Field: "title", Snippet: "BUY <mark>MILK</mark>"}}},
+		{"repeated hits", "milk", title("milk, more milk"), 7.619,
+			[]Highlight{{Field: "title", Snippet: "<mark>milk</mark>, more <mark>milk</mark>"}}},
+		{"overlapping spans merge", "milk mil", title("milk"), 7.2,
+			[]Highlight{{Field: "title", Snippet: "<mark>milk</mark>"}}},
+		{"CJK substring", "牛奶", title("去超市买牛奶和面包"), 4.5,
+			[]Highlight{{Field: "title", Snippet: "去超市买<mark>牛奶</mark>和面包"}}},
+		{"CJK next to Latin", "牛奶", title("买2L牛奶"), 4.5,
+			[]Highlight{{Field: "title", Snippet: "买2L<mark>牛奶</mark>"}}},
+		{"CJK every occurrence", "奶", title("牛奶和酸奶"), 7.619,
+			[]Highlight{{Field: "title", Snippet: "牛<mark>奶</mark>和酸<mark>奶</mark>"}}},
+		{"HTML escaped", "milk", title(`<b>milk</b> & "eggs"`), 4.5,
+			[]Highlight{{Field: "title", Snippet: "&lt;b&gt;<mark>milk</mark>&lt;/b&gt; &amp; &#34;eggs&#34;"}}},
+		{"terms split across fields", "milk bread", withComment("Buy milk", "and <bread>"), 4,
+			[]Highlight{{Field: "title", Snippet: "Buy <mark>milk</mark>"}, {Field: "comment", ID: 7, Snippet: "and &lt;<mark>bread</mark>&gt;"}}},
+		{"comment only", "bread", withComment("Buy milk", "bread too"), 1,
+			[]Highlight{{Field: "comment", ID: 7, Snippet: "<mark>bread</mark> too"}}},
+	}
+	for _, tt := range tests {
+		score, highlights, ok := Rank(ParseQuery(tt.q), tt.fields)
+		if !ok {
+			t.Errorf("%s: Rank(%q) did not match", tt.name, tt.q)
+			continue
+		}
+		if score != tt.score || !reflect.DeepEqual(highlights, tt.want) {
+			t.Errorf("%s: Rank(%q) = %v, %+v; want %v, %+v", tt.name, tt.q, score, highlights, tt.score, tt.want)
+		}
+	}
+}
+
+func TestRankNoMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		q      string
+		fields []Field
+	}{
+		{"inside a Latin word", "ilk", []Field{{Name: "title", Text: "Buy milk", Weight: TitleWeight}}},
+		{"missing term", "milk bread", []Field{{Name: "title", Text: "Buy milk", Weight: TitleWeight}}},
+		{"CJK term across a separator", "牛奶", []Field{{Name: "title", Text: "牛 奶", Weight: TitleWeight}}},
+		{"empty query", "!!", []Field{{Name: "title", Text: "Buy milk", Weight: TitleWeight}}},
+	}
+	for _, tt := range tests {
+		if score, highlights, ok := Rank(ParseQuery(tt.q), tt.fields); ok {
+			t.Errorf("%s: Rank(%q) = %v, %+v; want no match", tt.name, tt.q, score, highlights)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	tests := []struct {
+		name string
+		q    string
+		text string
+		want string
+	}{
+		{"trimmed on both sides", "milk",
+			strings.Repeat("a ", 50) + "milk" + strings.Repeat(" z", 50),
+			"…" + strings.Repeat("a ", 20) + "<mark>milk</mark>" + strings.Repeat(" z", 20) + "…"},
+		{"counted in characters", "牛奶",
+			strings.Repeat("很", 50) + "牛奶" + strings.Repeat("好", 50),
+			"…" + strings.Repeat("很", 40) + "<mark>牛奶</mark>" + strings.Repeat("好", 40) + "…"},
+		{"near the start", "milk",
+			"milk" + strings.Repeat(" z", 50),
+			"<mark>milk</mark>" + strings.Repeat(" z", 20) + "…"},
+		{"later hits inside the window", "milk",
+			strings.Repeat("a ", 50) + "milk and milk" + strings.Repeat(" z", 50),
+			"…" + strings.Repeat("a ", 20) + "<mark>milk</mark> and <mark>milk</mark>" + strings.Repeat(" z", 15) + " …"},
+		{"escaped after trimming", "milk",
+			strings.Repeat("<", 50) + " milk",
+			"…" + strings.Repeat("&lt;", 39) + " <mark>milk</mark>"},
+	}
+	for _, tt := range tests {
+		fields := []Field{{Name: "title", Text: "todo", Weight: TitleWeight}, {Name: "comment", ID: 1, Text: tt.text, Weight: CommentWeight, Snippet: true}}
+		_, highlights, ok := Rank(ParseQuery(tt.q), fields)
+		if !ok || len(highlights) != 1 || highlights[0].Snippet != tt.want {
+			t.Errorf("%s: highlights = %+v, want snippet %q", tt.name, highlights, tt.want)
+		}
+	}
+
+	// 标题不截取片段
+	long := strings.Repeat("a ", 50) + "milk"
+	if _, highlights, _ := Rank(ParseQuery("milk"), []Field{{Name: "title", Text: long, Weight: TitleWeight}}); highlights[0].Snippet != strings.Repeat("a ", 50)+"<mark>milk</mark>" {
+		t.Errorf("title highlight = %q, want the full title", highlights[0].Snippet)
+	}
+}
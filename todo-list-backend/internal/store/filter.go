@@ -0,0 +1,126 @@
+package store
+
+import (
+	"strings"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+)
+
+// TodoFilter 列表查询的过滤条件和排序，零值表示不过滤并按 DefaultSort 排序
+type TodoFilter struct {
+	// Completed 只返回完成状态与之相同的待办事项
+	Completed *bool
+	// Priorities 只返回优先级在其中的待办事项
+	Priorities []string
+	// TitleContains 只返回标题包含该文本（不区分大小写）的待办事项
+	TitleContains string
+	// DueAfter 只返回 due_at >= DueAfter 的待办事项
+	DueAfter *time.Time
+	// DueBefore 只返回 due_at < DueBefore 的待办事项
+	DueBefore *time.Time
+	// CreatedAfter、CreatedBefore 按 created_at 过滤，含义同 DueAfter、DueBefore
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// UpdatedAfter、UpdatedBefore 按 updated_at 过滤
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	// Overdue 只返回已过截止时间且未完成的待办事项
+	Overdue bool
+	// ProjectID 只返回该项目中的待办事项
+	ProjectID *int
+	// AssigneeID 只返回指派给该用户的待办事项
+	AssigneeID *int
+	// Tags 按标签名过滤，TagMode 决定需要包含全部还是任一标签
+	Tags    []string
+	TagMode TagMode
+	// Sort 排序键，为空时使用 DefaultSort
+	Sort []SortKey
+}
+
+// TagMode 多个标签过滤条件的组合方式
+type TagMode string
+
+const (
+	// TagModeAll 必须包含全部标签（AND），为默认方式
+	TagModeAll TagMode = "all"
+	// TagModeAny 包含任一标签即可（OR）
+	TagModeAny TagMode = "any"
+)
+
+// Match 判断待办事项是否满足过滤条件，供不依赖 SQL 的存储实现使用
+func (f TodoFilter) Match(todo models.Todo, now time.Time) bool {
+	if f.Completed != nil && todo.Completed != *f.Completed {
+		return false
+	}
+	if len(f.Priorities) > 0 && !contains(f.Priorities, todo.Priority) {
+		return false
+	}
+	if f.TitleContains != "" && !strings.Contains(strings.ToLower(todo.Title), strings.ToLower(f.TitleContains)) {
+		return false
+	}
+	if !inRange(todo.CreatedAt, f.CreatedAfter, f.CreatedBefore) || !inRange(todo.UpdatedAt, f.UpdatedAfter, f.UpdatedBefore) {
+		return false
+	}
+	if f.DueAfter != nil || f.DueBefore != nil || f.Overdue {
+		if todo.DueAt == nil {
+			return false
+		}
+	}
+	if f.DueAfter != nil && todo.DueAt.Before(*f.DueAfter) {
+		return false
+	}
+	if f.DueBefore != nil && !todo.DueAt.Before(*f.DueBefore) {
+		return false
+	}
+	if f.Overdue && (todo.Completed || !todo.DueAt.Before(now)) {
+		return false
+	}
+	if f.ProjectID != nil && (todo.ProjectID == nil || *todo.ProjectID != *f.ProjectID) {
+		return false
+	}
+	if f.AssigneeID != nil && (todo.AssigneeID == nil || *todo.AssigneeID != *f.AssigneeID) {
+		return false
+	}
+	if len(f.Tags) > 0 && !f.matchTags(todo.Tags) {
+		return false
+	}
+	return true
+}
+
+// inRange 判断 t 是否在 [after, before) 内，nil 表示不限制
+func inRange(t time.Time, after, before *time.Time) bool {
+	if after != nil && t.Before(*after) {
+		return false
+	}
+	if before != nil && !t.Before(*before) {
+		return false
+	}
+	return true
+}
+
+func contains(values []string, v string) bool {
+	for _, item := range values {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (f TodoFilter) matchTags(tags []models.Tag) bool {
+	names := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		names[t.Name] = true
+	}
+
+	for _, name := range f.Tags {
+		if f.TagMode == TagModeAny && names[name] {
+			return true
+		}
+		if f.TagMode != TagModeAny && !names[name] {
+			return false
+		}
+	}
+	return f.TagMode != TagModeAny
+}
@@ -0,0 +1,43 @@
+package store
+
+// 手动排序：同级按 Position 升序排列，移动时取相邻两项的中点，间隔过小时由定期任务或移动本身重新编号
+
+// PositionStep 新待办事项排在同级末尾时与前一项的间隔，也是重新编号后相邻两项的间隔
+const PositionStep = 1024.0
+
+// minPositionGap 相邻两项的间隔小于该值时不再取中点，而是先重新编号
+const minPositionGap = 1e-6
+
+// PositionBetween 返回排在 lower 和 upper 之间的位置，间隔过小时返回 false
+func PositionBetween(lower, upper *float64) (float64, bool) {
+	switch {
+	case lower == nil && upper == nil:
+		return PositionStep, true
+	case lower == nil:
+		return *upper - PositionStep, true
+	case upper == nil:
+		return *lower + PositionStep, true
+	}
+	if *upper-*lower < minPositionGap {
+		return 0, false
+	}
+	return *lower + (*upper-*lower)/2, true
+}
+
+// RebalanceGap 定期任务把存在小于该间隔的同级组重新编号，使移动在间隔耗尽之前就有足够的余量
+const RebalanceGap = 1.0
+
+// NeedsRebalance 判断按手动排序排列的一组同级中是否有相邻两项的间隔小于 RebalanceGap
+func NeedsRebalance(positions []float64) bool {
+	for i := 1; i < len(positions); i++ {
+		if positions[i]-positions[i-1] < RebalanceGap {
+			return true
+		}
+	}
+	return false
+}
+
+// RebalancedPosition 重新编号后第 i 个（从 0 开始）同级的位置
+func RebalancedPosition(i int) float64 {
+	return float64(i+1) * PositionStep
+}
@@ -0,0 +1,180 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+)
+
+// SortField 列表可以排序的字段，SQL 实现把它们映射为固定的表达式
+type SortField string
+
+const (
+	SortCreatedAt SortField = "created_at"
+	SortUpdatedAt SortField = "updated_at"
+	SortDueAt     SortField = "due_at"
+	SortPriority  SortField = "priority"
+	SortTitle     SortField = "title"
+	SortCompleted SortField = "completed"
+	SortPosition  SortField = "position"
+)
+
+var sortFields = map[SortField]bool{
+	SortCreatedAt: true,
+	SortUpdatedAt: true,
+	SortDueAt:     true,
+	SortPriority:  true,
+	SortTitle:     true,
+	SortCompleted: true,
+	SortPosition:  true,
+}
+
+// maxSortKeys 排序键的最大数量
+const maxSortKeys = 4
+
+// SortKey 一个排序键，Desc 为 true 时倒序
+type SortKey struct {
+	Field SortField
+	Desc  bool
+}
+
+// DefaultSort 未指定排序时使用的顺序：按创建时间倒序
+var DefaultSort = []SortKey{{Field: SortCreatedAt, Desc: true}}
+
+// ParseSort 解析逗号分隔的排序键，如 "-priority,created_at"，空字符串返回 nil
+func ParseSort(s string) ([]SortKey, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var keys []SortKey
+	seen := make(map[SortField]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		key := SortKey{Field: SortField(strings.TrimPrefix(part, "-")), Desc: strings.HasPrefix(part, "-")}
+		if !sortFields[key.Field] {
+			return nil, fmt.Errorf("unknown sort field %q", part)
+		}
+		if seen[key.Field] {
+			return nil, fmt.Errorf("duplicate sort field %q", key.Field)
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+	if len(keys) > maxSortKeys {
+		return nil, fmt.Errorf("at most %d sort fields are allowed", maxSortKeys)
+	}
+	return keys, nil
+}
+
+// PriorityRank 优先级的排序值，low < medium < high
+func PriorityRank(priority string) int {
+	switch priority {
+	case "low":
+		return 1
+	case "medium":
+		return 2
+	case "high":
+		return 3
+	}
+	return 0
+}
+
+// compareTime 比较可选时间，nil 总是排在最后（与方向无关）
+func compareTime(a, b *time.Time, desc bool) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	c := a.Compare(*b)
+	if desc {
+		c = -c
+	}
+	return c
+}
+
+// compareInt 比较两个整数，desc 为 true 时反转
+func compareInt(a, b int, desc bool) int {
+	c := 0
+	if a < b {
+		c = -1
+	} else if a > b {
+		c = 1
+	}
+	if desc {
+		c = -c
+	}
+	return c
+}
+
+// compareFloat 比较两个浮点数，desc 为 true 时反转
+func compareFloat(a, b float64, desc bool) int {
+	c := 0
+	if a < b {
+		c = -1
+	} else if a > b {
+		c = 1
+	}
+	if desc {
+		c = -c
+	}
+	return c
+}
+
+// boolInt 把布尔值转换为 0 或 1
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// LessTodo 按排序键比较两个待办事项，与 SQL 实现的顺序一致，全部相等时按 ID 倒序
+func LessTodo(a, b models.Todo, keys []SortKey) bool {
+	if len(keys) == 0 {
+		keys = DefaultSort
+	}
+
+	for _, k := range keys {
+		var c int
+		switch k.Field {
+		case SortCreatedAt:
+			c = compareTime(&a.CreatedAt, &b.CreatedAt, k.Desc)
+		case SortUpdatedAt:
+			c = compareTime(&a.UpdatedAt, &b.UpdatedAt, k.Desc)
+		case SortDueAt:
+			c = compareTime(a.DueAt, b.DueAt, k.Desc)
+		case SortPriority:
+			c = compareInt(PriorityRank(a.Priority), PriorityRank(b.Priority), k.Desc)
+		case SortTitle:
+			c = strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+			if k.Desc {
+				c = -c
+			}
+		case SortCompleted:
+			c = compareInt(boolInt(a.Completed), boolInt(b.Completed), k.Desc)
+		case SortPosition:
+			c = compareFloat(a.Position, b.Position, k.Desc)
+		}
+		if c != 0 {
+			return c < 0
+		}
+	}
+	return a.ID > b.ID
+}
+
+// Page 键集分页参数，After 和 Before 最多设置一个，都为空时从第一条开始
+type Page struct {
+	// After 返回排在该待办事项之后的数据
+	After *models.Todo
+	// Before 返回排在该待办事项之前的数据
+	Before *models.Todo
+	// Limit 每页最多返回的条数
+	Limit int
+}
@@ -0,0 +1,296 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+)
+
+// 各存储实现共用的错误，处理器通过 errors.Is 判断并映射为 HTTP 状态码
+var (
+	ErrEmailExists           = errors.New("email already exists")
+	ErrUsernameExists        = errors.New("username already exists")
+	ErrUserNotFound          = errors.New("user not found")
+	ErrTodoNotFound          = errors.New("todo not found or not owned by user")
+	ErrInvalidParent         = errors.New("parent todo not found, not owned by user, or inside the moved subtree")
+	ErrHasSubtasks           = errors.New("todo has subtasks")
+	ErrTagNotFound           = errors.New("tag not found or not owned by user")
+	ErrTagExists             = errors.New("tag already exists")
+	ErrProjectNotFound       = errors.New("project not found or not owned by user")
+	ErrInvalidProject        = errors.New("project not found, not owned by user, or archived")
+	ErrInboxProject          = errors.New("the inbox cannot be deleted, archived or shared")
+	ErrForbidden             = errors.New("insufficient permission")
+	ErrMemberNotFound        = errors.New("member not found")
+	ErrAlreadyMember         = errors.New("user is already a member")
+	ErrInvitationNotFound    = errors.New("invitation not found or already answered")
+	ErrInvitationExists      = errors.New("user already has a pending invitation to the project")
+	ErrInvalidAssignee       = errors.New("assignee not found or not a member of the workspace")
+	ErrWorkspaceNotFound     = errors.New("workspace not found or user is not a member")
+	ErrLastAdmin             = errors.New("workspace must keep at least one admin")
+	ErrCommentNotFound       = errors.New("comment not found")
+	ErrAttachmentNotFound    = errors.New("attachment not found")
+	ErrSmartListNotFound     = errors.New("smart list not found or not owned by user")
+	ErrInvalidAnchor         = errors.New("anchor todo not found, not accessible, or not a sibling of the moved todo")
+	ErrInvalidToken          = errors.New("refresh token is invalid, expired or revoked")
+	ErrTokenReused           = errors.New("refresh token was already used, the session has been revoked")
+	ErrPersonalTokenNotFound = errors.New("personal access token not found")
+	ErrInvalidPersonalToken  = errors.New("personal access token is invalid or expired")
+	ErrInvalidEmailToken     = errors.New("token is invalid, expired or already used")
+	ErrTwoFactorNotFound     = errors.New("two-factor authentication is not set up")
+	ErrTwoFactorEnabled      = errors.New("two-factor authentication is already enabled")
+	ErrCodeReused            = errors.New("code has already been used")
+	ErrInvalidRecoveryCode   = errors.New("recovery code is invalid or already used")
+)
+
+// UserStore 用户数据的持久化接口
+type UserStore interface {
+	// CreateUser 创建新用户并返回其ID，邮箱或用户名重复时返回 ErrEmailExists / ErrUsernameExists
+	CreateUser(user models.RegisterRequest) (int64, error)
+	// GetUserByEmail 通过邮箱获取用户，不存在时返回 ErrUserNotFound
+	GetUserByEmail(email string) (models.User, error)
+	// GetUserByID 通过ID获取用户，不存在时返回 ErrUserNotFound
+	GetUserByID(id int) (models.User, error)
+	// UpdateUserTimezone 修改用户的时区
+	UpdateUserTimezone(id int, timezone string) error
+	// UpdateUserPassword 哈希并保存用户的新密码，用户不存在时返回 ErrUserNotFound
+	UpdateUserPassword(id int, password string) error
+	// MarkEmailVerified 记录用户已确认邮箱，已确认过时保留原来的时间
+	MarkEmailVerified(id int) error
+}
+
+// TodoStore 待办事项的持久化接口，无法访问时返回 ErrTodoNotFound，角色不足时返回 ErrForbidden
+type TodoStore interface {
+	// GetAllTodos 获取用户可访问的（自己的、共享给自己的和指派给自己的）满足过滤条件的所有待办事项，按 filter.Sort 排序
+	GetAllTodos(userID int, filter TodoFilter) ([]models.Todo, error)
+	// GetTodosWithPagination 分页获取满足过滤条件的待办事项，同时返回过滤后的总记录数
+	GetTodosWithPagination(userID int, filter TodoFilter, page, pageSize int) ([]models.Todo, int, error)
+	// GetTodosPage 键集分页获取待办事项，more 表示沿翻页方向是否还有更多数据
+	GetTodosPage(userID int, filter TodoFilter, page Page) (todos []models.Todo, more bool, err error)
+	// GetTodo 获取单个待办事项
+	GetTodo(id int, userID int) (models.Todo, error)
+	// GetSubtree 获取待办事项及其全部后代，根节点在前
+	GetSubtree(id int, userID int) ([]models.Todo, error)
+	// GetChildren 获取待办事项的直接子项，按手动排序
+	GetChildren(parentID int, userID int) ([]models.Todo, error)
+	// CreateTodo 以 todo.UserID 为创建者创建待办事项并返回其ID，新待办事项排在同级的末尾
+	CreateTodo(todo models.Todo) (int64, error)
+	// UpdateTodo 以 todo.UserID 的身份更新待办事项，没有 editor 角色的负责人只能修改 Completed
+	UpdateTodo(todo models.Todo) error
+	// MoveTodo 将待办事项连同子树移动到 parentID 下，parentID 为 nil 时移动到顶层
+	MoveTodo(id int, userID int, parentID *int) error
+	// MoveTodoToProject 将待办事项连同子树移动到另一个项目的顶层，项目不可用时返回 ErrInvalidProject
+	MoveTodoToProject(id int, userID int, projectID int) error
+	// ReorderTodo 把待办事项放到同级锚点 anchorID 之前（before 为 true）或之后，锚点无效时返回 ErrInvalidAnchor
+	ReorderTodo(id int, userID int, anchorID int, before bool) error
+	// DeleteTodo 删除待办事项，存在子项时返回 ErrHasSubtasks
+	DeleteTodo(id int, userID int) error
+	// DeleteTodoTree 删除待办事项及其全部后代
+	DeleteTodoTree(id int, userID int) error
+	// SearchTodos 全文搜索的粗筛：按ID倒序返回ID小于 beforeID（为 0 时不限）且标题或评论包含全部 terms 的待办事项最多 limit 条及其命中的评论，more 表示是否还有更多候选
+	SearchTodos(userID int, terms []string, beforeID int, limit int) (todos []models.Todo, comments map[int][]models.Comment, more bool, err error)
+}
+
+// TagStore 标签及其与待办事项关联关系的持久化接口，标签按用户隔离
+type TagStore interface {
+	// GetTags 获取用户的全部标签，按名称排序
+	GetTags(userID int) ([]models.Tag, error)
+	// GetTag 获取单个标签，不属于 userID 时返回 ErrTagNotFound
+	GetTag(id int, userID int) (models.Tag, error)
+	// CreateTag 创建标签并返回其ID，同一用户下名称重复时返回 ErrTagExists
+	CreateTag(tag models.Tag) (int64, error)
+	// UpdateTag 修改标签名称和颜色
+	UpdateTag(tag models.Tag) error
+	// DeleteTag 删除标签，同时从所有待办事项上移除
+	DeleteTag(id int, userID int) error
+	// AttachTag 为待办事项添加自己的标签，需要 editor 角色，已添加时不做任何操作
+	AttachTag(todoID int, tagID int, userID int) error
+	// DetachTag 从待办事项上移除标签，需要 editor 角色，标签可以属于其他协作者
+	DetachTag(todoID int, tagID int, userID int) error
+}
+
+// SmartListStore 智能列表的持久化接口，不属于 userID 时返回 ErrSmartListNotFound
+type SmartListStore interface {
+	// GetSmartLists 获取用户在当前工作区的全部智能列表，按名称排序
+	GetSmartLists(userID int) ([]models.SmartList, error)
+	// GetSmartList 获取单个智能列表
+	GetSmartList(id int, userID int) (models.SmartList, error)
+	// CreateSmartList 创建智能列表并返回其ID
+	CreateSmartList(list models.SmartList) (int64, error)
+	// UpdateSmartList 修改智能列表的名称和查询参数
+	UpdateSmartList(list models.SmartList) error
+	// DeleteSmartList 删除智能列表
+	DeleteSmartList(id int, userID int) error
+}
+
+// CommentStore 待办事项评论的持久化接口，评论不存在时返回 ErrCommentNotFound
+type CommentStore interface {
+	// GetComments 获取待办事项的全部评论，按发表时间排序
+	GetComments(todoID int, userID int) ([]models.Comment, error)
+	// CreateComment 以 comment.UserID 为作者发表评论并返回其ID
+	CreateComment(comment models.Comment) (int64, error)
+	// UpdateComment 以 comment.UserID 的身份修改评论正文，只有作者可以修改，否则返回 ErrForbidden
+	UpdateComment(comment models.Comment) error
+	// DeleteComment 删除评论，作者或对待办事项具有 editor 角色的用户可以删除，否则返回 ErrForbidden
+	DeleteComment(id int, todoID int, userID int) error
+}
+
+// AttachmentStore 待办事项附件元数据的持久化接口，附件不存在时返回 ErrAttachmentNotFound
+type AttachmentStore interface {
+	// GetAttachments 获取待办事项的全部附件，按上传时间排序
+	GetAttachments(todoID int, userID int) ([]models.Attachment, error)
+	// GetAttachment 获取单个附件
+	GetAttachment(id int, todoID int, userID int) (models.Attachment, error)
+	// CreateAttachment 以 attachment.UserID 为上传者保存附件元数据并返回其ID
+	CreateAttachment(attachment models.Attachment) (int64, error)
+	// DeleteAttachment 删除附件并返回其内容摘要，上传者或对待办事项具有 editor 角色的用户可以删除，否则返回 ErrForbidden
+	DeleteAttachment(id int, todoID int, userID int) (string, error)
+	// GetTreeAttachmentHashes 获取待办事项及其全部后代的附件引用的内容摘要（去重），用于删除待办事项后清理内容
+	GetTreeAttachmentHashes(todoID int, userID int) ([]string, error)
+	// UnreferencedHashes 返回 hashes 中已没有任何附件引用的摘要。内容在全部工作区间共享，因此检查不限于当前工作区
+	UnreferencedHashes(hashes []string) ([]string, error)
+}
+
+// ProjectStore 项目（清单）的持久化接口，修改和删除需要 owner 角色
+type ProjectStore interface {
+	// GetProjects 获取用户自己的和共享给自己的项目，按 position 排序；includeArchived 为 false 时不包含已归档的项目
+	GetProjects(userID int, includeArchived bool) ([]models.Project, error)
+	// GetProject 获取单个项目
+	GetProject(id int, userID int) (models.Project, error)
+	// CreateProject 以 project.UserID 为创建者创建项目并返回其ID，Position 为 0 时排在最后
+	CreateProject(project models.Project) (int64, error)
+	// UpdateProject 以 project.UserID 的身份修改项目的名称、颜色、归档状态和位置，归档收件箱时返回 ErrInboxProject
+	UpdateProject(project models.Project) error
+	// DeleteProject 删除项目，其中的待办事项移到各自创建者的收件箱；删除收件箱时返回 ErrInboxProject
+	DeleteProject(id int, userID int) error
+	// EnsureInbox 返回用户的收件箱，不存在时创建
+	EnsureInbox(userID int) (models.Project, error)
+}
+
+// ShareStore 项目共享的持久化接口：成员、角色和邀请，管理需要 owner 角色
+type ShareStore interface {
+	// GetMembers 获取项目成员，需要 viewer 角色
+	GetMembers(projectID int, userID int) ([]models.Member, error)
+	// UpdateMemberRole 修改成员的角色，项目创建者的角色不能修改
+	UpdateMemberRole(projectID int, memberID int, role models.Role, userID int) error
+	// RemoveMember 移除成员；成员也可以移除自己以退出项目
+	RemoveMember(projectID int, memberID int, userID int) error
+	// CreateInvitation 邀请 email 对应的工作区成员以 role 角色加入项目并返回邀请ID
+	CreateInvitation(projectID int, email string, role models.Role, userID int) (int64, error)
+	// GetProjectInvitations 获取项目中待处理的邀请
+	GetProjectInvitations(projectID int, userID int) ([]models.Invitation, error)
+	// GetInvitations 获取用户在当前工作区收到的待处理邀请
+	GetInvitations(userID int) ([]models.Invitation, error)
+	// RespondInvitation 接受或拒绝邀请，不是发给 userID 的或已处理过时返回 ErrInvitationNotFound
+	RespondInvitation(id int, userID int, accept bool) error
+}
+
+// WorkspaceStore 工作区及其成员的持久化接口，修改工作区和管理成员需要 admin 角色
+type WorkspaceStore interface {
+	// CreateWorkspace 创建工作区并将 userID 设为管理员，返回工作区ID
+	CreateWorkspace(name string, userID int) (int64, error)
+	// GetWorkspaces 获取用户加入的全部工作区，按加入时间排序
+	GetWorkspaces(userID int) ([]models.Workspace, error)
+	// GetWorkspace 获取单个工作区，同时用于检查 userID 是否为成员
+	GetWorkspace(id int, userID int) (models.Workspace, error)
+	// RenameWorkspace 修改工作区名称
+	RenameWorkspace(id int, name string, userID int) error
+	// GetWorkspaceMembers 获取工作区成员，按加入时间排序
+	GetWorkspaceMembers(id int, userID int) ([]models.WorkspaceMemberInfo, error)
+	// AddWorkspaceMember 把 email 对应的已注册用户加入工作区，用户不存在时返回 ErrUserNotFound，已是成员时返回 ErrAlreadyMember
+	AddWorkspaceMember(id int, email string, role models.WorkspaceRole, userID int) error
+	// UpdateWorkspaceMember 修改成员角色，工作区必须至少保留一名管理员，否则返回 ErrLastAdmin
+	UpdateWorkspaceMember(id int, memberID int, role models.WorkspaceRole, userID int) error
+	// RemoveWorkspaceMember 移除成员，撤销其在该工作区项目中的共享权限并取消对其的指派；成员也可以移除自己以退出工作区
+	RemoveWorkspaceMember(id int, memberID int, userID int) error
+}
+
+// TokenStore 刷新令牌和已撤销访问令牌的持久化接口
+type TokenStore interface {
+	// CreateRefreshToken 保存新登录签发的刷新令牌，同时清理该用户已过期的刷新令牌
+	CreateRefreshToken(token models.RefreshToken) error
+	// RotateRefreshToken 用掉摘要为 hash 的刷新令牌并在同一族中保存 next，令牌已经使用过时撤销整个族并返回 ErrTokenReused
+	RotateRefreshToken(hash string, next models.RefreshToken) (models.RefreshToken, error)
+	// RevokeTokenFamily 撤销令牌族中全部未撤销的刷新令牌
+	RevokeTokenFamily(familyID string) error
+	// SetFamilyWorkspace 修改令牌族刷新时进入的工作区，切换工作区时调用
+	SetFamilyWorkspace(familyID string, workspaceID int) error
+	// RevokeUserSessions 撤销用户全部未撤销的刷新令牌，重置密码后调用
+	RevokeUserSessions(userID int) error
+	// RevokeOtherSessions 撤销用户除 keepFamilyID 之外全部未撤销的刷新令牌，开启两步验证后调用
+	RevokeOtherSessions(userID int, keepFamilyID string) error
+	// RevokeAccessToken 把访问令牌的 jti 加入撤销列表直到 expiresAt，同时清理已过期的记录
+	RevokeAccessToken(jti string, expiresAt time.Time) error
+	// IsAccessTokenRevoked 判断访问令牌是否已被撤销
+	IsAccessTokenRevoked(jti string) (bool, error)
+	// IsSessionRevoked 判断令牌族是否已被撤销
+	IsSessionRevoked(familyID string) (bool, error)
+}
+
+// LastUsedInterval UsePersonalToken 更新 LastUsedAt 的最小间隔
+const LastUsedInterval = time.Minute
+
+// PersonalTokenStore 个人访问令牌的持久化接口，令牌只保存摘要
+type PersonalTokenStore interface {
+	// CreatePersonalToken 保存个人访问令牌并返回其ID
+	CreatePersonalToken(token models.PersonalAccessToken) (int64, error)
+	// GetPersonalTokens 获取用户的全部个人访问令牌（包括已过期的），按创建时间倒序
+	GetPersonalTokens(userID int) ([]models.PersonalAccessToken, error)
+	// DeletePersonalToken 撤销个人访问令牌，不属于 userID 时返回 ErrPersonalTokenNotFound
+	DeletePersonalToken(id int, userID int) error
+	// UsePersonalToken 通过摘要查找令牌，距上次记录超过 LastUsedInterval 时更新 LastUsedAt
+	UsePersonalToken(hash string) (models.PersonalAccessToken, error)
+}
+
+// EmailTokenStore 通过邮件发送的一次性令牌（邮箱验证、密码重置）的持久化接口，令牌只保存摘要
+type EmailTokenStore interface {
+	// CreateEmailToken 保存令牌，同时作废该用户同一用途的旧令牌并清理已过期的令牌
+	CreateEmailToken(token models.EmailToken) error
+	// ConsumeEmailToken 用掉摘要为 hash、用途为 purpose 的令牌，无效时返回 ErrInvalidEmailToken
+	ConsumeEmailToken(hash string, purpose models.TokenPurpose) (models.EmailToken, error)
+}
+
+// TwoFactorStore 两步验证密钥和恢复码的持久化接口，恢复码只保存摘要
+type TwoFactorStore interface {
+	// GetTOTP 获取用户的两步验证密钥，未绑定时返回 ErrTwoFactorNotFound
+	GetTOTP(userID int) (models.TOTP, error)
+	// SetTOTPSecret 开始绑定：保存未确认的新密钥，替换此前未确认的密钥。已开启时返回 ErrTwoFactorEnabled
+	SetTOTPSecret(userID int, secret string) error
+	// EnableTOTP 确认绑定，记录已使用的时间步并用 recoveryHashes 替换全部恢复码
+	EnableTOTP(userID int, step int64, recoveryHashes []string) error
+	// DisableTOTP 删除用户的两步验证密钥和全部恢复码
+	DisableTOTP(userID int) error
+	// UseTOTPStep 把时间步 step 记录为已使用，step 不晚于上次记录的时间步时返回 ErrCodeReused
+	UseTOTPStep(userID int, step int64) error
+	// UseRecoveryCode 把摘要为 hash 的恢复码标记为已使用，不存在或已使用时返回 ErrInvalidRecoveryCode
+	UseRecoveryCode(userID int, hash string) error
+	// ReplaceRecoveryCodes 作废用户的全部恢复码并保存新的恢复码
+	ReplaceRecoveryCodes(userID int, hashes []string) error
+	// CountRecoveryCodes 返回用户未使用的恢复码数量
+	CountRecoveryCodes(userID int) (int, error)
+}
+
+// TenantStore 限定在单个工作区内的存储接口，通过它进行的任何查询都只会读写该工作区的数据
+type TenantStore interface {
+	TodoStore
+	TagStore
+	CommentStore
+	AttachmentStore
+	ProjectStore
+	ShareStore
+	SmartListStore
+}
+
+// Store 聚合了 API 服务需要的全部存储接口，工作区内的数据只能通过 Workspace 返回的视图访问
+type Store interface {
+	UserStore
+	WorkspaceStore
+	TokenStore
+	PersonalTokenStore
+	EmailTokenStore
+	TwoFactorStore
+	// Workspace 返回限定在 workspaceID 内的存储视图
+	Workspace(workspaceID int) TenantStore
+	// RebalancePositions 把全部工作区中 NeedsRebalance 的同级组重新编号，返回重新编号的组数，由定期任务调用
+	RebalancePositions() (int, error)
+}
@@ -0,0 +1,32 @@
+package store
+
+import (
+	"time"
+
+	"github.com/joy_project/todo-list-backend/internal/models"
+)
+
+// OnlyCompletionChanged 判断 todo 相对已保存的 stored 是否只修改了完成状态
+func OnlyCompletionChanged(stored, todo models.Todo) bool {
+	return todo.Title == stored.Title &&
+		todo.Priority == stored.Priority &&
+		todo.Recurrence == stored.Recurrence &&
+		sameInt(todo.AssigneeID, stored.AssigneeID) &&
+		sameTime(todo.DueAt, stored.DueAt) &&
+		sameTime(todo.StartAt, stored.StartAt) &&
+		sameTime(todo.RemindAt, stored.RemindAt)
+}
+
+func sameInt(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
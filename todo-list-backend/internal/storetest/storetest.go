@@ -0,0 +1,67 @@
+// Package storetest 在内存存储和 SQLite 上各运行一次同一组用例
+package storetest
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+
+	"github.com/joy_project/todo-list-backend/internal/database"
+	"github.com/joy_project/todo-list-backend/internal/memstore"
+	"github.com/joy_project/todo-list-backend/internal/migrate"
+	"github.com/joy_project/todo-list-backend/internal/store"
+)
+
+// Backend 一个可在测试中创建的存储后端
+type Backend struct {
+	Name string
+	New  func(t testing.TB) store.Store
+}
+
+// Backends 返回内存存储和 SQLite 两个后端
+func Backends() []Backend {
+	return []Backend{
+		{Name: "memory", New: func(testing.TB) store.Store { return memstore.New() }},
+		{Name: database.DriverSQLite, New: NewSQLite},
+	}
+}
+
+// OpenSQLite 在测试的临时目录中创建未应用任何迁移的 SQLite 数据库，测试结束时关闭连接
+func OpenSQLite(t testing.TB) *database.Store {
+	t.Helper()
+
+	db, err := database.Open(database.Options{
+		Driver: database.DriverSQLite,
+		DSN:    "file:" + filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// NewSQLite 创建 SQLite 数据库并应用全部迁移
+func NewSQLite(t testing.TB) store.Store {
+	t.Helper()
+
+	db := OpenSQLite(t)
+	m, err := migrate.New(db.DB(), database.DriverSQLite, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// Run 在每个后端的新存储上运行 fn，每个后端是一个子测试
+func Run(t *testing.T, fn func(t *testing.T, st store.Store)) {
+	for _, b := range Backends() {
+		t.Run(b.Name, func(t *testing.T) {
+			fn(t, b.New(t))
+		})
+	}
+}
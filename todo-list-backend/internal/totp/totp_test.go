@@ -0,0 +1,98 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 6238 附录 B 中 SHA1 用例的密钥 "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238(t *testing.T) {
+	// 附录 B 给出 8 位验证码，这里取其末 6 位
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func code(t *testing.T, step int64) string {
+	t.Helper()
+	c, err := Code(rfcSecret, step)
+	if err != nil {
+		t.Fatalf("Code: %v", err)
+	}
+	return c
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Step(now)
+
+	tests := []struct {
+		step int64
+		ok   bool
+	}{
+		{current - 2, false},
+		{current - 1, true},
+		{current, true},
+		{current + 1, true},
+		{current + 2, false},
+	}
+	for _, tt := range tests {
+		step, ok := Validate(rfcSecret, code(t, tt.step), now, 0)
+		if ok != tt.ok {
+			t.Errorf("step %+d: ok = %v, want %v", tt.step-current, ok, tt.ok)
+		}
+		if ok && step != tt.step {
+			t.Errorf("step %+d: matched step %d, want %d", tt.step-current, step, tt.step)
+		}
+	}
+}
+
+func TestValidateRejectsReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Step(now)
+	c := code(t, current)
+
+	step, ok := Validate(rfcSecret, c, now, 0)
+	if !ok || step != current {
+		t.Fatalf("first use = %d, %v; want %d, true", step, ok, current)
+	}
+	// 同一时间步的验证码不能再次使用，同一时间步内稍后提交也一样
+	if _, ok := Validate(rfcSecret, c, now.Add(10*time.Second), step); ok {
+		t.Error("same-step code accepted twice")
+	}
+	// 早于上次使用的时间步也被拒绝，即使仍在容忍范围内
+	if _, ok := Validate(rfcSecret, code(t, current-1), now, step); ok {
+		t.Error("code from an earlier step accepted after a later one was used")
+	}
+	if _, ok := Validate(rfcSecret, code(t, current+1), now, step); !ok {
+		t.Error("code from the next step rejected")
+	}
+}
+
+func TestValidateRejectsMalformed(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	for _, c := range []string{"", "12345", "1234567", "abcdef"} {
+		if _, ok := Validate(rfcSecret, c, now, 0); ok {
+			t.Errorf("Validate(%q) accepted", c)
+		}
+	}
+}
@@ -2,12 +2,18 @@ package validator
 
 import (
 	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/joy_project/todo-list-backend/internal/models"
+	"github.com/joy_project/todo-list-backend/internal/recurrence"
 )
 
 var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
 
+var colorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
 func ValidateTodo(todo models.Todo) map[string]string {
 	errors := make(map[string]string)
 
@@ -23,9 +29,53 @@ func ValidateTodo(todo models.Todo) map[string]string {
 		errors["priority"] = "Priority must be low, medium, or high"
 	}
 
+	if todo.AssigneeID != nil && *todo.AssigneeID <= 0 {
+		errors["assignee_id"] = "Assignee must be a valid user ID"
+	}
+
+	// 验证日期
+	if todo.StartAt != nil && todo.DueAt != nil && todo.StartAt.After(*todo.DueAt) {
+		errors["start_at"] = "Start date must not be after the due date"
+	}
+
+	if todo.RemindAt != nil && todo.DueAt != nil && todo.RemindAt.After(*todo.DueAt) {
+		errors["remind_at"] = "Reminder must not be after the due date"
+	}
+
+	// 验证重复规则
+	if todo.Recurrence != "" {
+		if _, err := recurrence.Parse(todo.Recurrence); err != nil {
+			errors["recurrence"] = "Invalid recurrence rule: " + err.Error()
+		} else if todo.DueAt == nil {
+			errors["recurrence"] = "Recurring todos must have a due date"
+		}
+	}
+
 	return errors
 }
 
+// ValidateTimezone 验证 IANA 时区名，空字符串视为 UTC
+func ValidateTimezone(timezone string) string {
+	if timezone == "" {
+		return ""
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return "Unknown timezone"
+	}
+	return ""
+}
+
+// ValidatePassword 验证新密码，返回空字符串表示有效
+func ValidatePassword(password string) string {
+	if password == "" {
+		return "Password is required"
+	}
+	if len(password) < 6 {
+		return "Password must be at least 6 characters"
+	}
+	return ""
+}
+
 func ValidateRegister(req models.RegisterRequest) map[string]string {
 	errors := make(map[string]string)
 
@@ -46,10 +96,13 @@ func ValidateRegister(req models.RegisterRequest) map[string]string {
 	}
 
 	// 验证密码
-	if req.Password == "" {
-		errors["password"] = "Password is required"
-	} else if len(req.Password) < 6 {
-		errors["password"] = "Password must be at least 6 characters"
+	if msg := ValidatePassword(req.Password); msg != "" {
+		errors["password"] = msg
+	}
+
+	// 验证时区
+	if msg := ValidateTimezone(req.Timezone); msg != "" {
+		errors["timezone"] = msg
 	}
 
 	return errors
@@ -72,3 +125,196 @@ func ValidateLogin(req models.LoginRequest) map[string]string {
 
 	return errors
 }
+
+func ValidateTag(tag models.Tag) map[string]string {
+	errors := make(map[string]string)
+
+	// 验证名称，名称会出现在 ?tag= 查询参数中，因此不允许逗号和首尾空白
+	if tag.Name == "" {
+		errors["name"] = "Name is required"
+	} else if utf8.RuneCountInString(tag.Name) > 50 {
+		errors["name"] = "Name must be at most 50 characters"
+	} else if strings.TrimSpace(tag.Name) != tag.Name {
+		errors["name"] = "Name must not start or end with whitespace"
+	} else if strings.ContainsAny(tag.Name, ",\t\r\n") {
+		errors["name"] = "Name must not contain commas or control characters"
+	}
+
+	// 验证颜色
+	if !colorRegex.MatchString(tag.Color) {
+		errors["color"] = "Color must be a hex value like #1e90ff"
+	}
+
+	return errors
+}
+
+func ValidateProject(project models.Project) map[string]string {
+	errors := make(map[string]string)
+
+	// 验证名称
+	if strings.TrimSpace(project.Name) == "" {
+		errors["name"] = "Name is required"
+	} else if utf8.RuneCountInString(project.Name) > 100 {
+		errors["name"] = "Name must be at most 100 characters"
+	}
+
+	// 验证颜色
+	if !colorRegex.MatchString(project.Color) {
+		errors["color"] = "Color must be a hex value like #1e90ff"
+	}
+
+	if project.Position < 0 {
+		errors["position"] = "Position must not be negative"
+	}
+
+	return errors
+}
+
+// ValidateRole 验证协作者角色，返回空字符串表示有效
+func ValidateRole(role models.Role) string {
+	if !role.Valid() {
+		return "Role must be viewer, editor, or owner"
+	}
+	return ""
+}
+
+func ValidateInvite(req models.InviteRequest) map[string]string {
+	errors := make(map[string]string)
+
+	// 验证邮箱
+	if req.Email == "" {
+		errors["email"] = "Email is required"
+	} else if !emailRegex.MatchString(req.Email) {
+		errors["email"] = "Invalid email format"
+	}
+
+	if msg := ValidateRole(req.Role); msg != "" {
+		errors["role"] = msg
+	}
+
+	return errors
+}
+
+func ValidateWorkspace(workspace models.Workspace) map[string]string {
+	errors := make(map[string]string)
+
+	// 验证名称
+	if strings.TrimSpace(workspace.Name) == "" {
+		errors["name"] = "Name is required"
+	} else if utf8.RuneCountInString(workspace.Name) > 100 {
+		errors["name"] = "Name must be at most 100 characters"
+	}
+
+	return errors
+}
+
+// ValidateWorkspaceRole 验证工作区角色，返回空字符串表示有效
+func ValidateWorkspaceRole(role models.WorkspaceRole) string {
+	if !role.Valid() {
+		return "Role must be admin or member"
+	}
+	return ""
+}
+
+func ValidateWorkspaceMember(req models.AddWorkspaceMemberRequest) map[string]string {
+	errors := make(map[string]string)
+
+	// 验证邮箱
+	if req.Email == "" {
+		errors["email"] = "Email is required"
+	} else if !emailRegex.MatchString(req.Email) {
+		errors["email"] = "Invalid email format"
+	}
+
+	if msg := ValidateWorkspaceRole(req.Role); msg != "" {
+		errors["role"] = msg
+	}
+
+	return errors
+}
+
+func ValidateComment(comment models.Comment) map[string]string {
+	errors := make(map[string]string)
+
+	// 验证正文，Markdown 原样保存
+	if strings.TrimSpace(comment.Body) == "" {
+		errors["body"] = "Body is required"
+	} else if utf8.RuneCountInString(comment.Body) > 10000 {
+		errors["body"] = "Body must be at most 10000 characters"
+	}
+
+	return errors
+}
+
+func ValidateSmartList(list models.SmartList) map[string]string {
+	errors := make(map[string]string)
+
+	// 验证名称
+	if strings.TrimSpace(list.Name) == "" {
+		errors["name"] = "Name is required"
+	} else if utf8.RuneCountInString(list.Name) > 100 {
+		errors["name"] = "Name must be at most 100 characters"
+	}
+
+	// 查询参数的内容由 API 层按列表过滤参数校验，这里只限制长度
+	if len(list.Query) > 2000 {
+		errors["query"] = "Query must be at most 2000 characters"
+	}
+
+	return errors
+}
+
+func ValidatePersonalToken(req models.CreatePersonalTokenRequest) map[string]string {
+	errors := make(map[string]string)
+
+	// 验证名称
+	if strings.TrimSpace(req.Name) == "" {
+		errors["name"] = "Name is required"
+	} else if utf8.RuneCountInString(req.Name) > 100 {
+		errors["name"] = "Name must be at most 100 characters"
+	}
+
+	// 验证权限范围
+	if len(req.Scopes) == 0 {
+		errors["scopes"] = "At least one scope is required"
+	}
+	for _, scope := range req.Scopes {
+		if !scope.Valid() {
+			errors["scopes"] = "Unknown scope " + string(scope)
+			break
+		}
+	}
+
+	// 过期时间可以为空，表示永不过期
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		errors["expires_at"] = "Expiry must be in the future"
+	}
+
+	return errors
+}
+
+func ValidateForgotPassword(req models.ForgotPasswordRequest) map[string]string {
+	errors := make(map[string]string)
+
+	// 验证邮箱
+	if req.Email == "" {
+		errors["email"] = "Email is required"
+	} else if !emailRegex.MatchString(req.Email) {
+		errors["email"] = "Invalid email format"
+	}
+
+	return errors
+}
+
+func ValidateResetPassword(req models.ResetPasswordRequest) map[string]string {
+	errors := make(map[string]string)
+
+	if req.Token == "" {
+		errors["token"] = "Token is required"
+	}
+	if msg := ValidatePassword(req.Password); msg != "" {
+		errors["password"] = msg
+	}
+
+	return errors
+}